@@ -37,6 +37,7 @@ type cert struct {
 
 	show       bool
 	thumbprint bool
+	trust      bool
 }
 
 func init() {
@@ -52,6 +53,7 @@ func (cmd *cert) Register(ctx context.Context, f *flag.FlagSet) {
 
 	f.BoolVar(&cmd.show, "show", false, "Show PEM encoded server certificate only")
 	f.BoolVar(&cmd.thumbprint, "thumbprint", false, "Output host hash and thumbprint only")
+	f.BoolVar(&cmd.trust, "trust", false, "Add host thumbprint to the TLS known hosts file")
 }
 
 func (cmd *cert) Description() string {
@@ -60,11 +62,13 @@ func (cmd *cert) Description() string {
 If the HOST certificate cannot be verified, about.cert will return with exit code 60 (as curl does).
 If the '-k' flag is provided, about.cert will return with exit code 0 in this case.
 The SHA1 thumbprint can also be used as '-thumbprint' for the 'host.add' and 'cluster.add' commands.
+The '-trust' flag pins the SHA1 thumbprint in the TLS known hosts file, defaulting to ~/.govmomi/known_hosts.
 
 Examples:
   govc about.cert -k -json | jq -r .ThumbprintSHA1
   govc about.cert -k -show | sudo tee /usr/local/share/ca-certificates/host.crt
-  govc about.cert -k -thumbprint | tee -a ~/.govmomi/known_hosts`
+  govc about.cert -k -thumbprint | tee -a ~/.govmomi/known_hosts
+  govc about.cert -k -trust`
 }
 
 func (cmd *cert) Process(ctx context.Context) error {
@@ -110,7 +114,13 @@ func (cmd *cert) Run(ctx context.Context, f *flag.FlagSet) error {
 		return err
 	}
 
-	if r.info.Err != nil && r.cmd.IsSecure() {
+	if cmd.trust {
+		if err := soap.AddKnownHost(cmd.KnownHosts(), u.Host, r.info.ThumbprintSHA1); err != nil {
+			return err
+		}
+	}
+
+	if r.info.Err != nil && r.cmd.IsSecure() && !cmd.trust {
 		cmd.Out = os.Stderr
 		// using same exit code as curl:
 		defer os.Exit(60)
@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/vmware/govmomi/govc/cli"
+	"github.com/vmware/govmomi/govc/flags"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type find struct {
+	*flags.DatacenterFlag
+
+	Type  string
+	Where string
+	ToRef bool
+}
+
+func init() {
+	cli.Register("find", &find{})
+}
+
+func (cmd *find) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.DatacenterFlag, ctx = flags.NewDatacenterFlag(ctx)
+	cmd.DatacenterFlag.Register(ctx, f)
+
+	f.StringVar(&cmd.Type, "type", "VirtualMachine", "Managed object type")
+	f.StringVar(&cmd.Where, "where", "", "Property expression")
+	f.BoolVar(&cmd.ToRef, "i", false, "Print the managed object reference")
+}
+
+func (cmd *find) Description() string {
+	return `Find managed objects of the given type within each PATH, matching the property expression.
+
+The expression compares properties of the managed object type using ==, !=, <, <=, >, >=,
+=~ and !~ (regular expression), combined with &&, || and !.  The age function
+compares a time property with a duration such as 12h or 7d and the len function
+counts the values of an array property.  PATH defaults to the root folder.
+
+Examples:
+  govc find -where 'runtime.powerState == "poweredOn" && summary.config.memorySizeMB >= 4096'
+  govc find -where 'name =~ "^web-" && !summary.config.template' /dc1/vm
+  govc find -where 'age(snapshot.rootSnapshotList.createTime) > 7d'
+  govc find -type HostSystem -where 'runtime.inMaintenanceMode' -i`
+}
+
+func (cmd *find) Usage() string {
+	return "[PATH]..."
+}
+
+func (cmd *find) Process(ctx context.Context) error {
+	if err := cmd.DatacenterFlag.Process(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cmd *find) Run(ctx context.Context, f *flag.FlagSet) error {
+	q, err := property.NewQuery(cmd.Type, cmd.Where)
+	if err != nil {
+		return err
+	}
+
+	c, err := cmd.Client()
+	if err != nil {
+		return err
+	}
+
+	roots, err := cmd.ManagedObjects(ctx, f.Args())
+	if err != nil {
+		return err
+	}
+
+	r := findResult{find: cmd}
+	seen := make(map[types.ManagedObjectReference]bool)
+
+	for _, root := range roots {
+		objs, err := q.Run(ctx, c, root, "name")
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objs {
+			ref := obj.(mo.Reference).Reference()
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			o := findObject{Ref: ref}
+			if e, ok := obj.(mo.IsManagedEntity); ok {
+				o.Name = e.GetManagedEntity().Name
+			}
+
+			r.Objects = append(r.Objects, o)
+		}
+	}
+
+	return cmd.WriteResult(&r)
+}
+
+type findObject struct {
+	Ref  types.ManagedObjectReference `json:"ref"`
+	Name string                       `json:"name,omitempty"`
+}
+
+type findResult struct {
+	*find
+	Objects []findObject `json:"objects"`
+}
+
+func (r *findResult) Write(w io.Writer) error {
+	for _, o := range r.Objects {
+		var err error
+
+		if r.ToRef || o.Name == "" {
+			_, err = fmt.Fprintln(w, o.Ref.String())
+		} else {
+			_, err = fmt.Fprintln(w, o.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
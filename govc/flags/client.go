@@ -18,9 +18,7 @@ package flags
 
 import (
 	"context"
-	"crypto/sha1"
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -31,9 +29,9 @@ import (
 	"strings"
 
 	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/session/cache"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/soap"
-	"github.com/vmware/govmomi/vim25/types"
 )
 
 const (
@@ -49,6 +47,7 @@ const (
 	envVimVersion    = "GOVC_VIM_VERSION"
 	envTLSCaCerts    = "GOVC_TLS_CA_CERTS"
 	envTLSKnownHosts = "GOVC_TLS_KNOWN_HOSTS"
+	envTLSTOFU       = "GOVC_TLS_TOFU"
 )
 
 const cDescr = "ESX or vCenter URL"
@@ -70,6 +69,7 @@ type ClientFlag struct {
 	vimVersion    string
 	tlsCaCerts    string
 	tlsKnownHosts string
+	tlsTOFU       bool
 	tlsHostHash   string
 
 	client *vim25.Client
@@ -197,10 +197,7 @@ func (flag *ClientFlag) Register(ctx context.Context, f *flag.FlagSet) {
 
 		{
 			value := os.Getenv(envVimVersion)
-			if value == "" {
-				value = soap.DefaultVimVersion
-			}
-			usage := fmt.Sprintf("Vim version [%s]", envVimVersion)
+			usage := fmt.Sprintf("Vim version, negotiated with the server if empty [%s]", envVimVersion)
 			f.StringVar(&flag.vimVersion, "vim-version", value, usage)
 		}
 
@@ -212,9 +209,20 @@ func (flag *ClientFlag) Register(ctx context.Context, f *flag.FlagSet) {
 
 		{
 			value := os.Getenv(envTLSKnownHosts)
-			usage := fmt.Sprintf("TLS known hosts file [%s]", envTLSKnownHosts)
+			usage := fmt.Sprintf("TLS known hosts file, defaults to $GOVMOMI_HOME/known_hosts [%s]", envTLSKnownHosts)
 			f.StringVar(&flag.tlsKnownHosts, "tls-known-hosts", value, usage)
 		}
+
+		{
+			tofu := false
+			switch env := strings.ToLower(os.Getenv(envTLSTOFU)); env {
+			case "1", "true":
+				tofu = true
+			}
+
+			usage := fmt.Sprintf("Trust unverified hosts on first use, recording thumbprints to the TLS known hosts file [%s]", envTLSTOFU)
+			f.BoolVar(&flag.tlsTOFU, "tls-tofu", tofu, usage)
+		}
 	})
 }
 
@@ -267,111 +275,44 @@ func (flag *ClientFlag) configure(sc *soap.Client) (soap.RoundTripper, error) {
 		return nil, err
 	}
 
-	if err := sc.LoadThumbprints(flag.tlsKnownHosts); err != nil {
+	if err := sc.LoadThumbprints(flag.KnownHosts()); err != nil {
 		return nil, err
 	}
 
+	if flag.tlsTOFU {
+		if err := sc.SetKnownHosts(flag.KnownHosts()); err != nil {
+			return nil, err
+		}
+	}
+
 	// Retry twice when a temporary I/O error occurs.
 	// This means a maximum of 3 attempts.
 	return vim25.Retry(sc, vim25.TemporaryNetworkError(3)), nil
 }
 
-func (flag *ClientFlag) sessionFile() string {
-	url := flag.URLWithoutPassword()
-
-	// Key session file off of full URI and insecure setting.
-	// Hash key to get a predictable, canonical format.
-	key := fmt.Sprintf("%s#insecure=%t", url.String(), flag.insecure)
-	name := fmt.Sprintf("%040x", sha1.Sum([]byte(key)))
-	return filepath.Join(home, "sessions", name)
-}
-
-func (flag *ClientFlag) saveClient(c *vim25.Client) error {
-	if !flag.persist {
-		return nil
-	}
-
-	p := flag.sessionFile()
-	err := os.MkdirAll(filepath.Dir(p), 0700)
-	if err != nil {
-		return err
-	}
-
-	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	err = json.NewEncoder(f).Encode(c)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (flag *ClientFlag) restoreClient(c *vim25.Client) (bool, error) {
-	if !flag.persist {
-		return false, nil
-	}
+// sessionCache returns a cache for sessions persisted under $GOVMOMI_HOME/sessions
+func (flag *ClientFlag) sessionCache() *cache.Cache {
+	c := cache.New(&cache.FileStore{Dir: filepath.Join(home, "sessions")})
 
-	f, err := os.Open(flag.sessionFile())
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
+	c.Configure = func(vc *vim25.Client) error {
+		rt, err := flag.configure(vc.Client)
+		if err != nil {
+			return err
 		}
 
-		return false, err
-	}
-
-	defer f.Close()
-
-	dec := json.NewDecoder(f)
-	err = dec.Decode(c)
-	if err != nil {
-		return false, err
+		vc.RoundTripper = rt
+		return nil
 	}
 
-	return true, nil
+	return c
 }
 
-func (flag *ClientFlag) loadClient() (*vim25.Client, error) {
-	c := new(vim25.Client)
-	ok, err := flag.restoreClient(c)
-	if err != nil {
-		return nil, err
-	}
-
-	if !ok || !c.Valid() {
-		return nil, nil
-	}
-
-	c.RoundTripper, err = flag.configure(c.Client)
-	if err != nil {
-		return nil, err
-	}
-
-	m := session.NewManager(c)
-	u, err := m.UserSession(context.TODO())
-	if err != nil {
-		if soap.IsSoapFault(err) {
-			fault := soap.ToSoapFault(err).VimFault()
-			// If the PropertyCollector is not found, the saved session for this URL is not valid
-			if _, ok := fault.(types.ManagedObjectNotFound); ok {
-				return nil, nil
-			}
-		}
-
-		return nil, err
-	}
-
-	// If the session is nil, the client is not authenticated
-	if u == nil {
-		return nil, nil
+// KnownHosts returns the TLS known hosts file, which defaults to $GOVMOMI_HOME/known_hosts
+func (flag *ClientFlag) KnownHosts() string {
+	if flag.tlsKnownHosts == "" {
+		return filepath.Join(home, "known_hosts")
 	}
-
-	return c, nil
+	return flag.tlsKnownHosts
 }
 
 func (flag *ClientFlag) SetRootCAs(c *soap.Client) error {
@@ -381,8 +322,7 @@ func (flag *ClientFlag) SetRootCAs(c *soap.Client) error {
 	return nil
 }
 
-func (flag *ClientFlag) newClient() (*vim25.Client, error) {
-	ctx := context.TODO()
+func (flag *ClientFlag) newClient(ctx context.Context) (*vim25.Client, error) {
 	sc := soap.NewClient(flag.url, flag.insecure)
 	isTunnel := false
 
@@ -398,7 +338,9 @@ func (flag *ClientFlag) newClient() (*vim25.Client, error) {
 
 	// Set namespace and version
 	sc.Namespace = flag.vimNamespace
-	sc.Version = flag.vimVersion
+	if flag.vimVersion != "" {
+		sc.Version = flag.vimVersion
+	}
 
 	rt, err := flag.configure(sc)
 	if err != nil {
@@ -413,6 +355,12 @@ func (flag *ClientFlag) newClient() (*vim25.Client, error) {
 	// Set client, since we didn't pass it in the constructor
 	c.Client = sc
 
+	if flag.vimVersion == "" {
+		if err = c.UseServiceVersion(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	m := session.NewManager(c)
 	u := flag.url.User
 
@@ -436,11 +384,6 @@ func (flag *ClientFlag) newClient() (*vim25.Client, error) {
 		}
 	}
 
-	err = flag.saveClient(c)
-	if err != nil {
-		return nil, err
-	}
-
 	return c, nil
 }
 
@@ -466,23 +409,18 @@ func apiVersionValid(c *vim25.Client, minVersionString string) error {
 		return nil
 	}
 
-	apiVersion := c.ServiceContent.About.ApiVersion
-	if strings.HasSuffix(apiVersion, ".x") {
-		// Skip version check for development builds
-		return nil
-	}
-
-	realVersion, err := ParseVersion(apiVersion)
-	if err != nil {
-		return err
+	realVersion := c.APIVersion()
+	if realVersion == nil {
+		return fmt.Errorf("invalid API version %q", c.ServiceContent.About.ApiVersion)
 	}
 
-	minVersion, err := ParseVersion(minVersionString)
+	minVersion, err := vim25.ParseVersion(minVersionString)
 	if err != nil {
 		return err
 	}
 
-	if !minVersion.Lte(realVersion) {
+	// Development builds, such as "6.x", compare greater than any released version
+	if realVersion.Compare(minVersion) < 0 {
 		err = fmt.Errorf("Require API version %s, connected to API version %s (set %s to override)",
 			minVersionString,
 			c.ServiceContent.About.ApiVersion,
@@ -498,19 +436,21 @@ func (flag *ClientFlag) Client() (*vim25.Client, error) {
 		return flag.client, nil
 	}
 
-	c, err := flag.loadClient()
+	var c *vim25.Client
+	var err error
+	ctx := context.TODO()
+
+	if flag.persist {
+		// Reuse the session saved to disk if still valid, otherwise login and save the new session
+		key := cache.Key(flag.url, flag.insecure)
+		c, err = flag.sessionCache().Login(ctx, key, flag.newClient)
+	} else {
+		c, err = flag.newClient(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// loadClient returns nil if it was unable to load a session from disk
-	if c == nil {
-		c, err = flag.newClient()
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	// Check that the endpoint has the right API version
 	err = apiVersionValid(c, flag.minAPIVersion)
 	if err != nil {
@@ -31,7 +31,37 @@ import (
 type DebugFlag struct {
 	common
 
-	enable bool
+	enable debugMode
+}
+
+// debugMode is a boolean flag that can also select the debug log format,
+// for example: -debug, -debug=true or -debug=json
+type debugMode string
+
+func (m *debugMode) String() string {
+	if *m == "" {
+		return "false"
+	}
+	return string(*m)
+}
+
+func (m *debugMode) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "1", "true":
+		*m = "true"
+	case "0", "false", "":
+		*m = ""
+	case "json":
+		*m = "json"
+	default:
+		return fmt.Errorf("invalid debug mode: %q", s)
+	}
+
+	return nil
+}
+
+func (m *debugMode) IsBoolFlag() bool {
+	return true
 }
 
 var debugFlagKey = flagKey("debug")
@@ -49,25 +79,25 @@ func NewDebugFlag(ctx context.Context) (*DebugFlag, context.Context) {
 func (flag *DebugFlag) Register(ctx context.Context, f *flag.FlagSet) {
 	flag.RegisterOnce(func() {
 		env := "GOVC_DEBUG"
-		enable := false
-		switch env := strings.ToLower(os.Getenv(env)); env {
-		case "1", "true":
-			enable = true
-		}
+		_ = flag.enable.Set(os.Getenv(env))
 
-		usage := fmt.Sprintf("Store debug logs [%s]", env)
-		f.BoolVar(&flag.enable, "debug", enable, usage)
+		usage := fmt.Sprintf("Store debug logs, set to 'json' for a single redacted JSON stream [%s]", env)
+		f.Var(&flag.enable, "debug", usage)
 	})
 }
 
 func (flag *DebugFlag) Process(ctx context.Context) error {
-	if !flag.enable {
+	if flag.enable == "" {
 		return nil
 	}
 
 	return flag.ProcessOnce(func() error {
 		// Base path for storing debug logs.
 		r := os.Getenv("GOVC_DEBUG_PATH")
+		if r == "-" && flag.enable == "json" {
+			debug.SetProvider(debug.NewJSONProvider(os.Stderr))
+			return nil
+		}
 		if r == "" {
 			r = home
 		}
@@ -84,6 +114,22 @@ func (flag *DebugFlag) Process(ctx context.Context) error {
 			_ = os.RemoveAll(r)
 		}
 
+		if flag.enable == "json" {
+			err := os.MkdirAll(filepath.Dir(r), 0700)
+			if err != nil {
+				return err
+			}
+
+			// Single file for this run, with secrets redacted.
+			f, err := os.OpenFile(r+".json", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+
+			debug.SetProvider(debug.NewJSONProvider(f))
+			return nil
+		}
+
 		err := os.MkdirAll(r, 0700)
 		if err != nil {
 			return err
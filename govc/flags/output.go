@@ -23,9 +23,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
+	vimjson "github.com/vmware/govmomi/vim25/json"
 	"github.com/vmware/govmomi/vim25/progress"
 
 	"github.com/davecgh/go-spew/spew"
@@ -42,6 +44,39 @@ type OutputFlag struct {
 	TTY  bool
 	Dump bool
 	Out  io.Writer
+
+	json jsonMode
+}
+
+// jsonMode is a boolean flag that can also select typed JSON output, which includes
+// the _typeName of data objects and of values held by interface fields such as AnyType,
+// for example: -json, -json=true or -json=typed
+type jsonMode string
+
+func (m *jsonMode) String() string {
+	if *m == "" {
+		return "false"
+	}
+	return string(*m)
+}
+
+func (m *jsonMode) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "1", "true":
+		*m = "true"
+	case "0", "false", "":
+		*m = ""
+	case "typed":
+		*m = "typed"
+	default:
+		return fmt.Errorf("invalid json mode: %q", s)
+	}
+
+	return nil
+}
+
+func (m *jsonMode) IsBoolFlag() bool {
+	return true
 }
 
 var outputFlagKey = flagKey("output")
@@ -58,13 +93,17 @@ func NewOutputFlag(ctx context.Context) (*OutputFlag, context.Context) {
 
 func (flag *OutputFlag) Register(ctx context.Context, f *flag.FlagSet) {
 	flag.RegisterOnce(func() {
-		f.BoolVar(&flag.JSON, "json", false, "Enable JSON output")
+		f.Var(&flag.json, "json", "Enable JSON output, set to 'typed' to include type names")
 		f.BoolVar(&flag.Dump, "dump", false, "Enable output dump")
 	})
 }
 
 func (flag *OutputFlag) Process(ctx context.Context) error {
 	return flag.ProcessOnce(func() error {
+		if flag.json != "" {
+			flag.JSON = true
+		}
+
 		if !flag.JSON {
 			// Assume we have a tty if not outputting JSON
 			flag.TTY = true
@@ -103,7 +142,9 @@ func (flag *OutputFlag) WriteString(s string) (int, error) {
 func (flag *OutputFlag) WriteResult(result OutputWriter) error {
 	var err error
 
-	if flag.JSON {
+	if flag.json == "typed" {
+		err = vimjson.NewEncoder(flag.Out).Encode(result)
+	} else if flag.JSON {
 		err = json.NewEncoder(flag.Out).Encode(result)
 	} else if flag.Dump {
 		scs := spew.ConfigState{Indent: "    "}
@@ -0,0 +1,33 @@
+#!/usr/bin/env bats
+
+load test_helper
+
+@test "find" {
+  vcsim_env
+
+  run govc find -where 'nope == 1'
+  assert_failure
+
+  run govc find -where 'name =~ "("'
+  assert_failure
+
+  run govc find
+  assert_success
+  [ ${#lines[@]} -gt 0 ]
+
+  vm=${lines[0]}
+
+  run govc find -where "name == \"$vm\""
+  assert_output "$vm"
+
+  run govc find -where "name == \"$vm\"" /DC0/vm
+  assert_output "$vm"
+
+  run govc find -where "name == \"$vm\" && !(name =~ \"^$vm\$\")"
+  assert_success ""
+
+  run govc find -type HostSystem -i -where 'len(vm) >= 0'
+  assert_success
+  [ ${#lines[@]} -gt 0 ]
+  [[ "${lines[0]}" == HostSystem:* ]]
+}
@@ -45,6 +45,17 @@ get_property() {
   assert_equal "Evaluation Mode" $(get_key 00000-00000-00000-00000-00000 <<<$output | jq -r ".Name")
 }
 
+@test "license.ls -json=typed" {
+  verify_evaluation
+
+  run govc license.ls -json=typed
+  assert_success
+
+  # Values of AnyType properties include their type name
+  assert_equal "Evaluation Mode" $(get_key 00000-00000-00000-00000-00000 <<<$output | jq -r ".Name")
+  assert_equal "true" $(get_key 00000-00000-00000-00000-00000 <<<$output | jq -r "[.Properties[].Value | has(\"_typeName\")] | all")
+}
+
 @test "license.decode" {
   verify_evaluation
 
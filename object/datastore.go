@@ -226,7 +226,11 @@ func (d Datastore) ServiceTicket(ctx context.Context, path string, method string
 		u.Host = ticket.HostName
 	}
 
-	d.Client().SetThumbprint(u.Host, ticket.SslThumbprint)
+	// The ESX host thumbprint is provided by vCenter.  If it is not, keep any thumbprint
+	// loaded from known hosts, or let the client trust the host on first use.
+	if ticket.SslThumbprint != "" {
+		d.Client().SetThumbprint(u.Host, ticket.SslThumbprint)
+	}
 
 	return u, cookie, nil
 }
@@ -296,6 +300,9 @@ func (d Datastore) DownloadFile(ctx context.Context, path string, file string, p
 	if err != nil {
 		return err
 	}
+	if p.Context == nil {
+		p.Context = ctx
+	}
 	return d.Client().DownloadFile(file, u, p)
 }
 
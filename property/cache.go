@@ -0,0 +1,489 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// CacheEvent describes a change to an object in a Cache.
+type CacheEvent struct {
+	// Kind is enter for objects added to the cache, modify for changed objects
+	// and leave for objects removed from the cache.
+	Kind types.ObjectUpdateKind
+
+	Obj types.ManagedObjectReference
+
+	// Changes holds the changed properties. Unset properties are assigned a nil value.
+	Changes []types.PropertyChange
+
+	// Value is the object after the change, such as mo.VirtualMachine,
+	// or the last known value of the object when Kind is leave.
+	Value interface{}
+}
+
+type cacheEntry struct {
+	props map[string]types.AnyType
+	value interface{}
+}
+
+// Cache maintains a local copy of properties of the managed objects of the given types,
+// found recursively within a container such as the root folder.
+// The Cache creates its own PropertyCollector, ContainerView and filter,
+// loads the initial state and then applies the updates returned by WaitForUpdatesEx.
+// Lookups are safe for concurrent use while Run applies updates.
+//
+// If the collector is lost once the initial state has been loaded, for example when it is destroyed,
+// the Cache creates a new collector and resyncs: objects that no longer exist are removed
+// and changes made in the meantime are applied, notifying subscribers as for any other update.
+// Other faults, including NotAuthenticated when the session has expired, are returned by Run;
+// a client that logs in again, such as one using session.Reauth, avoids the latter.
+type Cache struct {
+	// MaxWaitSeconds bounds the time the server may block each WaitForUpdatesEx call.
+	MaxWaitSeconds int32
+
+	c     *vim25.Client
+	root  types.ManagedObjectReference
+	props map[string][]string
+
+	mu      sync.RWMutex
+	objects map[types.ManagedObjectReference]*cacheEntry
+	version string
+	ready   chan struct{}
+
+	subMu sync.Mutex
+	subs  map[int]func(CacheEvent)
+	nsub  int
+}
+
+// NewCache returns a Cache of the given properties of the managed objects within root.
+// Each key of props is a managed object type name, such as "VirtualMachine",
+// mapped to the property paths to cache, or to nil for all properties.
+// Partial updates are not requested, so paths are cached and reported as a whole.
+func NewCache(c *vim25.Client, root types.ManagedObjectReference, props map[string][]string) *Cache {
+	return &Cache{
+		MaxWaitSeconds: 60,
+
+		c:       c,
+		root:    root,
+		props:   props,
+		objects: make(map[types.ManagedObjectReference]*cacheEntry),
+		ready:   make(chan struct{}),
+	}
+}
+
+// Ready returns a channel that is closed once the initial state has been loaded.
+func (c *Cache) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Version returns the version of the last update applied to the Cache,
+// which changes whenever the collector is recreated.
+func (c *Cache) Version() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.version
+}
+
+// Get returns the cached value for the given object, such as mo.VirtualMachine.
+func (c *Cache) Get(ref types.ManagedObjectReference) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.objects[ref]
+	if !ok {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+type byValue []types.ManagedObjectReference
+
+func (s byValue) Len() int           { return len(s) }
+func (s byValue) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byValue) Less(i, j int) bool { return s[i].Value < s[j].Value }
+
+// List returns the cached values of all objects of the given type, ordered by reference.
+func (c *Cache) List(kind string) []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var refs []types.ManagedObjectReference
+	for ref := range c.objects {
+		if ref.Type == kind {
+			refs = append(refs, ref)
+		}
+	}
+
+	sort.Sort(byValue(refs))
+
+	values := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		values[i] = c.objects[ref].value
+	}
+
+	return values
+}
+
+// Subscribe registers f to be called with each change applied to the Cache.
+// Calls are made by the goroutine executing Run, in the order the changes are applied,
+// so f must not block. The returned func removes the subscription.
+func (c *Cache) Subscribe(f func(CacheEvent)) func() {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.subs == nil {
+		c.subs = make(map[int]func(CacheEvent))
+	}
+
+	id := c.nsub
+	c.nsub++
+	c.subs[id] = f
+
+	return func() {
+		c.subMu.Lock()
+		delete(c.subs, id)
+		c.subMu.Unlock()
+	}
+}
+
+func (c *Cache) notify(events []CacheEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	c.subMu.Lock()
+	ids := make([]int, 0, len(c.subs))
+	for id := range c.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subs := make([]func(CacheEvent), len(ids))
+	for i, id := range ids {
+		subs[i] = c.subs[id]
+	}
+	c.subMu.Unlock()
+
+	for _, e := range events {
+		for _, f := range subs {
+			f(e)
+		}
+	}
+}
+
+// Run loads the initial state and applies updates until ctx is done or an error occurs,
+// recreating the collector if it is lost, waiting longer between each consecutive attempt.
+// An error creating the view, collector or filter, or loading the state, is returned
+// rather than retried. Run can be called again after it returns an error,
+// resyncing the existing cache contents.
+func (c *Cache) Run(ctx context.Context) error {
+	delay := minResyncDelay
+
+	for {
+		start := time.Now()
+
+		synced, err := c.run(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !synced || !isCollectorLost(err) {
+			return err
+		}
+
+		if time.Since(start) > maxResyncDelay {
+			delay = minResyncDelay
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		if delay *= 2; delay > maxResyncDelay {
+			delay = maxResyncDelay
+		}
+	}
+}
+
+// The delay before recreating a lost collector doubles with each consecutive loss,
+// starting over once a collector has been running for longer than maxResyncDelay.
+const (
+	minResyncDelay = time.Second
+	maxResyncDelay = time.Minute
+)
+
+// isCollectorLost returns true if err indicates the collector or its filter no longer exists.
+func isCollectorLost(err error) bool {
+	if soap.IsSoapFault(err) {
+		switch soap.ToSoapFault(err).VimFault().(type) {
+		case types.ManagedObjectNotFound, types.InvalidCollectorVersion:
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterSpec returns the filter for the objects of the Cache types within view.
+func (c *Cache) filterSpec(view types.ManagedObjectReference) (types.PropertyFilterSpec, error) {
+	filter := NewFilterBuilder(view).Skip().Traverse("view")
+
+	for _, kind := range c.kinds() {
+		filter.Properties(kind, c.props[kind]...)
+	}
+
+	return filter.Build()
+}
+
+// kinds returns the managed object types of the Cache, sorted by name.
+func (c *Cache) kinds() []string {
+	var kinds []string
+	for kind := range c.props {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// run creates a collector and applies its updates, starting with a resync of the current state.
+// The returned bool is true if the current state was loaded before the error occurred.
+func (c *Cache) run(ctx context.Context) (bool, error) {
+	// Destroy the view and collector using the background context, as ctx may be done.
+	cleanup := context.Background()
+
+	view, err := createContainerView(ctx, c.c, c.root, c.kinds())
+	if err != nil {
+		return false, err
+	}
+	defer methods.DestroyView(cleanup, c.c, &types.DestroyView{This: view})
+
+	spec, err := c.filterSpec(view)
+	if err != nil {
+		return false, err
+	}
+
+	pc, err := DefaultCollector(c.c).Create(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer pc.Destroy(cleanup)
+
+	err = pc.CreateFilter(ctx, types.CreateFilter{Spec: spec})
+	if err != nil {
+		return false, err
+	}
+
+	opts := types.WaitOptions{MaxWaitSeconds: c.MaxWaitSeconds}
+	version := ""
+
+	// The first update reports all objects as entering, which are compared against
+	// the cache contents until the update is no longer truncated.
+	seen := make(map[types.ManagedObjectReference]bool)
+
+	for {
+		set, err := pc.WaitForUpdatesEx(ctx, version, &opts)
+		if err != nil {
+			if soap.IsSoapFault(err) {
+				if _, ok := soap.ToSoapFault(err).VimFault().(types.RequestCanceled); ok && ctx.Err() == nil {
+					continue
+				}
+			}
+			return seen == nil, err
+		}
+
+		if set != nil {
+			events, err := c.apply(set, seen)
+			if err != nil {
+				return seen == nil, err
+			}
+			c.notify(events)
+
+			version = set.Version
+		}
+
+		if seen != nil && (set == nil || set.Truncated == nil || !*set.Truncated) {
+			c.notify(c.resynced(seen))
+			seen = nil
+		}
+	}
+}
+
+// apply applies the given updates, recording the objects seen during a resync.
+func (c *Cache) apply(set *types.UpdateSet, seen map[types.ManagedObjectReference]bool) ([]CacheEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []CacheEvent
+
+	for _, fs := range set.FilterSet {
+		for _, u := range fs.ObjectSet {
+			if seen != nil {
+				seen[u.Obj] = true
+			}
+
+			event, err := c.update(u)
+			if err != nil {
+				return events, err
+			}
+
+			if event != nil {
+				events = append(events, *event)
+			}
+		}
+	}
+
+	c.version = set.Version
+
+	return events, nil
+}
+
+// update applies a single ObjectUpdate, returning the resulting event, if any.
+func (c *Cache) update(u types.ObjectUpdate) (*CacheEvent, error) {
+	e, exists := c.objects[u.Obj]
+
+	if u.Kind == types.ObjectUpdateKindLeave {
+		if !exists {
+			return nil, nil
+		}
+
+		delete(c.objects, u.Obj)
+		return &CacheEvent{Kind: u.Kind, Obj: u.Obj, Value: e.value}, nil
+	}
+
+	props := make(map[string]types.AnyType)
+	if exists && u.Kind == types.ObjectUpdateKindModify {
+		for name, val := range e.props {
+			props[name] = val
+		}
+	}
+
+	for _, change := range u.ChangeSet {
+		switch change.Op {
+		case types.PropertyChangeOpRemove, types.PropertyChangeOpIndirectRemove:
+			delete(props, change.Name)
+		default:
+			if change.Val == nil {
+				delete(props, change.Name)
+			} else {
+				props[change.Name] = change.Val
+			}
+		}
+	}
+
+	event := CacheEvent{Kind: types.ObjectUpdateKindEnter, Obj: u.Obj, Changes: u.ChangeSet}
+
+	if exists {
+		event.Kind = types.ObjectUpdateKindModify
+
+		if u.Kind == types.ObjectUpdateKindEnter {
+			// An existing object enters again during a resync,
+			// report only the properties that changed in the meantime.
+			event.Changes = diffProps(e.props, props)
+			if len(event.Changes) == 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	content := types.ObjectContent{Obj: u.Obj}
+	for _, name := range sortedNames(props) {
+		content.PropSet = append(content.PropSet, types.DynamicProperty{Name: name, Val: props[name]})
+	}
+
+	value, err := mo.ObjectContentToType(content)
+	if err != nil {
+		return nil, err
+	}
+
+	c.objects[u.Obj] = &cacheEntry{props: props, value: value}
+	event.Value = value
+
+	return &event, nil
+}
+
+// resynced removes the objects that were not seen during a resync and closes the Ready channel.
+func (c *Cache) resynced(seen map[types.ManagedObjectReference]bool) []CacheEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var gone []types.ManagedObjectReference
+	for ref := range c.objects {
+		if !seen[ref] {
+			gone = append(gone, ref)
+		}
+	}
+
+	sort.Sort(byValue(gone))
+
+	var events []CacheEvent
+	for _, ref := range gone {
+		events = append(events, CacheEvent{Kind: types.ObjectUpdateKindLeave, Obj: ref, Value: c.objects[ref].value})
+		delete(c.objects, ref)
+	}
+
+	select {
+	case <-c.ready:
+	default:
+		close(c.ready)
+	}
+
+	return events
+}
+
+func sortedNames(props map[string]types.AnyType) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffProps returns the changes from properties a to b.
+func diffProps(a, b map[string]types.AnyType) []types.PropertyChange {
+	var changes []types.PropertyChange
+
+	for _, name := range sortedNames(b) {
+		if val, ok := a[name]; !ok || !reflect.DeepEqual(val, b[name]) {
+			changes = append(changes, types.PropertyChange{Name: name, Op: types.PropertyChangeOpAssign, Val: b[name]})
+		}
+	}
+
+	for _, name := range sortedNames(a) {
+		if _, ok := b[name]; !ok {
+			changes = append(changes, types.PropertyChange{Name: name, Op: types.PropertyChangeOpAssign})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,194 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// collectorRecorder records the property collectors created through it.
+type collectorRecorder struct {
+	soap.RoundTripper
+	collectors chan types.ManagedObjectReference
+}
+
+func (r *collectorRecorder) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	err := r.RoundTripper.RoundTrip(ctx, req, res)
+	if body, ok := res.(*methods.CreatePropertyCollectorBody); ok && err == nil {
+		r.collectors <- body.Res.Returnval
+	}
+	return err
+}
+
+func TestCache(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		recorder := &collectorRecorder{c.RoundTripper, make(chan types.ManagedObjectReference, 10)}
+		c.RoundTripper = recorder
+
+		cache := NewCache(c, c.ServiceContent.RootFolder, map[string][]string{
+			"VirtualMachine": {"name", "runtime.powerState"},
+			"HostSystem":     {"name"},
+		})
+
+		events := make(chan CacheEvent, 100)
+		unsubscribe := cache.Subscribe(func(e CacheEvent) { events <- e })
+		defer unsubscribe()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- cache.Run(ctx)
+		}()
+
+		select {
+		case <-cache.Ready():
+		case err := <-done:
+			t.Fatal(err)
+		}
+
+		vms := cache.List("VirtualMachine")
+		hosts := cache.List("HostSystem")
+		if len(vms) < 2 || len(hosts) == 0 {
+			t.Fatalf("%d VMs, %d hosts", len(vms), len(hosts))
+		}
+
+		vm := vms[0].(mo.VirtualMachine)
+		if vm.Name == "" || vm.Runtime.PowerState == "" || vm.Config != nil {
+			t.Errorf("vm=%#v", vm)
+		}
+
+		for i := 0; i < len(vms)+len(hosts); i++ {
+			if e := <-events; e.Kind != types.ObjectUpdateKindEnter {
+				t.Errorf("event=%#v", e)
+			}
+		}
+
+		// wait for the event matching f, ignoring others
+		wait := func(f func(CacheEvent) bool) CacheEvent {
+			for {
+				select {
+				case e := <-events:
+					if f(e) {
+						return e
+					}
+				case err := <-done:
+					t.Fatalf("Run returned: %v", err)
+				case <-ctx.Done():
+					t.Fatal(ctx.Err())
+				}
+			}
+		}
+
+		_, err := methods.Rename_Task(ctx, c, &types.Rename_Task{This: vm.Self, NewName: "renamed"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e := wait(func(e CacheEvent) bool { return e.Obj == vm.Self })
+		if e.Kind != types.ObjectUpdateKindModify || len(e.Changes) != 1 || e.Changes[0].Name != "name" {
+			t.Errorf("event=%#v", e)
+		}
+
+		if v, ok := cache.Get(vm.Self); !ok || v.(mo.VirtualMachine).Name != "renamed" {
+			t.Errorf("vm=%#v", v)
+		}
+
+		_, err = methods.Destroy_Task(ctx, c, &types.Destroy_Task{This: vm.Self})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e = wait(func(e CacheEvent) bool { return e.Obj == vm.Self })
+		if e.Kind != types.ObjectUpdateKindLeave || e.Value.(mo.VirtualMachine).Name != "renamed" {
+			t.Errorf("event=%#v", e)
+		}
+
+		if _, ok := cache.Get(vm.Self); ok {
+			t.Error("vm still cached")
+		}
+
+		// Destroy the collector and change the inventory while it is lost
+		vm = vms[1].(mo.VirtualMachine)
+		version := cache.Version()
+
+		_, err = methods.DestroyPropertyCollector(ctx, c, &types.DestroyPropertyCollector{This: <-recorder.collectors})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = methods.Rename_Task(ctx, c, &types.Rename_Task{This: vm.Self, NewName: "resynced"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e = wait(func(e CacheEvent) bool { return e.Obj == vm.Self })
+		if e.Kind != types.ObjectUpdateKindModify || len(e.Changes) != 1 || e.Changes[0].Val != "resynced" {
+			t.Errorf("event=%#v", e)
+		}
+
+		if cache.Version() == version {
+			t.Error("version not updated")
+		}
+
+		cancel()
+
+		if err = <-done; err != context.Canceled {
+			t.Errorf("Run returned: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheRootNotFound(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		root := types.ManagedObjectReference{Type: "Folder", Value: "enoent"}
+
+		cache := NewCache(c, root, map[string][]string{"VirtualMachine": {"name"}})
+
+		err := cache.Run(ctx)
+		if err == nil || ctx.Err() != nil {
+			t.Fatalf("Run returned: %v", err)
+		}
+
+		if _, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound); !ok {
+			t.Errorf("Run returned: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
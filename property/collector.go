@@ -35,6 +35,16 @@ import (
 type Collector struct {
 	roundTripper soap.RoundTripper
 	reference    types.ManagedObjectReference
+
+	// PageSize is the maximum number of objects Retrieve requests per call to
+	// RetrievePropertiesEx or ContinueRetrievePropertiesEx.
+	// If zero, the server's default page size is used.
+	PageSize int32
+
+	// MaxObjectUpdates is the maximum number of object updates Wait and WaitForView
+	// request per call to WaitForUpdatesEx.  Truncated update sets are continued by the
+	// following call.  If zero, the server's default limit is used.
+	MaxObjectUpdates int32
 }
 
 // DefaultCollector returns the session's default property collector.
@@ -64,8 +74,10 @@ func (p *Collector) Create(ctx context.Context) (*Collector, error) {
 	}
 
 	newp := Collector{
-		roundTripper: p.roundTripper,
-		reference:    res.Returnval,
+		roundTripper:     p.roundTripper,
+		reference:        res.Returnval,
+		PageSize:         p.PageSize,
+		MaxObjectUpdates: p.MaxObjectUpdates,
 	}
 
 	return &newp, nil
@@ -98,9 +110,21 @@ func (p *Collector) CreateFilter(ctx context.Context, req types.CreateFilter) er
 }
 
 func (p *Collector) WaitForUpdates(ctx context.Context, v string) (*types.UpdateSet, error) {
+	return p.WaitForUpdatesEx(ctx, v, nil)
+}
+
+// WaitForUpdatesEx returns the changes to the objects selected by this Collector's filters since
+// the given version, or all objects if the version is empty.
+// If opts.MaxWaitSeconds elapses without any changes, a nil UpdateSet is returned,
+// such that the call can be used to poll with a timeout.
+// If the number of changes exceeds opts.MaxObjectUpdates, the UpdateSet is truncated:
+// its Truncated field is set and the remaining changes are returned by calling again with the
+// UpdateSet's Version, without waiting.
+func (p *Collector) WaitForUpdatesEx(ctx context.Context, v string, opts *types.WaitOptions) (*types.UpdateSet, error) {
 	req := types.WaitForUpdatesEx{
 		This:    p.Reference(),
 		Version: v,
+		Options: opts,
 	}
 
 	res, err := methods.WaitForUpdatesEx(ctx, p.roundTripper, &req)
@@ -111,11 +135,105 @@ func (p *Collector) WaitForUpdates(ctx context.Context, v string) (*types.Update
 	return res.Returnval, nil
 }
 
+// CancelWaitForUpdates cancels a blocked WaitForUpdatesEx call,
+// which returns a RequestCanceled fault.
+func (p *Collector) CancelWaitForUpdates(ctx context.Context) error {
+	req := types.CancelWaitForUpdates{
+		This: p.Reference(),
+	}
+
+	_, err := methods.CancelWaitForUpdates(ctx, p.roundTripper, &req)
+	return err
+}
+
 func (p *Collector) RetrieveProperties(ctx context.Context, req types.RetrieveProperties) (*types.RetrievePropertiesResponse, error) {
 	req.This = p.Reference()
 	return methods.RetrieveProperties(ctx, p.roundTripper, &req)
 }
 
+// RetrievePropertiesEx returns the first page of the objects selected by req.SpecSet.
+// If the result has a Token, the next page is returned by ContinueRetrievePropertiesEx.
+// The result is nil if no objects were selected.
+func (p *Collector) RetrievePropertiesEx(ctx context.Context, req types.RetrievePropertiesEx) (*types.RetrieveResult, error) {
+	req.This = p.Reference()
+
+	res, err := methods.RetrievePropertiesEx(ctx, p.roundTripper, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Returnval, nil
+}
+
+// ContinueRetrievePropertiesEx returns the next page of the result identified by token.
+func (p *Collector) ContinueRetrievePropertiesEx(ctx context.Context, token string) (*types.RetrieveResult, error) {
+	req := types.ContinueRetrievePropertiesEx{
+		This:  p.Reference(),
+		Token: token,
+	}
+
+	res, err := methods.ContinueRetrievePropertiesEx(ctx, p.roundTripper, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res.Returnval, nil
+}
+
+// CancelRetrievePropertiesEx discards the remaining pages of the result identified by token.
+func (p *Collector) CancelRetrievePropertiesEx(ctx context.Context, token string) error {
+	req := types.CancelRetrievePropertiesEx{
+		This:  p.Reference(),
+		Token: token,
+	}
+
+	_, err := methods.CancelRetrievePropertiesEx(ctx, p.roundTripper, &req)
+	return err
+}
+
+// RetrieveAll returns the objects selected by specs, calling RetrievePropertiesEx and
+// ContinueRetrievePropertiesEx to retrieve every page of the result, using PageSize if set.
+func (p *Collector) RetrieveAll(ctx context.Context, specs []types.PropertyFilterSpec) ([]types.ObjectContent, error) {
+	req := types.RetrievePropertiesEx{
+		SpecSet: specs,
+		Options: types.RetrieveOptions{MaxObjects: p.PageSize},
+	}
+
+	res, err := p.RetrievePropertiesEx(ctx, req)
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	objects := res.Objects
+
+	for res.Token != "" {
+		token := res.Token
+
+		res, err = p.ContinueRetrievePropertiesEx(ctx, token)
+		if err != nil {
+			// Release the remaining pages using the background context, as ctx may be done.
+			_ = p.CancelRetrievePropertiesEx(context.Background(), token)
+			return nil, err
+		}
+
+		objects = append(objects, res.Objects...)
+	}
+
+	return objects, nil
+}
+
+// RetrieveStream calls RetrieveProperties with the objects selected by specs, calling fn with each
+// ObjectContent as it is decoded, rather than holding the response in memory as a whole.
+// See mo.RetrievePropertiesStream, including how fn is called when the request is sent again.
+func (p *Collector) RetrieveStream(ctx context.Context, specs []types.PropertyFilterSpec, fn func(types.ObjectContent) error) error {
+	req := types.RetrieveProperties{
+		This:    p.Reference(),
+		SpecSet: specs,
+	}
+
+	return mo.RetrievePropertiesStream(ctx, p.roundTripper, req, fn)
+}
+
 // Retrieve loads properties for a slice of managed objects. The dst argument
 // must be a pointer to a []interface{}, which is populated with the instances
 // of the specified managed objects, with the relevant properties filled in. If
@@ -150,21 +268,21 @@ func (p *Collector) Retrieve(ctx context.Context, objs []types.ManagedObjectRefe
 		objectSet = append(objectSet, objectSpec)
 	}
 
-	req := types.RetrieveProperties{
-		SpecSet: []types.PropertyFilterSpec{
-			{
-				ObjectSet: objectSet,
-				PropSet:   []types.PropertySpec{*propSpec},
-			},
+	specs := []types.PropertyFilterSpec{
+		{
+			ObjectSet: objectSet,
+			PropSet:   []types.PropertySpec{*propSpec},
 		},
 	}
 
-	res, err := p.RetrieveProperties(ctx, req)
+	objects, err := p.RetrieveAll(ctx, specs)
 	if err != nil {
 		return err
 	}
 
-	return mo.LoadRetrievePropertiesResponse(res, dst)
+	res := types.RetrievePropertiesResponse{Returnval: objects}
+
+	return mo.LoadRetrievePropertiesResponse(&res, dst)
 }
 
 // RetrieveOne calls Retrieve with a single managed object reference.
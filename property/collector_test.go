@@ -0,0 +1,275 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// vmSpec selects all VMs within the root folder, using a ContainerView.
+func vmSpec(ctx context.Context, t *testing.T, c *vim25.Client, ps ...string) types.PropertyFilterSpec {
+	res, err := methods.CreateContainerView(ctx, c, &types.CreateContainerView{
+		This:      *c.ServiceContent.ViewManager,
+		Container: c.ServiceContent.RootFolder,
+		Type:      []string{"VirtualMachine"},
+		Recursive: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return types.PropertyFilterSpec{
+		ObjectSet: []types.ObjectSpec{
+			{
+				Obj:  res.Returnval,
+				Skip: types.NewBool(true),
+				SelectSet: []types.BaseSelectionSpec{
+					&types.TraversalSpec{
+						Path: "view",
+						Type: "ContainerView",
+					},
+				},
+			},
+		},
+		PropSet: []types.PropertySpec{{Type: "VirtualMachine", PathSet: ps}},
+	}
+}
+
+// methodCounter counts the calls to each method made through it.
+type methodCounter struct {
+	soap.RoundTripper
+	calls map[string]int
+}
+
+func (c *methodCounter) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	c.calls[soap.MethodName(req)]++
+	return c.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+func TestRetrievePages(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		counter := &methodCounter{c.RoundTripper, make(map[string]int)}
+		c.RoundTripper = counter
+
+		pc := DefaultCollector(c)
+
+		all, err := pc.RetrieveAll(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(all) < 2 || counter.calls["ContinueRetrievePropertiesEx"] != 0 {
+			t.Fatalf("%d objects, calls=%v", len(all), counter.calls)
+		}
+
+		pc.PageSize = 1
+
+		objects, err := pc.RetrieveAll(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(objects) != len(all) || counter.calls["ContinueRetrievePropertiesEx"] != len(all)-1 {
+			t.Errorf("%d objects, calls=%v", len(objects), counter.calls)
+		}
+
+		// Retrieve pages through the same calls
+		var refs []types.ManagedObjectReference
+		for _, o := range objects {
+			refs = append(refs, o.Obj)
+		}
+
+		var vms []mo.VirtualMachine
+		if err = pc.Retrieve(ctx, refs, []string{"name"}, &vms); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(vms) != len(refs) || vms[0].Name == "" || counter.calls["ContinueRetrievePropertiesEx"] != 2*(len(all)-1) {
+			t.Errorf("%d vms, calls=%v", len(vms), counter.calls)
+		}
+
+		// Nothing selected
+		objects, err = pc.RetrieveAll(ctx, []types.PropertyFilterSpec{{
+			ObjectSet: []types.ObjectSpec{{Obj: c.ServiceContent.RootFolder, Skip: types.NewBool(true)}},
+			PropSet:   []types.PropertySpec{{Type: "Folder", PathSet: []string{"name"}}},
+		}})
+		if err != nil || len(objects) != 0 {
+			t.Errorf("objects=%v, err=%v", objects, err)
+		}
+
+		if _, err = pc.ContinueRetrievePropertiesEx(ctx, "invalid"); err == nil {
+			t.Error("expected error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetrieveStream(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		pc := DefaultCollector(c)
+
+		all, err := pc.RetrieveAll(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var vms []mo.VirtualMachine
+		err = pc.RetrieveStream(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")}, func(o types.ObjectContent) error {
+			v, err := mo.ObjectContentToType(o)
+			if err != nil {
+				return err
+			}
+			vms = append(vms, v.(mo.VirtualMachine))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(vms) != len(all) || vms[0].Name == "" {
+			t.Errorf("%d vms, expected %d", len(vms), len(all))
+		}
+
+		// An error returned by fn stops the stream
+		n := 0
+		err = pc.RetrieveStream(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")}, func(types.ObjectContent) error {
+			n++
+			return context.Canceled
+		})
+		if err == nil || n != 1 {
+			t.Errorf("n=%d, err=%v", n, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForUpdatesEx(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		pc, err := DefaultCollector(c).Create(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Destroy(ctx)
+
+		err = pc.CreateFilter(ctx, types.CreateFilter{Spec: vmSpec(ctx, t, c, "name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Truncated sets are continued using their version
+		opts := &types.WaitOptions{MaxWaitSeconds: 1, MaxObjectUpdates: 1}
+		version := ""
+		count := 0
+
+		for {
+			set, err := pc.WaitForUpdatesEx(ctx, version, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if set == nil {
+				t.Fatal("expected update")
+			}
+
+			version = set.Version
+			count += len(set.FilterSet[0].ObjectSet)
+
+			if set.Truncated == nil || !*set.Truncated {
+				break
+			}
+		}
+
+		if count < 2 {
+			t.Errorf("count=%d", count)
+		}
+
+		// Poll with a timeout
+		start := time.Now()
+
+		set, err := pc.WaitForUpdatesEx(ctx, version, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if set != nil || time.Since(start) < time.Second {
+			t.Errorf("set=%#v", set)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForViewTruncated(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		counter := &methodCounter{c.RoundTripper, make(map[string]int)}
+		c.RoundTripper = counter
+
+		pc := DefaultCollector(c)
+
+		objects, err := pc.RetrieveAll(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		view := vmSpec(ctx, t, c).ObjectSet[0].Obj
+		obj := types.ManagedObjectReference{Type: "VirtualMachine"}
+
+		// Each object is reported by its own call when MaxObjectUpdates is 1
+		pc.MaxObjectUpdates = 1
+		seen := make(map[types.ManagedObjectReference]bool)
+
+		err = WaitForView(ctx, pc, view, obj, []string{"name"}, func(ref types.ManagedObjectReference, _ []types.PropertyChange) bool {
+			seen[ref] = true
+			return len(seen) == len(objects)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(objects) < 2 || counter.calls["WaitForUpdatesEx"] != len(objects) {
+			t.Errorf("%d objects, calls=%v", len(objects), counter.calls)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,679 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The expression language is parsed by a recursive descent parser:
+//
+//	expr    = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | primary
+//	primary = "(" expr ")" | operand [ op literal ]
+//	operand = path | ("age" | "len") "(" path ")"
+//	op      = "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" | "!~"
+//	literal = string | number | duration | "true" | "false"
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDuration
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are ordered such that the longest match is found first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %s", i, err)
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i = j + 1
+			continue
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			kind := tokenNumber
+			j := i + 1
+			for ; j < len(s) && (isIdent(rune(s[j])) || s[j] == '.'); j++ {
+				if unicode.IsLetter(rune(s[j])) {
+					kind = tokenDuration
+				}
+			}
+			tokens = append(tokens, token{kind, s[i:j], i})
+			i = j
+			continue
+		case isIdent(c):
+			j := i + 1
+			for ; j < len(s) && (isIdent(rune(s[j])) || s[j] == '.'); j++ {
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:j], i})
+			i = j
+			continue
+		}
+
+		found := false
+		for _, op := range operators {
+			if strings.HasPrefix(s[i:], op) {
+				tokens = append(tokens, token{tokenOp, op, i})
+				i += len(op)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(s)}), nil
+}
+
+func isIdent(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// node is an element of a parsed expression.
+type node interface {
+	eval(v reflect.Value) bool
+	paths() []*path
+}
+
+type orNode struct{ x, y node }
+
+func (n orNode) eval(v reflect.Value) bool { return n.x.eval(v) || n.y.eval(v) }
+func (n orNode) paths() []*path            { return append(n.x.paths(), n.y.paths()...) }
+
+type andNode struct{ x, y node }
+
+func (n andNode) eval(v reflect.Value) bool { return n.x.eval(v) && n.y.eval(v) }
+func (n andNode) paths() []*path            { return append(n.x.paths(), n.y.paths()...) }
+
+type notNode struct{ x node }
+
+func (n notNode) eval(v reflect.Value) bool { return !n.x.eval(v) }
+func (n notNode) paths() []*path            { return n.x.paths() }
+
+// path is a property path, which may include properties of array elements,
+// such as "snapshot.rootSnapshotList.createTime".
+type path struct {
+	name   string
+	fields [][]int
+	typ    reflect.Type
+}
+
+// resolve checks that the path exists on typ, and saves the field indices of each path element.
+func (p *path) resolve(typ reflect.Type) error {
+	for _, name := range strings.Split(p.name, ".") {
+		typ = elemType(typ)
+		if typ.Kind() != reflect.Struct {
+			return fmt.Errorf("%q: cannot select %q of %s", p.name, name, typ)
+		}
+
+		f, ok := fieldByName(typ, name)
+		if !ok {
+			return fmt.Errorf("%q: unknown property %q of %s", p.name, name, typ.Name())
+		}
+
+		p.fields = append(p.fields, f.Index)
+		typ = f.Type
+	}
+
+	p.typ = elemType(typ)
+
+	return nil
+}
+
+// values returns the values of the path within v, including those of each array element.
+func (p *path) values(v reflect.Value) []reflect.Value {
+	var vals []reflect.Value
+	p.walk(v, 0, &vals)
+	return vals
+}
+
+func (p *path) walk(v reflect.Value, i int, vals *[]reflect.Value) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		for j := 0; j < v.Len(); j++ {
+			p.walk(v.Index(j), i, vals)
+		}
+		return
+	}
+
+	if i == len(p.fields) {
+		*vals = append(*vals, v)
+		return
+	}
+
+	p.walk(v.FieldByIndex(p.fields[i]), i+1, vals)
+}
+
+// elemType returns the type of the values found at a property of type typ.
+func elemType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// fieldByName returns the struct field for the given property name, including the fields of embedded types.
+func fieldByName(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+
+		if f.Anonymous {
+			if ef, ok := fieldByName(elemType(f.Type), name); ok {
+				ef.Index = append([]int{i}, ef.Index...)
+				return ef, true
+			}
+			continue
+		}
+
+		tag := f.Tag.Get("mo")
+		if tag == "" {
+			tag = strings.Split(f.Tag.Get("xml"), ",")[0]
+		}
+
+		if tag == name {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// operand is a path, or a function of a path, evaluating to zero or more values.
+type operand struct {
+	fn   string
+	path *path
+}
+
+func (o operand) kind() reflect.Kind {
+	switch o.fn {
+	case "age":
+		return reflect.Int64
+	case "len":
+		return reflect.Int
+	}
+	return o.path.typ.Kind()
+}
+
+// values returns the values of the operand, normalized to one of the literal types.
+func (o operand) values(v reflect.Value) []interface{} {
+	vals := o.path.values(v)
+
+	if o.fn == "len" {
+		return []interface{}{float64(len(vals))}
+	}
+
+	res := make([]interface{}, 0, len(vals))
+
+	for _, val := range vals {
+		switch val.Kind() {
+		case reflect.String:
+			res = append(res, val.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			res = append(res, float64(val.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			res = append(res, float64(val.Uint()))
+		case reflect.Float32, reflect.Float64:
+			res = append(res, val.Float())
+		default:
+			i := val.Interface()
+			if t, ok := i.(time.Time); ok && o.fn == "age" {
+				i = time.Since(t)
+			}
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
+
+// existsNode is true if any value of the operand is not the zero value of its type.
+type existsNode struct{ x operand }
+
+func (n existsNode) paths() []*path { return []*path{n.x.path} }
+
+func (n existsNode) eval(v reflect.Value) bool {
+	for _, val := range n.x.values(v) {
+		switch val := val.(type) {
+		case string:
+			if val != "" {
+				return true
+			}
+		case float64:
+			if val != 0 {
+				return true
+			}
+		case bool:
+			if val {
+				return true
+			}
+		case time.Time:
+			if !val.IsZero() {
+				return true
+			}
+		case time.Duration:
+			if val != 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// compareNode is true if any value of the operand compares true with the literal.
+type compareNode struct {
+	x  operand
+	op string
+	y  interface{}
+	re *regexp.Regexp
+}
+
+func (n compareNode) paths() []*path { return []*path{n.x.path} }
+
+func (n compareNode) eval(v reflect.Value) bool {
+	for _, val := range n.x.values(v) {
+		if n.re != nil {
+			if n.re.MatchString(val.(string)) == (n.op == "=~") {
+				return true
+			}
+			continue
+		}
+
+		var c int
+
+		switch x := val.(type) {
+		case string:
+			c = strings.Compare(x, n.y.(string))
+		case float64:
+			c = compareFloat(x, n.y.(float64))
+		case time.Duration:
+			c = compareFloat(float64(x), float64(n.y.(time.Duration)))
+		case time.Time:
+			y := n.y.(time.Time)
+			c = compareFloat(float64(x.Sub(y)), 0)
+		case bool:
+			if x != n.y.(bool) {
+				c = 1
+			}
+		}
+
+		var ok bool
+
+		switch n.op {
+		case "==":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		}
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// check converts the literal to the type of the operand values and validates the operator.
+func (n *compareNode) check() error {
+	ordered := true
+	lit := n.y
+
+	switch n.x.kind() {
+	case reflect.String:
+		if _, ok := lit.(string); !ok {
+			return fmt.Errorf("%q: cannot compare string with %v", n.x.path.name, lit)
+		}
+		if n.op == "=~" || n.op == "!~" {
+			re, err := regexp.Compile(lit.(string))
+			if err != nil {
+				return fmt.Errorf("%q: %s", n.x.path.name, err)
+			}
+			n.re = re
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n.x.fn == "age" {
+			if _, ok := lit.(time.Duration); !ok {
+				return fmt.Errorf("%q: cannot compare age with %v", n.x.path.name, lit)
+			}
+		} else if _, ok := lit.(float64); !ok {
+			return fmt.Errorf("%q: cannot compare number with %v", n.x.path.name, lit)
+		}
+	case reflect.Bool:
+		if _, ok := lit.(bool); !ok {
+			return fmt.Errorf("%q: cannot compare bool with %v", n.x.path.name, lit)
+		}
+		ordered = false
+	default:
+		if n.x.path.typ != timeType {
+			return fmt.Errorf("%q: cannot compare %s", n.x.path.name, n.x.path.typ)
+		}
+		s, ok := lit.(string)
+		if !ok {
+			return fmt.Errorf("%q: cannot compare time with %v", n.x.path.name, lit)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("%q: %s", n.x.path.name, err)
+		}
+		n.y = t
+	}
+
+	switch n.op {
+	case "=~", "!~":
+		return fmt.Errorf("%q: %s requires a string property", n.x.path.name, n.op)
+	case "<", "<=", ">", ">=":
+		if !ordered {
+			return fmt.Errorf("%q: %s is not defined for %v", n.x.path.name, n.op, lit)
+		}
+	}
+
+	return nil
+}
+
+// expr is a parsed expression, along with the paths it references.
+type expr struct {
+	root  node
+	paths []*path
+}
+
+// parseExpr parses s and checks its property paths against the struct type typ.
+func parseExpr(s string, typ reflect.Type) (*expr, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens, typ: typ}
+
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+
+	return &expr{root: root, paths: root.paths()}, nil
+}
+
+type parser struct {
+	tokens []token
+	typ    reflect.Type
+}
+
+func (p *parser) peek() token {
+	return p.tokens[0]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[0]
+	if t.kind != tokenEOF {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("expected %q at offset %d", op, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) or() (node, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = orNode{x, y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) and() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = andNode{x, y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.accept("!") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	if p.accept("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	}
+
+	x, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOp {
+		return existsNode{x}, nil
+	}
+
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+		p.next()
+	default:
+		return existsNode{x}, nil
+	}
+
+	y, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &compareNode{x: x, op: t.text, y: y}
+
+	if err = n.check(); err != nil {
+		return nil, err
+	}
+
+	return *n, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	var o operand
+
+	t := p.next()
+	if t.kind != tokenIdent {
+		return o, fmt.Errorf("expected property at offset %d", t.pos)
+	}
+
+	name := t.text
+
+	if t.text == "age" || t.text == "len" {
+		if p.accept("(") {
+			o.fn = t.text
+
+			t = p.next()
+			if t.kind != tokenIdent {
+				return o, fmt.Errorf("expected property at offset %d", t.pos)
+			}
+			name = t.text
+
+			if err := p.expect(")"); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	o.path = &path{name: name}
+
+	if err := o.path.resolve(p.typ); err != nil {
+		return o, err
+	}
+
+	if o.fn == "age" && o.path.typ != timeType {
+		return o, fmt.Errorf("%q: age requires a time property", name)
+	}
+
+	return o, nil
+}
+
+func (p *parser) literal() (interface{}, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		return strconv.ParseFloat(t.text, 64)
+	case tokenDuration:
+		return parseDuration(t.text)
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected literal at offset %d", t.pos)
+}
+
+// parseDuration extends time.ParseDuration with a "d" suffix for days.
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Traversal describes a TraversalSpec, following the references in property Path
+// of objects of managed object Type, or any of its sub types.
+type Traversal struct {
+	Type string
+	Path string
+}
+
+// Name returns the name used to reference the traversal in a SelectionSpec, in the form "Type.Path".
+func (t Traversal) Name() string {
+	return t.Type + "." + t.Path
+}
+
+// validate checks that Path is a reference property of Type.
+func (t Traversal) validate() error {
+	typ, ok := mo.PropertyType(t.Type, t.Path)
+	if !ok {
+		return fmt.Errorf("property: unknown traversal path %q of %s", t.Path, t.Type)
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		typ = typ.Elem()
+	}
+
+	if typ != reflect.TypeOf(types.ManagedObjectReference{}) {
+		return fmt.Errorf("property: traversal path %q of %s is not a reference", t.Path, t.Type)
+	}
+
+	return nil
+}
+
+// StandardTraversals covers the inventory graph, from the root folder down to the leaf entities,
+// along with the cross references between hosts, virtual machines, datastores and networks.
+var StandardTraversals = []Traversal{
+	{"Folder", "childEntity"},
+	{"Datacenter", "vmFolder"},
+	{"Datacenter", "hostFolder"},
+	{"Datacenter", "datastoreFolder"},
+	{"Datacenter", "networkFolder"},
+	{"Datacenter", "datastore"},
+	{"Datacenter", "network"},
+	{"ComputeResource", "host"},
+	{"ComputeResource", "resourcePool"},
+	{"ComputeResource", "datastore"},
+	{"ComputeResource", "network"},
+	{"ResourcePool", "resourcePool"},
+	{"ResourcePool", "vm"},
+	{"HostSystem", "vm"},
+	{"HostSystem", "datastore"},
+	{"HostSystem", "network"},
+	{"VirtualMachine", "datastore"},
+	{"VirtualMachine", "network"},
+	{"Datastore", "vm"},
+	{"Network", "host"},
+	{"Network", "vm"},
+	{"DistributedVirtualSwitch", "portgroup"},
+	{"ManagedObjectView", "view"},
+}
+
+// FilterBuilder builds a types.PropertyFilterSpec starting at a single object.
+// Each of the traversals added to the builder may be followed from the object
+// and from any object reached through another traversal, such that the order
+// in which traversals are added does not matter.  For example, the virtual
+// machines within the resource pools of a datacenter's host folder:
+//
+//	spec, err := property.NewFilterBuilder(dc).
+//		Skip().
+//		Traverse("hostFolder", "childEntity", "resourcePool", "ResourcePool.vm").
+//		Properties("VirtualMachine", "name", "runtime.powerState").
+//		Build()
+type FilterBuilder struct {
+	obj        types.ManagedObjectReference
+	skip       bool
+	traversals []Traversal
+	props      []types.PropertySpec
+	err        error
+}
+
+// NewFilterBuilder returns a FilterBuilder starting at obj.
+func NewFilterBuilder(obj types.ManagedObjectReference) *FilterBuilder {
+	return &FilterBuilder{obj: obj}
+}
+
+// Skip excludes the starting object from the results.
+func (b *FilterBuilder) Skip() *FilterBuilder {
+	b.skip = true
+	return b
+}
+
+// Traverse adds StandardTraversals by name, such as "Datacenter.vmFolder".
+// A name without a type, such as "vm", adds all StandardTraversals with that path.
+func (b *FilterBuilder) Traverse(names ...string) *FilterBuilder {
+	for _, name := range names {
+		found := false
+
+		for _, t := range StandardTraversals {
+			if t.Name() == name || (t.Path == name && !strings.Contains(name, ".")) {
+				b.add(t)
+				found = true
+			}
+		}
+
+		if !found && b.err == nil {
+			b.err = fmt.Errorf("property: unknown traversal %q", name)
+		}
+	}
+
+	return b
+}
+
+// TraverseAll adds all StandardTraversals.
+func (b *FilterBuilder) TraverseAll() *FilterBuilder {
+	return b.TraverseWith(StandardTraversals...)
+}
+
+// TraverseWith adds the given traversals, which need not be one of the StandardTraversals.
+func (b *FilterBuilder) TraverseWith(traversals ...Traversal) *FilterBuilder {
+	for _, t := range traversals {
+		b.add(t)
+	}
+
+	return b
+}
+
+func (b *FilterBuilder) add(t Traversal) {
+	for _, x := range b.traversals {
+		if x == t {
+			return
+		}
+	}
+
+	b.traversals = append(b.traversals, t)
+}
+
+// Properties collects the given property paths of objects of managed object type kind.
+// All properties are collected if no paths are given.
+func (b *FilterBuilder) Properties(kind string, paths ...string) *FilterBuilder {
+	spec := types.PropertySpec{Type: kind}
+
+	if len(paths) == 0 {
+		spec.All = types.NewBool(true)
+	} else {
+		spec.PathSet = paths
+	}
+
+	b.props = append(b.props, spec)
+
+	return b
+}
+
+// Build validates the traversal and property paths against the mo types and returns the filter spec.
+func (b *FilterBuilder) Build() (types.PropertyFilterSpec, error) {
+	var spec types.PropertyFilterSpec
+
+	if b.err != nil {
+		return spec, b.err
+	}
+
+	for _, t := range b.traversals {
+		if err := t.validate(); err != nil {
+			return spec, err
+		}
+	}
+
+	for _, p := range b.props {
+		if _, ok := mo.PropertyType(p.Type, ""); !ok {
+			return spec, fmt.Errorf("property: unknown type %q", p.Type)
+		}
+
+		for _, path := range p.PathSet {
+			if _, ok := mo.PropertyType(p.Type, path); !ok {
+				return spec, fmt.Errorf("property: unknown property %q of %s", path, p.Type)
+			}
+		}
+	}
+
+	// Each traversal selects all others by name.  A traversal is only followed from objects
+	// of its Type, so the objects reached are limited to the paths added to the builder.
+	var names []types.BaseSelectionSpec
+	for _, t := range b.traversals {
+		names = append(names, &types.SelectionSpec{Name: t.Name()})
+	}
+
+	ospec := types.ObjectSpec{
+		Obj:  b.obj,
+		Skip: types.NewBool(b.skip),
+	}
+
+	for _, t := range b.traversals {
+		ospec.SelectSet = append(ospec.SelectSet, &types.TraversalSpec{
+			SelectionSpec: types.SelectionSpec{
+				Name: t.Name(),
+			},
+			Type:      t.Type,
+			Path:      t.Path,
+			SelectSet: append([]types.BaseSelectionSpec(nil), names...),
+		})
+	}
+
+	spec.ObjectSet = []types.ObjectSpec{ospec}
+	spec.PropSet = append([]types.PropertySpec(nil), b.props...)
+
+	return spec, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestStandardTraversals(t *testing.T) {
+	for _, x := range StandardTraversals {
+		if err := x.validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFilterBuilderValidate(t *testing.T) {
+	root := types.ManagedObjectReference{Type: "Folder", Value: "group-d1"}
+
+	tests := []*FilterBuilder{
+		NewFilterBuilder(root).Traverse("nope"),
+		NewFilterBuilder(root).Traverse("Folder.vm"),
+		NewFilterBuilder(root).TraverseWith(Traversal{"Folder", "nope"}),
+		NewFilterBuilder(root).TraverseWith(Traversal{"VirtualMachine", "name"}),
+		NewFilterBuilder(root).TraverseWith(Traversal{"Nope", "vm"}),
+		NewFilterBuilder(root).Properties("Nope"),
+		NewFilterBuilder(root).Properties("VirtualMachine", "name", "runtime.nope"),
+	}
+
+	for i, test := range tests {
+		if _, err := test.Build(); err == nil {
+			t.Errorf("%d: expected error", i)
+		}
+	}
+
+	spec, err := NewFilterBuilder(root).
+		Traverse("childEntity", "vmFolder", "vm", "Datacenter.vmFolder").
+		Properties("VirtualMachine", "name", "runtime.powerState").
+		Properties("Folder").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "vm" matches ResourcePool, HostSystem, Datastore and Network
+	set := spec.ObjectSet[0].SelectSet
+	if len(set) != 6 {
+		t.Fatalf("%d traversals", len(set))
+	}
+
+	for _, s := range set {
+		ts := s.(*types.TraversalSpec)
+		if ts.Name != ts.Type+"."+ts.Path || len(ts.SelectSet) != len(set) {
+			t.Errorf("spec=%#v", ts)
+		}
+	}
+
+	if *spec.ObjectSet[0].Skip || len(spec.PropSet) != 2 || !*spec.PropSet[1].All {
+		t.Errorf("spec=%#v", spec)
+	}
+}
+
+func TestFilterBuilder(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		pc := DefaultCollector(c)
+
+		// retrieve returns the names of the VMs found by following the given traversals from the root folder
+		retrieve := func(names ...string) []string {
+			spec, err := NewFilterBuilder(c.ServiceContent.RootFolder).
+				Skip().
+				Traverse(names...).
+				Properties("VirtualMachine", "name").
+				Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := pc.RetrieveAll(ctx, []types.PropertyFilterSpec{spec})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var vms []string
+			for _, o := range content {
+				if o.Obj.Type != "VirtualMachine" {
+					t.Errorf("obj=%s", o.Obj)
+				}
+				vms = append(vms, o.PropSet[0].Val.(string))
+			}
+			sort.Strings(vms)
+			return vms
+		}
+
+		content, err := pc.RetrieveAll(ctx, []types.PropertyFilterSpec{vmSpec(ctx, t, c, "name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		folders := retrieve("childEntity", "vmFolder")
+		if len(folders) != len(content) || len(folders) == 0 {
+			t.Fatalf("%d VMs in folders, %d VMs in view", len(folders), len(content))
+		}
+
+		pools := retrieve("childEntity", "hostFolder", "resourcePool", "ResourcePool.vm")
+		if len(pools) != len(folders) {
+			t.Fatalf("%d VMs in pools, %d VMs in folders", len(pools), len(folders))
+		}
+
+		for i := range pools {
+			if pools[i] != folders[i] {
+				t.Errorf("%s != %s", pools[i], folders[i])
+			}
+		}
+
+		if vms := retrieve("childEntity"); len(vms) != 0 {
+			t.Errorf("vms=%v", vms)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
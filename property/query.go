@@ -0,0 +1,213 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Query selects managed objects of a given type using an expression evaluated
+// client side against the mo type, for example:
+//
+//	runtime.powerState == "poweredOn" && summary.config.memorySizeMB >= 4096 && name =~ "^web-"
+//
+// Comparisons use the operators ==, !=, <, <=, >, >=, along with =~ and !~ to match
+// a regular expression.  Property values are compared with a string, number, true, false
+// or, using the age function, a duration such as 12h or 7d:
+//
+//	age(snapshot.rootSnapshotList.createTime) > 7d
+//
+// Time properties may also be compared with an RFC 3339 string.  A property without a
+// comparison is true if it is set to a non-zero value and len counts the property values.
+// Expressions are combined using &&, || and !, along with parentheses for grouping.
+// Properties of array elements are referenced by name, such that a comparison is true
+// if any element compares true.
+type Query struct {
+	kind string
+	expr *expr
+}
+
+// NewQuery parses the expression where, checking the property paths against the mo type kind.
+// An empty expression matches all objects.
+func NewQuery(kind string, where string) (*Query, error) {
+	typ, ok := mo.PropertyType(kind, "")
+	if !ok {
+		return nil, fmt.Errorf("property: unknown type %q", kind)
+	}
+
+	q := &Query{kind: kind}
+
+	if strings.TrimSpace(where) != "" {
+		x, err := parseExpr(where, typ)
+		if err != nil {
+			return nil, fmt.Errorf("property: %s", err)
+		}
+		q.expr = x
+	}
+
+	return q, nil
+}
+
+// Kind returns the managed object type of the Query.
+func (q *Query) Kind() string {
+	return q.kind
+}
+
+// Properties returns the minimal set of properties to retrieve in order to evaluate the Query.
+func (q *Query) Properties() []string {
+	if q.expr == nil {
+		return nil
+	}
+
+	var props []string
+
+	for _, p := range q.expr.paths {
+		// The collector can only retrieve paths up to an array or data object interface,
+		// for example "snapshot.rootSnapshotList" for "snapshot.rootSnapshotList.createTime".
+		name := p.name
+		for {
+			i := strings.LastIndex(name, ".")
+			if _, ok := mo.PropertyType(q.kind, name); ok || i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+		props = append(props, name)
+	}
+
+	return minimize(props)
+}
+
+// minimize returns the sorted unique props, without those contained by another.
+func minimize(props []string) []string {
+	sort.Strings(props)
+
+	var res []string
+
+	for _, p := range props {
+		if n := len(res); n != 0 {
+			last := res[n-1]
+			if p == last || strings.HasPrefix(p, last+".") {
+				continue
+			}
+		}
+		res = append(res, p)
+	}
+
+	return res
+}
+
+// Match returns true if obj, a mo type value such as mo.VirtualMachine, matches the Query.
+func (q *Query) Match(obj interface{}) bool {
+	if q.expr == nil {
+		return true
+	}
+
+	return q.expr.root.eval(reflect.ValueOf(obj))
+}
+
+// FilterSpec returns the spec that selects the Query properties, along with props, of each object
+// of the Query type within view, a ContainerView such as that used by Run.
+func (q *Query) FilterSpec(view types.ManagedObjectReference, props ...string) (types.PropertyFilterSpec, error) {
+	props = minimize(append(q.Properties(), props...))
+
+	for _, p := range props {
+		if _, ok := mo.PropertyType(q.kind, p); !ok {
+			return types.PropertyFilterSpec{}, fmt.Errorf("property: unknown property %q of %s", p, q.kind)
+		}
+	}
+
+	spec, err := NewFilterBuilder(view).Skip().Traverse("view").Build()
+	if err != nil {
+		return spec, err
+	}
+
+	// FilterBuilder.Properties collects all properties when given none, so the PropSet is set here
+	spec.PropSet = []types.PropertySpec{{Type: q.kind, PathSet: props}}
+
+	return spec, nil
+}
+
+// Filter returns the mo type values of the objects in content that match the Query, such as those
+// retrieved using FilterSpec.  Properties that the collector reports as missing are treated as unset.
+func (q *Query) Filter(content []types.ObjectContent) ([]interface{}, error) {
+	var objs []interface{}
+
+	for _, o := range content {
+		o.MissingSet = nil
+
+		obj, err := mo.ObjectContentToType(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if q.Match(obj) {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs, nil
+}
+
+// Run retrieves the Query properties, along with props, of each object of the Query type within
+// the container root and returns the mo type values of those that match.  Properties that the
+// collector reports as missing are treated as unset.
+func (q *Query) Run(ctx context.Context, c *vim25.Client, root types.ManagedObjectReference, props ...string) ([]interface{}, error) {
+	view, err := createContainerView(ctx, c, root, []string{q.kind})
+	if err != nil {
+		return nil, err
+	}
+	defer methods.DestroyView(context.Background(), c, &types.DestroyView{This: view})
+
+	spec, err := q.FilterSpec(view, props...)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := DefaultCollector(c).RetrieveAll(ctx, []types.PropertyFilterSpec{spec})
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Filter(content)
+}
+
+// createContainerView creates a recursive ContainerView of the objects of the given types within root.
+func createContainerView(ctx context.Context, c *vim25.Client, root types.ManagedObjectReference, kinds []string) (types.ManagedObjectReference, error) {
+	req := types.CreateContainerView{
+		This:      *c.ServiceContent.ViewManager,
+		Container: root,
+		Type:      kinds,
+		Recursive: true,
+	}
+
+	res, err := methods.CreateContainerView(ctx, c, &req)
+	if err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+
+	return res.Returnval, nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestQueryParseError(t *testing.T) {
+	tests := []string{
+		`name ==`,
+		`name == "web`,
+		`(name == "web"`,
+		`name == "web")`,
+		`name = "web"`,
+		`nope == "web"`,
+		`name.nope == "web"`,
+		`config.hardware.device.key == 1`,
+		`name == 1`,
+		`name =~ "("`,
+		`summary.config.memorySizeMB == "big"`,
+		`summary.config.memorySizeMB =~ "1"`,
+		`summary.config.template < true`,
+		`age(name) > 1d`,
+		`age(runtime.bootTime) > 1`,
+		`runtime.bootTime > "yesterday"`,
+		`runtime.device == 1`,
+	}
+
+	for _, test := range tests {
+		if _, err := NewQuery("VirtualMachine", test); err == nil {
+			t.Errorf("expected error for: %s", test)
+		}
+	}
+
+	if _, err := NewQuery("Nope", ""); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestQueryProperties(t *testing.T) {
+	q, err := NewQuery("VirtualMachine", `name =~ "^web-" && (summary.config.memorySizeMB >= 4096 || summary.config.template)
+		&& age(snapshot.rootSnapshotList.createTime) > 7d && len(snapshot.rootSnapshotList.childSnapshotList) == 0 && !summary`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	props := q.Properties()
+	expect := []string{"name", "snapshot.rootSnapshotList", "summary"}
+
+	if !reflect.DeepEqual(props, expect) {
+		t.Errorf("props=%v", props)
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	now := time.Now()
+	boot := now.Add(-time.Hour)
+
+	vm := mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: "web-1"},
+		Runtime: types.VirtualMachineRuntimeInfo{
+			PowerState: types.VirtualMachinePowerStatePoweredOn,
+			BootTime:   &boot,
+		},
+		Summary: types.VirtualMachineSummary{
+			Config: types.VirtualMachineConfigSummary{MemorySizeMB: 4096},
+		},
+		Snapshot: &types.VirtualMachineSnapshotInfo{
+			RootSnapshotList: []types.VirtualMachineSnapshotTree{
+				{Name: "old", CreateTime: now.Add(-10 * 24 * time.Hour)},
+				{Name: "new", CreateTime: now.Add(-time.Minute)},
+			},
+		},
+	}
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{``, true},
+		{`runtime.powerState == "poweredOn" && summary.config.memorySizeMB >= 4096 && name =~ "^web-"`, true},
+		{`runtime.powerState == "poweredOn" && summary.config.memorySizeMB > 4096`, false},
+		{`runtime.powerState != "poweredOn" || name !~ "^web-"`, false},
+		{`!(name == "web-1")`, false},
+		{`name < "x" && name > "a" && name <= "web-1" && name >= "web-1"`, true},
+		{`summary.config.template`, false},
+		{`!summary.config.template == true`, true},
+		{`snapshot`, true},
+		{`config`, false},
+		{`config.name == ""`, false},
+		{`len(snapshot.rootSnapshotList) == 2`, true},
+		{`len(config.hardware.device) == 0`, true},
+		{`snapshot.rootSnapshotList.name == "new"`, true},
+		{`snapshot.rootSnapshotList.name == "other"`, false},
+		{`age(snapshot.rootSnapshotList.createTime) > 7d`, true},
+		{`age(snapshot.rootSnapshotList.createTime) > 11d`, false},
+		{`age(runtime.bootTime) < 1h30m`, true},
+		{`runtime.bootTime > "2017-01-01T00:00:00Z"`, true},
+		{`summary.config.memorySizeMB == 4096.0 && summary.config.memorySizeMB != -1`, true},
+	}
+
+	for _, test := range tests {
+		q, err := NewQuery("VirtualMachine", test.expr)
+		if err != nil {
+			t.Errorf("%s: %s", test.expr, err)
+			continue
+		}
+
+		if q.Match(vm) != test.match {
+			t.Errorf("%s: expected %t", test.expr, test.match)
+		}
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	q, err := NewQuery("VirtualMachine", `name =~ "^web-" && !config.template`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := types.ManagedObjectReference{Type: "ContainerView", Value: "session[0]view-1"}
+
+	if _, err = q.FilterSpec(view, "invalid"); err == nil {
+		t.Error("expected error")
+	}
+
+	spec, err := q.FilterSpec(view, "runtime.powerState")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := spec.PropSet[0].PathSet
+	if !reflect.DeepEqual(paths, []string{"config.template", "name", "runtime.powerState"}) {
+		t.Errorf("paths=%v", paths)
+	}
+
+	content := []types.ObjectContent{
+		{
+			Obj:        types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+			PropSet:    []types.DynamicProperty{{Name: "name", Val: "web-1"}},
+			MissingSet: []types.MissingProperty{{Path: "config.template"}},
+		},
+		{
+			Obj:     types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-2"},
+			PropSet: []types.DynamicProperty{{Name: "name", Val: "db-1"}},
+		},
+	}
+
+	objs, err := q.Filter(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objs) != 1 || objs[0].(mo.VirtualMachine).Self.Value != "vm-1" {
+		t.Errorf("objs=%v", objs)
+	}
+}
+
+func TestQueryRun(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		root := c.ServiceContent.RootFolder
+
+		run := func(where string, props ...string) []interface{} {
+			q, err := NewQuery("VirtualMachine", where)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			objs, err := q.Run(ctx, c, root, props...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return objs
+		}
+
+		all := run("", "name", "runtime.powerState")
+		if len(all) == 0 {
+			t.Fatal("no VMs")
+		}
+
+		on := 0
+		for _, obj := range all {
+			if obj.(mo.VirtualMachine).Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn {
+				on++
+			}
+		}
+
+		if n := len(run(`runtime.powerState == "poweredOn"`)); n != on {
+			t.Errorf("%d powered on, expected %d", n, on)
+		}
+
+		if n := len(run(`!(runtime.powerState == "poweredOn")`)); n != len(all)-on {
+			t.Errorf("%d not powered on, expected %d", n, len(all)-on)
+		}
+
+		name := all[0].(mo.VirtualMachine).Name
+
+		vms := run(`name == "`+name+`"`, "summary.config.memorySizeMB")
+		if len(vms) != 1 {
+			t.Fatalf("%d VMs named %s", len(vms), name)
+		}
+
+		vm := vms[0].(mo.VirtualMachine)
+		if vm.Name != name || vm.Summary.Config.MemorySizeMB == 0 || vm.Runtime.PowerState != "" {
+			t.Errorf("vm=%#v", vm)
+		}
+
+		q, _ := NewQuery("VirtualMachine", "")
+		if _, err := q.Run(ctx, c, root, "nope"); err == nil {
+			t.Error("expected error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -123,9 +123,13 @@ func WaitForView(ctx context.Context, c *Collector, view types.ManagedObjectRefe
 	return waitLoop(ctx, p, f)
 }
 
+// waitLoop calls f for each object update until f returns true.
+// A truncated update set is continued by the next call, which returns without waiting.
 func waitLoop(ctx context.Context, c *Collector, f func(types.ManagedObjectReference, []types.PropertyChange) bool) error {
+	opts := types.WaitOptions{MaxObjectUpdates: c.MaxObjectUpdates}
+
 	for version := ""; ; {
-		res, err := c.WaitForUpdates(ctx, version)
+		res, err := c.WaitForUpdatesEx(ctx, version, &opts)
 		if err != nil {
 			return err
 		}
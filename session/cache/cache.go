@@ -0,0 +1,196 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Cache saves and restores authenticated vim25 clients, such that a session can be reused
+// between processes without logging in again.
+type Cache struct {
+	Store Store
+
+	// Configure, if set, is called with clients restored by Load before their session is validated.
+	// For example, to set root CAs and thumbprints or to wrap the RoundTripper.
+	Configure func(*vim25.Client) error
+}
+
+// entry is the format of data saved to a Store.
+type entry struct {
+	Client  *vim25.Client      `json:"client"`
+	Session *types.UserSession `json:"session,omitempty"`
+}
+
+// New returns a Cache that uses the given Store.
+func New(store Store) *Cache {
+	return &Cache{Store: store}
+}
+
+// Key returns a cache key for the given URL and insecure setting.
+// The user's password, if any, is not part of the key.
+func Key(u *url.URL, insecure bool) string {
+	withoutPassword := *u
+	if u.User != nil {
+		withoutPassword.User = url.User(u.User.Username())
+	}
+
+	// Hash key to get a predictable, canonical format.
+	key := fmt.Sprintf("%s#insecure=%t", withoutPassword.String(), insecure)
+	return fmt.Sprintf("%040x", sha1.Sum([]byte(key)))
+}
+
+// Load restores the client saved for key.
+// Nil is returned if no client was saved or if its session is no longer valid,
+// in which case the saved data is removed from the Store.
+// Data that cannot be decrypted, such as after the Encrypt key has changed, is also removed.
+func (c *Cache) Load(ctx context.Context, key string) (*vim25.Client, error) {
+	data, err := c.Store.Load(key)
+	if _, ok := err.(decryptError); ok {
+		return nil, c.Store.Delete(key)
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var e entry
+	if err = json.Unmarshal(data, &e); err != nil || e.Client == nil || !e.Client.Valid() {
+		// Saved by an incompatible version or otherwise unusable, treat as expired.
+		return nil, c.Store.Delete(key)
+	}
+
+	if c.Configure != nil {
+		if err = c.Configure(e.Client); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := c.valid(ctx, &e)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, c.Store.Delete(key)
+	}
+
+	return e.Client, nil
+}
+
+// valid checks if the session of the given entry is still active,
+// using SessionIsActive and falling back to the SessionManager's currentSession property
+// where SessionIsActive is not supported or permitted, such as on ESX.
+func (c *Cache) valid(ctx context.Context, e *entry) (bool, error) {
+	if e.Session != nil {
+		req := types.SessionIsActive{
+			This:      *e.Client.ServiceContent.SessionManager,
+			SessionID: e.Session.Key,
+			UserName:  e.Session.UserName,
+		}
+
+		res, err := methods.SessionIsActive(ctx, e.Client, &req)
+		if err == nil {
+			return res.Returnval, nil
+		}
+
+		if !soap.IsSoapFault(err) {
+			return false, err
+		}
+
+		switch soap.ToSoapFault(err).VimFault().(type) {
+		case types.NotAuthenticated, *types.NotAuthenticated:
+			return false, nil
+		}
+	}
+
+	u, err := session.NewManager(e.Client).UserSession(ctx)
+	if err != nil {
+		if soap.IsSoapFault(err) {
+			// If the PropertyCollector is not found, the saved session for this URL is not valid
+			if _, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound); ok {
+				return false, nil
+			}
+		}
+
+		return false, err
+	}
+
+	// If the session is nil, the client is not authenticated
+	return u != nil, nil
+}
+
+// Save stores the given client, which must be authenticated, for key.
+func (c *Cache) Save(ctx context.Context, key string, client *vim25.Client) error {
+	u, err := session.NewManager(client).UserSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry{Client: client, Session: u})
+	if err != nil {
+		return err
+	}
+
+	return c.Store.Save(key, data)
+}
+
+// Delete removes the client saved for key.
+func (c *Cache) Delete(key string) error {
+	return c.Store.Delete(key)
+}
+
+// Login returns the client saved for key if its session is still valid.
+// Otherwise the login function is called and the client it returns is saved.
+// If the Store implements Locker, the key is locked while loading and saving,
+// such that concurrent callers share a single login.
+func (c *Cache) Login(ctx context.Context, key string, login func(context.Context) (*vim25.Client, error)) (*vim25.Client, error) {
+	if l, ok := c.Store.(Locker); ok {
+		unlock, err := l.Lock(key)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = unlock()
+		}()
+	}
+
+	client, err := c.Load(ctx, key)
+	if err != nil || client != nil {
+		return client, err
+	}
+
+	client, err = login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.Save(ctx, key, client); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
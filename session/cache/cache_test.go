@@ -0,0 +1,303 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+type keyring map[string]string
+
+func (k keyring) Get(service, user string) (string, error) {
+	s, ok := k[service+"/"+user]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
+
+func (k keyring) Set(service, user, secret string) error {
+	k[service+"/"+user] = secret
+	return nil
+}
+
+func (k keyring) Delete(service, user string) error {
+	if _, ok := k[service+"/"+user]; !ok {
+		return ErrNotFound
+	}
+	delete(k, service+"/"+user)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "govmomi-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	encrypted, err := Encrypt(&FileStore{Dir: filepath.Join(dir, "encrypted")}, bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]Store{
+		"file":      &FileStore{Dir: dir},
+		"memory":    new(MemoryStore),
+		"keyring":   &KeyringStore{Keyring: keyring{}, Service: "govmomi"},
+		"encrypted": encrypted,
+	}
+
+	for _, model := range []*simulator.Model{simulator.ESX(), simulator.VPX()} {
+		err := model.Create()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := model.Service.NewServer()
+
+		for name, store := range stores {
+			logins := 0
+			login := func(ctx context.Context) (*vim25.Client, error) {
+				logins++
+				c, err := vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+				if err != nil {
+					return nil, err
+				}
+				return c, session.NewManager(c).Login(ctx, s.URL.User)
+			}
+
+			c := New(store)
+			key := Key(s.URL, true)
+
+			for i := 0; i < 2; i++ {
+				vc, err := c.Login(ctx, key, login)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if logins != 1 {
+					t.Errorf("%s: logins=%d", name, logins)
+				}
+
+				u, err := session.NewManager(vc).UserSession(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if u == nil {
+					t.Errorf("%s: restored client is not authenticated", name)
+				}
+			}
+
+			vc, err := c.Load(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Expired sessions are removed
+			if err = session.NewManager(vc).Logout(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			vc, err = c.Load(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vc != nil {
+				t.Errorf("%s: expected expired session", name)
+			}
+
+			data, err := store.Load(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data != nil {
+				t.Errorf("%s: expected expired session to be deleted", name)
+			}
+
+			if _, err = c.Login(ctx, key, login); err != nil {
+				t.Fatal(err)
+			}
+			if logins != 2 {
+				t.Errorf("%s: logins=%d", name, logins)
+			}
+		}
+
+		s.Close()
+	}
+}
+
+func TestCacheEncryptKeyChanged(t *testing.T) {
+	ctx := context.Background()
+
+	model := simulator.ESX()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	logins := 0
+	login := func(ctx context.Context) (*vim25.Client, error) {
+		logins++
+		c, err := vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+		if err != nil {
+			return nil, err
+		}
+		return c, session.NewManager(c).Login(ctx, s.URL.User)
+	}
+
+	m := new(MemoryStore)
+	key := Key(s.URL, true)
+
+	for i, k := range [][]byte{bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)} {
+		store, err := Encrypt(m, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Data saved with the previous key is treated as expired
+		if _, err = New(store).Login(ctx, key, login); err != nil {
+			t.Fatal(err)
+		}
+
+		if logins != i+1 {
+			t.Errorf("logins=%d", logins)
+		}
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	m := new(MemoryStore)
+	key := bytes.Repeat([]byte{1}, 16)
+
+	s, err := Encrypt(m, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.(Locker); !ok {
+		t.Error("expected Locker")
+	}
+
+	secret := []byte("vmware_soap_session=secret")
+
+	if err = s.Save("a", secret); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := m.Load("a")
+	if bytes.Contains(data, []byte("secret")) {
+		t.Error("data is not encrypted")
+	}
+
+	data, err = s.Load("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, secret) {
+		t.Errorf("data=%q", data)
+	}
+
+	// Data cannot be moved to another key
+	_ = m.Save("b", m.data["a"])
+	if _, err = s.Load("b"); err == nil {
+		t.Error("expected error")
+	}
+
+	if _, err = Encrypt(m, []byte("short")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestFileStoreLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govmomi-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &FileStore{Dir: dir}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	held := 0
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			unlock, err := s.Lock("key")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			held++
+			if held != 1 {
+				t.Errorf("lock held by %d", held)
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			held--
+			mu.Unlock()
+
+			if err := unlock(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Stale locks are removed
+	s.LockTimeout = time.Second
+	name := filepath.Join(dir, "key.lock")
+	if err = ioutil.WriteFile(name, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(name, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := s.Lock("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = unlock()
+}
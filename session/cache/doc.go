@@ -0,0 +1,25 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package cache saves authenticated sessions to a Store, such that they can be
+reused by later processes.
+
+Restored sessions are validated before use; expired sessions are removed from
+the Store. Stores include files, memory and OS keyrings. Session data can be
+encrypted at rest using Encrypt.
+*/
+package cache
@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// encryptedStore encrypts session data with AES-GCM before saving it to the underlying Store.
+type encryptedStore struct {
+	Store
+
+	aead cipher.AEAD
+}
+
+// Encrypt wraps the given Store, such that session data, including the session cookie,
+// is encrypted at rest using AES-GCM with the given key, which must be 16, 24 or 32 bytes.
+// If the Store implements Locker, so does the returned Store.
+func Encrypt(s Store, key []byte) (Store, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &encryptedStore{Store: s, aead: aead}
+
+	if l, ok := s.(Locker); ok {
+		return &lockingEncryptedStore{e, l}, nil
+	}
+
+	return e, nil
+}
+
+// decryptError is returned by an encrypted Store for data that cannot be decrypted,
+// such as data saved with another key, which Cache.Load treats as an expired session.
+type decryptError struct {
+	err error
+}
+
+func (e decryptError) Error() string {
+	return "invalid encrypted session data: " + e.err.Error()
+}
+
+type lockingEncryptedStore struct {
+	*encryptedStore
+	Locker
+}
+
+func (s *encryptedStore) Load(key string) ([]byte, error) {
+	data, err := s.Store.Load(key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return nil, decryptError{errors.New("too short")}
+	}
+
+	// The key is authenticated as additional data, such that data cannot be swapped between keys.
+	data, err = s.aead.Open(nil, data[:n], data[n:], []byte(key))
+	if err != nil {
+		return nil, decryptError{err}
+	}
+
+	return data, nil
+}
+
+func (s *encryptedStore) Save(key string, data []byte) error {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	return s.Store.Save(key, s.aead.Seal(nonce, nonce, data, []byte(key)))
+}
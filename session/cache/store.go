@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrNotFound may be returned by a Keyring when no data exists for a key.
+var ErrNotFound = errors.New("session not found")
+
+// Store persists session data by key.
+type Store interface {
+	// Load returns the data saved for key, or nil if there is none.
+	Load(key string) ([]byte, error)
+
+	// Save stores data for key, replacing any existing data.
+	Save(key string, data []byte) error
+
+	// Delete removes any data saved for key.
+	Delete(key string) error
+}
+
+// Locker is implemented by a Store that can serialize access to a key,
+// for example between concurrent processes sharing a session.
+type Locker interface {
+	// Lock blocks until the key is locked, returning a function that releases the lock.
+	Lock(key string) (func() error, error)
+}
+
+// MemoryStore is a Store that keeps session data in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	keys map[string]*sync.Mutex
+}
+
+func (s *MemoryStore) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data[key], nil
+}
+
+func (s *MemoryStore) Save(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+
+	s.data[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, key)
+
+	return nil
+}
+
+func (s *MemoryStore) Lock(key string) (func() error, error) {
+	s.mu.Lock()
+	if s.keys == nil {
+		s.keys = make(map[string]*sync.Mutex)
+	}
+	l, ok := s.keys[key]
+	if !ok {
+		l = new(sync.Mutex)
+		s.keys[key] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+
+	return func() error {
+		l.Unlock()
+		return nil
+	}, nil
+}
+
+// FileStore is a Store that saves session data to a file per key in Dir.
+// Files are created with mode 0600 and written atomically.
+type FileStore struct {
+	Dir string
+
+	// LockTimeout is the maximum time Lock waits for a lock held by another process, defaults to 1 minute.
+	// A lock file older than LockTimeout is assumed to be left behind by a process that exited and is removed.
+	LockTimeout time.Duration
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.Dir, key)
+}
+
+func (s *FileStore) Load(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *FileStore) Save(key string, data []byte) error {
+	err := os.MkdirAll(s.Dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.Dir, key+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Chmod(f.Name(), 0600)
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(key))
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
+
+func (s *FileStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Lock creates a lock file for the given key, waiting for any existing lock to be released.
+func (s *FileStore) Lock(key string) (func() error, error) {
+	err := os.MkdirAll(s.Dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := s.LockTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	name := s.path(key) + ".lock"
+	start := time.Now()
+	delay := 10 * time.Millisecond
+
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+
+			return func() error {
+				return os.Remove(name)
+			}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, serr := os.Stat(name); serr == nil && time.Since(info.ModTime()) > timeout {
+			// Stale lock
+			_ = os.Remove(name)
+			continue
+		}
+
+		if time.Since(start) > timeout {
+			return nil, fmt.Errorf("timeout waiting for lock %s", name)
+		}
+
+		time.Sleep(delay)
+		if delay < time.Second {
+			delay *= 2
+		}
+	}
+}
+
+// Keyring is implemented by OS credential stores, such as the macOS Keychain,
+// Windows Credential Manager or Secret Service on Linux.
+// Get must return ErrNotFound if no secret exists for the given service and user.
+type Keyring interface {
+	Get(service, user string) (string, error)
+	Set(service, user, secret string) error
+	Delete(service, user string) error
+}
+
+// KeyringStore is a Store that saves session data to a Keyring, using the key as the user name.
+type KeyringStore struct {
+	Keyring Keyring
+	Service string
+}
+
+func (s *KeyringStore) Load(key string) ([]byte, error) {
+	secret, err := s.Keyring.Get(s.Service, key)
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []byte(secret), nil
+}
+
+func (s *KeyringStore) Save(key string, data []byte) error {
+	return s.Keyring.Set(s.Service, key, string(data))
+}
+
+func (s *KeyringStore) Delete(key string) error {
+	err := s.Keyring.Delete(s.Service, key)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
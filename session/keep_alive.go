@@ -117,7 +117,7 @@ func (k *keepAlive) RoundTrip(ctx context.Context, req, res soap.HasFault) error
 
 	// Start ticker on login, stop ticker on logout.
 	switch req.(type) {
-	case *methods.LoginBody, *methods.LoginExtensionByCertificateBody:
+	case *methods.LoginBody, *methods.LoginExtensionByCertificateBody, *methods.CloneSessionBody:
 		k.start()
 	case *methods.LogoutBody:
 		k.stop()
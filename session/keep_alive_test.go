@@ -164,16 +164,6 @@ func TestRealKeepAlive(t *testing.T) {
 	testSessionOK(t, m2, true)
 }
 
-func isNotAuthenticated(err error) bool {
-	if soap.IsSoapFault(err) {
-		switch soap.ToSoapFault(err).VimFault().(type) {
-		case types.NotAuthenticated:
-			return true
-		}
-	}
-	return false
-}
-
 func isInvalidLogin(err error) bool {
 	if soap.IsSoapFault(err) {
 		switch soap.ToSoapFault(err).VimFault().(type) {
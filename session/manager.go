@@ -105,6 +105,57 @@ func (sm *Manager) LoginExtensionByCertificate(ctx context.Context, key string,
 	return nil
 }
 
+// LoginByToken creates a session using a SAML token, which must be set as the Security field of
+// a soap.Header in the given context, for example a Signer issued by the sts package:
+//
+//	header := soap.Header{Security: signer}
+//	err = m.LoginByToken(soap.WithHeader(ctx, header))
+func (sm *Manager) LoginByToken(ctx context.Context) error {
+	req := types.LoginByToken{
+		This:   sm.Reference(),
+		Locale: Locale,
+	}
+
+	login, err := methods.LoginByToken(ctx, sm.client, &req)
+	if err != nil {
+		return err
+	}
+
+	sm.userSession = &login.Returnval
+	return nil
+}
+
+// AcquireCloneTicket acquires a ticket that can be used with CloneSession,
+// by another client, to create a new session for the current user.
+func (sm *Manager) AcquireCloneTicket(ctx context.Context) (string, error) {
+	req := types.AcquireCloneTicket{
+		This: sm.Reference(),
+	}
+
+	res, err := methods.AcquireCloneTicket(ctx, sm.client, &req)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Returnval, nil
+}
+
+// CloneSession creates a new session using a ticket acquired by AcquireCloneTicket.
+func (sm *Manager) CloneSession(ctx context.Context, ticket string) error {
+	req := types.CloneSession{
+		This:        sm.Reference(),
+		CloneTicket: ticket,
+	}
+
+	res, err := methods.CloneSession(ctx, sm.client, &req)
+	if err != nil {
+		return err
+	}
+
+	sm.userSession = &res.Returnval
+	return nil
+}
+
 func (sm *Manager) Logout(ctx context.Context) error {
 	req := types.Logout{
 		This: sm.Reference(),
@@ -135,6 +186,10 @@ func (sm *Manager) UserSession(ctx context.Context) (*types.UserSession, error)
 			}
 		}
 
+		if isNotAuthenticated(err) {
+			return nil, nil
+		}
+
 		return nil, err
 	}
 
@@ -0,0 +1,263 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrPoolClosed is returned by Pool.Get once the Pool has been closed.
+var ErrPoolClosed = errors.New("session pool closed")
+
+// Pool leases independent sessions, cloned from an authenticated client, to concurrent workers.
+// vCenter serializes some operations per session, a Pool allows such operations to run in parallel.
+// Each session has its own cookie jar and connections, see soap.Client.Clone.
+type Pool struct {
+	// KeepAlive, if non-zero, is the idle time after which each session is kept alive.
+	KeepAlive time.Duration
+
+	// CheckInterval is the idle time after which a session is checked with
+	// SessionIsActive before it is leased. Sessions that are no longer active
+	// are replaced. A negative value disables health checks.
+	CheckInterval time.Duration
+
+	client *vim25.Client
+	size   int
+
+	mu      sync.Mutex
+	members map[*vim25.Client]*poolMember
+	idle    []*poolMember
+	count   int
+	closed  bool
+	notify  chan struct{}
+}
+
+type poolMember struct {
+	client  *vim25.Client
+	manager *Manager
+	used    time.Time
+}
+
+// NewPool returns a Pool of up to size sessions, cloned from the given authenticated client.
+// The client must be a vim25.Client with a soap.Client, sessions are created on demand by Get.
+func NewPool(client *vim25.Client, size int) *Pool {
+	return &Pool{
+		CheckInterval: time.Minute,
+		client:        client,
+		size:          size,
+		members:       make(map[*vim25.Client]*poolMember),
+		notify:        make(chan struct{}),
+	}
+}
+
+// Get leases a session from the pool, waiting until one is available or ctx is done.
+// The session must be returned to the pool using Put.
+func (p *Pool) Get(ctx context.Context) (*vim25.Client, error) {
+	for {
+		p.mu.Lock()
+
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		if n := len(p.idle); n != 0 {
+			m := p.idle[n-1]
+			p.idle = p.idle[:n-1]
+			p.mu.Unlock()
+
+			if p.active(ctx, m) {
+				return m.client, nil
+			}
+
+			// Replace the dead session
+			p.discard(ctx, m)
+			continue
+		}
+
+		if p.count < p.size {
+			p.count++
+			p.mu.Unlock()
+
+			m, err := p.clone(ctx)
+			if err != nil {
+				p.mu.Lock()
+				p.count--
+				p.mu.Unlock()
+				p.broadcast()
+				return nil, err
+			}
+
+			p.mu.Lock()
+			p.members[m.client] = m
+			p.mu.Unlock()
+
+			return m.client, nil
+		}
+
+		notify := p.notify
+		p.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Put returns a session leased by Get to the pool.
+func (p *Pool) Put(client *vim25.Client) {
+	p.mu.Lock()
+
+	m, ok := p.members[client]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	m.used = time.Now()
+
+	if p.closed {
+		p.mu.Unlock()
+		p.discard(context.Background(), m)
+		return
+	}
+
+	p.idle = append(p.idle, m)
+	p.mu.Unlock()
+
+	p.broadcast()
+}
+
+// broadcast wakes up any callers waiting in Get.
+func (p *Pool) broadcast() {
+	p.mu.Lock()
+	close(p.notify)
+	p.notify = make(chan struct{})
+	p.mu.Unlock()
+}
+
+// clone creates a new session using a clone ticket acquired by the pool's client.
+func (p *Pool) clone(ctx context.Context) (*poolMember, error) {
+	if p.client.Client == nil {
+		return nil, errors.New("session pool requires a soap.Client")
+	}
+
+	ticket, err := NewManager(p.client).AcquireCloneTicket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := p.client.Client.Clone()
+
+	c := &vim25.Client{
+		Client:         sc,
+		ServiceContent: p.client.ServiceContent,
+		RoundTripper:   sc,
+	}
+
+	if p.KeepAlive > 0 {
+		c.RoundTripper = KeepAlive(sc, p.KeepAlive)
+	}
+
+	m := &poolMember{
+		client:  c,
+		manager: NewManager(c),
+		used:    time.Now(),
+	}
+
+	if err = m.manager.CloneSession(ctx, ticket); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// active checks if the member's session is still active, if it has been idle longer than CheckInterval.
+func (p *Pool) active(ctx context.Context, m *poolMember) bool {
+	if p.CheckInterval < 0 || time.Since(m.used) < p.CheckInterval {
+		return true
+	}
+
+	s := m.manager.userSession
+
+	req := types.SessionIsActive{
+		This:      m.manager.Reference(),
+		SessionID: s.Key,
+		UserName:  s.UserName,
+	}
+
+	res, err := methods.SessionIsActive(ctx, p.client, &req)
+	if err == nil {
+		return res.Returnval
+	}
+
+	// SessionIsActive is only supported by vCenter, check the session itself instead.
+	_, err = methods.GetCurrentTime(ctx, m.client)
+	return !isNotAuthenticated(err)
+}
+
+// discard removes the member from the pool and logs out its session.
+func (p *Pool) discard(ctx context.Context, m *poolMember) error {
+	p.mu.Lock()
+	delete(p.members, m.client)
+	p.count--
+	p.mu.Unlock()
+
+	p.broadcast()
+
+	err := m.manager.Logout(ctx)
+	if isNotAuthenticated(err) {
+		err = nil
+	}
+
+	if k, ok := m.client.RoundTripper.(*keepAlive); ok {
+		k.stop()
+	}
+
+	return err
+}
+
+// Close logs out of all idle sessions. Sessions currently leased are logged out when returned by Put.
+// Get returns ErrPoolClosed once Close has been called.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	p.broadcast()
+
+	var err error
+
+	for _, m := range idle {
+		if lerr := p.discard(ctx, m); lerr != nil && err == nil {
+			err = lerr
+		}
+	}
+
+	return err
+}
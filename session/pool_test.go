@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	c, err := vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := NewManager(c)
+	if err = admin.Login(ctx, s.URL.User); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPool(c, 2)
+	p.KeepAlive = time.Minute
+
+	var wg sync.WaitGroup
+	clients := make([]*vim25.Client, 2)
+	keys := make(map[string]bool)
+
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			clients[i], err = p.Get(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, member := range clients {
+		if _, err = methods.GetCurrentTime(ctx, member); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := NewManager(member).UserSession(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys[s.Key] = true
+	}
+
+	if len(keys) != 2 {
+		t.Errorf("expected 2 sessions, got %d", len(keys))
+	}
+
+	// Pool is exhausted
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	_, err = p.Get(tctx)
+	cancel()
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected timeout, got %v", err)
+	}
+
+	// Waiting Get is unblocked by Put
+	go p.Put(clients[0])
+	member, err := p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != clients[0] {
+		t.Error("expected idle session to be reused")
+	}
+
+	// Dead sessions are replaced
+	dead := p.members[member].manager.userSession.Key
+	if err = admin.TerminateSession(ctx, []string{dead}); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Put(member)
+	p.CheckInterval = 0
+
+	member, err = p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if member == clients[0] {
+		t.Error("expected dead session to be replaced")
+	}
+
+	if _, err = methods.GetCurrentTime(ctx, member); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Put(member)
+
+	if err = p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = p.Get(ctx); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+
+	// Leased session is logged out when returned
+	p.Put(clients[1])
+	if _, err = methods.GetCurrentTime(ctx, clients[1]); !isNotAuthenticated(err) {
+		t.Errorf("expected NotAuthenticated, got %v", err)
+	}
+
+	if _, err = methods.GetCurrentTime(ctx, member); !isNotAuthenticated(err) {
+		t.Errorf("expected NotAuthenticated, got %v", err)
+	}
+}
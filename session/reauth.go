@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type reauth struct {
+	sync.Mutex
+
+	roundTripper soap.RoundTripper
+
+	// login re-establishes the session, nil until a login succeeds.
+	login func(context.Context) error
+
+	// ticket is the last local ticket request, if any.
+	ticket *types.AcquireLocalTicket
+
+	// ticketUser is the user name of the last local ticket acquired.
+	ticketUser string
+
+	// generation is incremented after each successful login, such that
+	// concurrent requests failing with the same session only login once.
+	generation int
+}
+
+// Reauth wraps the specified soap.RoundTripper and remembers how the session was
+// established: Login, LoginExtensionByCertificate or Login with a local ticket.
+// When a request fails with a NotAuthenticated fault, for example after the
+// session expired or vCenter was restarted, the session is established once
+// again and the original request is retried. Concurrent requests that fail with
+// the same expired session wait for a single login to complete.
+// Once the user logs out, NotAuthenticated faults are returned as-is.
+func Reauth(roundTripper soap.RoundTripper) soap.RoundTripper {
+	r := &reauth{
+		roundTripper: roundTripper,
+	}
+
+	return r
+}
+
+// isNotAuthenticated returns true if err is a NotAuthenticated fault.
+func isNotAuthenticated(err error) bool {
+	if !soap.IsSoapFault(err) {
+		return false
+	}
+
+	switch soap.ToSoapFault(err).VimFault().(type) {
+	case types.NotAuthenticated, *types.NotAuthenticated:
+		return true
+	}
+
+	return false
+}
+
+func (r *reauth) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	r.Lock()
+	generation := r.generation
+	r.Unlock()
+
+	err := r.roundTripper.RoundTrip(ctx, req, res)
+	if err != nil {
+		if isNotAuthenticated(err) && r.relogin(ctx, generation) {
+			soap.ResetResponse(res)
+			err = r.roundTripper.RoundTrip(ctx, req, res)
+		}
+
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	switch body := req.(type) {
+	case *methods.LoginBody:
+		login := *body.Req
+		if r.ticket != nil && login.UserName == r.ticketUser {
+			// Ticket passwords can only be used once, a new ticket is acquired for each login.
+			ticket := *r.ticket
+			r.login = func(ctx context.Context) error {
+				return r.loginByLocalTicket(ctx, ticket, login)
+			}
+		} else {
+			r.login = func(ctx context.Context) error {
+				_, err := methods.Login(ctx, r.roundTripper, &login)
+				return err
+			}
+		}
+		r.ticket = nil
+		r.generation++
+	case *methods.LoginExtensionByCertificateBody:
+		login := *body.Req
+		r.login = func(ctx context.Context) error {
+			_, err := methods.LoginExtensionByCertificate(ctx, r.roundTripper, &login)
+			return err
+		}
+		r.generation++
+	case *methods.AcquireLocalTicketBody:
+		ticket := *body.Req
+		r.ticket = &ticket
+		r.ticketUser = res.(*methods.AcquireLocalTicketBody).Res.Returnval.UserName
+	case *methods.LogoutBody:
+		r.login = nil
+		r.ticket = nil
+	}
+
+	return nil
+}
+
+// relogin establishes a new session, unless another request already did so
+// since generation was observed. Returns true if the request should be retried.
+func (r *reauth) relogin(ctx context.Context, generation int) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.login == nil {
+		return false
+	}
+
+	if r.generation != generation {
+		return true
+	}
+
+	if err := r.login(ctx); err != nil {
+		return false
+	}
+
+	r.generation++
+
+	return true
+}
+
+func (r *reauth) loginByLocalTicket(ctx context.Context, ticket types.AcquireLocalTicket, login types.Login) error {
+	res, err := methods.AcquireLocalTicket(ctx, r.roundTripper, &ticket)
+	if err != nil {
+		return err
+	}
+
+	password, err := ioutil.ReadFile(res.Returnval.PasswordFilePath)
+	if err != nil {
+		return err
+	}
+
+	login.UserName = res.Returnval.UserName
+	login.Password = string(password)
+
+	_, err = methods.Login(ctx, r.roundTripper, &login)
+	return err
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+type countLogin struct {
+	sync.Mutex
+	soap.RoundTripper
+	count int
+}
+
+func (c *countLogin) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	switch req.(type) {
+	case *methods.LoginBody, *methods.LoginExtensionByCertificateBody:
+		c.Lock()
+		c.count++
+		c.Unlock()
+	}
+
+	return c.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+func TestReauth(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	newClient := func() *vim25.Client {
+		c, err := vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	admin := NewManager(newClient())
+	if err = admin.Login(ctx, s.URL.User); err != nil {
+		t.Fatal(err)
+	}
+
+	// terminate expires the session of the given Manager
+	terminate := func(sm *Manager) {
+		if err = admin.TerminateSession(ctx, []string{sm.userSession.Key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, login := range []func(*Manager) error{
+		func(sm *Manager) error { return sm.Login(ctx, s.URL.User) },
+		func(sm *Manager) error { return sm.LoginExtensionByCertificate(ctx, "com.vmware.test", "") },
+	} {
+		c := newClient()
+		counter := &countLogin{RoundTripper: c.RoundTripper}
+		c.RoundTripper = Reauth(counter)
+		sm := NewManager(c)
+
+		if err = login(sm); err != nil {
+			t.Fatal(err)
+		}
+
+		terminate(sm)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := methods.GetCurrentTime(ctx, c); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if counter.count != 2 {
+			t.Errorf("expected 2 logins, got %d", counter.count)
+		}
+
+		// After Logout, NotAuthenticated is returned as-is
+		if err = sm.Logout(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = methods.GetCurrentTime(ctx, c)
+		if !isNotAuthenticated(err) {
+			t.Errorf("expected NotAuthenticated, got %v", err)
+		}
+
+		if counter.count != 2 {
+			t.Errorf("expected 2 logins, got %d", counter.count)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ComputeResource simulates the vim.ComputeResource managed object of a standalone host
+type ComputeResource struct {
+	mo.ComputeResource
+}
+
+// ClusterComputeResource simulates the vim.ClusterComputeResource managed object
+type ClusterComputeResource struct {
+	mo.ClusterComputeResource
+}
+
+// CreateStandaloneHost creates a ComputeResource in the given host Folder,
+// containing a single HostSystem and root ResourcePool.
+func CreateStandaloneHost(r *Registry, f *Folder, name string) *HostSystem {
+	summary := &types.ComputeResourceSummary{
+		OverallStatus: types.ManagedEntityStatusGreen,
+	}
+
+	cr := &ComputeResource{}
+	cr.Name = name
+	cr.Summary = summary
+
+	dc := r.getEntityDatacenter(f)
+	if dc.isESX() {
+		cr.Self = types.ManagedObjectReference{Type: "ComputeResource", Value: "ha-compute-res"}
+	}
+
+	f.putChild(r, cr)
+
+	createRootPool(r, &cr.ComputeResource)
+
+	host := NewHostSystem(name)
+	addHost(r, &cr.ComputeResource, summary, host)
+
+	return host
+}
+
+// CreateClusterComputeResource creates a ClusterComputeResource with no hosts in the given host Folder
+func CreateClusterComputeResource(r *Registry, f *Folder, name string) *ClusterComputeResource {
+	summary := &types.ClusterComputeResourceSummary{}
+	summary.OverallStatus = types.ManagedEntityStatusGreen
+
+	c := &ClusterComputeResource{}
+	c.Name = name
+	c.Summary = summary
+
+	f.putChild(r, c)
+
+	createRootPool(r, &c.ComputeResource)
+
+	return c
+}
+
+// addHost creates a HostSystem with the given name in the cluster
+func (c *ClusterComputeResource) addHost(r *Registry, name string) *HostSystem {
+	summary := c.Summary.(*types.ClusterComputeResourceSummary)
+
+	host := NewHostSystem(name)
+	addHost(r, &c.ComputeResource, &summary.ComputeResourceSummary, host)
+
+	return host
+}
+
+// computeResourceHosts returns the hosts of the given ComputeResource or ClusterComputeResource
+func computeResourceHosts(e mo.Entity) []types.ManagedObjectReference {
+	switch cr := e.(type) {
+	case *ComputeResource:
+		return cr.Host
+	case *ClusterComputeResource:
+		return cr.Host
+	}
+
+	return nil
+}
+
+// hostComputeResource returns the ComputeResource (or ClusterComputeResource) containing the given host
+func hostComputeResource(r *Registry, host *HostSystem) *mo.ComputeResource {
+	switch cr := r.Get(*host.Parent).(type) {
+	case *ComputeResource:
+		return &cr.ComputeResource
+	case *ClusterComputeResource:
+		return &cr.ComputeResource
+	}
+
+	return nil
+}
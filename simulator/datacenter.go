@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// esxDatacenter is the reference of the single Datacenter simulated in ESX mode
+var esxDatacenter = types.ManagedObjectReference{
+	Type:  "Datacenter",
+	Value: "ha-datacenter",
+}
+
+// Datacenter simulates the vim.Datacenter managed object
+type Datacenter struct {
+	mo.Datacenter
+}
+
+// isESX returns true if the Datacenter is the ha-datacenter of a simulated ESX host,
+// in which case its inventory uses the fixed "ha-" references of a host agent.
+func (dc *Datacenter) isESX() bool {
+	return dc.Self == esxDatacenter
+}
+
+// createDatacenter adds the given Datacenter to the parent Folder along with its
+// vm, host, datastore and network folders.
+func createDatacenter(r *Registry, parent *Folder, dc *Datacenter) {
+	parent.putChild(r, dc)
+
+	folders := []struct {
+		ref   *types.ManagedObjectReference
+		name  string
+		types []string
+	}{
+		{&dc.VmFolder, "vm", []string{"Folder", "VirtualMachine", "VirtualApp"}},
+		{&dc.HostFolder, "host", []string{"Folder", "ComputeResource"}},
+		{&dc.DatastoreFolder, "datastore", []string{"Folder", "Datastore", "StoragePod"}},
+		{&dc.NetworkFolder, "network", []string{"Folder", "Network", "DistributedVirtualSwitch"}},
+	}
+
+	for _, x := range folders {
+		f := &Folder{}
+
+		f.Name = x.name
+		f.ChildType = x.types
+
+		if dc.isESX() {
+			f.Self = types.ManagedObjectReference{Type: "Folder", Value: "ha-folder-" + x.name}
+		}
+
+		r.Put(f)
+		f.Parent = &dc.Self
+
+		*x.ref = f.Self
+	}
+}
+
+// folder returns the Folder of the Datacenter for the given reference, such as dc.HostFolder
+func (dc *Datacenter) folder(r *Registry, ref types.ManagedObjectReference) *Folder {
+	return r.Get(ref).(*Folder)
+}
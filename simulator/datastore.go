@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Datastore simulates the vim.Datastore managed object
+type Datastore struct {
+	mo.Datastore
+}
+
+// createDatastore creates a local Datastore in the datastore Folder of the given Datacenter
+func createDatastore(r *Registry, dc *Datacenter, name string) *Datastore {
+	ds := &Datastore{}
+
+	id := newUUID()
+	url := "ds:///vmfs/volumes/" + id + "/"
+	now := time.Now()
+
+	var capacity int64 = 1024 * 1024 * 1024 * 1024
+	free := capacity / 2
+
+	ds.Name = name
+	ds.OverallStatus = types.ManagedEntityStatusGreen
+
+	ds.Info = &types.LocalDatastoreInfo{
+		DatastoreInfo: types.DatastoreInfo{
+			Name:        name,
+			Url:         url,
+			FreeSpace:   free,
+			MaxFileSize: capacity,
+			Timestamp:   &now,
+		},
+		Path: "/vmfs/volumes/" + id,
+	}
+
+	ds.Summary = types.DatastoreSummary{
+		Name:               name,
+		Url:                url,
+		Capacity:           capacity,
+		FreeSpace:          free,
+		Accessible:         true,
+		MultipleHostAccess: types.NewBool(false),
+		Type:               "OTHER",
+	}
+
+	ds.Capability = types.DatastoreCapability{
+		DirectoryHierarchySupported:      true,
+		PerFileThinProvisioningSupported: true,
+	}
+
+	dc.folder(r, dc.DatastoreFolder).putChild(r, ds)
+
+	ds.Summary.Datastore = &ds.Self
+	dc.Datastore = append(dc.Datastore, ds.Self)
+
+	return ds
+}
+
+// mount makes the Datastore available to the given host and its ComputeResource
+func (ds *Datastore) mount(r *Registry, host *HostSystem) {
+	info := ds.Info.GetDatastoreInfo()
+
+	ds.Host = append(ds.Host, types.DatastoreHostMount{
+		Key: host.Self,
+		MountInfo: types.HostMountInfo{
+			Path:       strings.TrimPrefix(info.Url, "ds://"),
+			AccessMode: string(types.HostMountModeReadWrite),
+			Mounted:    types.NewBool(true),
+			Accessible: types.NewBool(true),
+		},
+	})
+
+	host.Datastore = AddReference(ds.Self, host.Datastore)
+
+	if cr := hostComputeResource(r, host); cr != nil {
+		cr.Datastore = AddReference(ds.Self, cr.Datastore)
+	}
+}
+
+// parseDatastorePath splits a path of the form "[datastore] path" into its datastore name and path
+func parseDatastorePath(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return "", "", false
+	}
+
+	i := strings.Index(s, "]")
+	if i < 0 {
+		return "", "", false
+	}
+
+	name := s[1:i]
+	if name == "" {
+		return "", "", false
+	}
+
+	return name, strings.TrimSpace(s[i+1:]), true
+}
@@ -0,0 +1,42 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package simulator is a mock framework for the vSphere API.
+
+The simulator serves the vim25 SOAP API over an httptest Server, backed by an
+in-memory inventory of managed objects. A Model populates the inventory with a
+typical vCenter (VPX) or standalone ESX layout of datacenters, clusters, hosts,
+resource pools, datastores, networks and virtual machines:
+
+	model := simulator.VPX()
+
+	err := model.Create()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	c, err := govmomi.NewClient(ctx, s.URL, true)
+
+Each managed object type is simulated by a type that embeds the mo type of the
+same name. Methods are dispatched by name, where a method named "Foo_Task" is
+implemented by a Go method named "FooTask". Methods that create a Task run to
+completion before returning the Task reference.
+*/
+package simulator
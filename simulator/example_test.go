@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Example() {
+	ctx := context.Background()
+
+	model := simulator.VPX()
+
+	err := model.Create()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	c, err := govmomi.NewClient(ctx, s.URL, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	finder := find.NewFinder(c.Client, false)
+
+	vm, err := finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	task, err := vm.PowerOn(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = task.Wait(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	state, err := vm.PowerState(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(state)
+	// Output: poweredOn
+}
+
+func ExampleModel_Run() {
+	err := simulator.ESX().Run(func(ctx context.Context, c *vim25.Client) error {
+		fmt.Println(c.ServiceContent.About.ApiType)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output: HostAgent
+}
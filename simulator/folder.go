@@ -0,0 +1,266 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Folder simulates the vim.Folder managed object
+type Folder struct {
+	mo.Folder
+}
+
+// putChild adds the given entity to the Registry and links it as a child of the Folder
+func (f *Folder) putChild(r *Registry, o mo.Entity) {
+	r.Put(o)
+
+	o.Entity().Parent = &f.Self
+
+	f.ChildEntity = AddReference(o.Reference(), f.ChildEntity)
+}
+
+// removeChild unlinks the given reference from the Folder and removes it from the Registry
+func (f *Folder) removeChild(r *Registry, ref types.ManagedObjectReference) {
+	r.Remove(ref)
+
+	f.ChildEntity = RemoveReference(ref, f.ChildEntity)
+}
+
+func (f *Folder) hasChildType(kind string) bool {
+	for _, t := range f.ChildType {
+		if t == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeNotSupported returns the fault for a Folder method that creates a child type the Folder cannot contain
+func (f *Folder) typeNotSupported() *soap.Fault {
+	return Fault(fmt.Sprintf("%s supports types: %#v", f.Self, f.ChildType), &types.NotSupported{})
+}
+
+// duplicateName returns a DuplicateName fault if a child of the Folder has the given name
+func (f *Folder) duplicateName(ctx *Context, name string) types.BaseMethodFault {
+	if e := ctx.Map.FindByName(name, f.ChildEntity); e != nil {
+		return &types.DuplicateName{
+			Name:   name,
+			Object: e.Reference(),
+		}
+	}
+
+	return nil
+}
+
+func (f *Folder) CreateFolder(ctx *Context, c *types.CreateFolder) soap.HasFault {
+	body := &methods.CreateFolderBody{}
+
+	if !f.hasChildType("Folder") {
+		body.Fault_ = f.typeNotSupported()
+		return body
+	}
+
+	if err := f.duplicateName(ctx, c.Name); err != nil {
+		body.Fault_ = Fault("", err)
+		return body
+	}
+
+	folder := &Folder{}
+
+	folder.Name = c.Name
+	folder.ChildType = f.ChildType
+
+	f.putChild(ctx.Map, folder)
+
+	body.Res = &types.CreateFolderResponse{
+		Returnval: folder.Self,
+	}
+
+	return body
+}
+
+func (f *Folder) CreateDatacenter(ctx *Context, c *types.CreateDatacenter) soap.HasFault {
+	body := &methods.CreateDatacenterBody{}
+
+	if !f.hasChildType("Datacenter") {
+		body.Fault_ = f.typeNotSupported()
+		return body
+	}
+
+	if err := f.duplicateName(ctx, c.Name); err != nil {
+		body.Fault_ = Fault("", err)
+		return body
+	}
+
+	dc := &Datacenter{}
+	dc.Name = c.Name
+
+	createDatacenter(ctx.Map, f, dc)
+
+	body.Res = &types.CreateDatacenterResponse{
+		Returnval: dc.Self,
+	}
+
+	return body
+}
+
+// CreateVMTask creates a VirtualMachine in the Folder.
+// The VM is placed on the given host, or the first host of the pool owner's ComputeResource if not specified.
+func (f *Folder) CreateVMTask(ctx *Context, c *types.CreateVM_Task) soap.HasFault {
+	body := &methods.CreateVM_TaskBody{}
+
+	if !f.hasChildType("VirtualMachine") {
+		body.Fault_ = f.typeNotSupported()
+		return body
+	}
+
+	task := CreateTask(f, "createVm", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		config := &c.Config
+
+		if config.Name == "" {
+			return nil, &types.InvalidName{Name: config.Name}
+		}
+
+		if err := f.duplicateName(ctx, config.Name); err != nil {
+			return nil, err
+		}
+
+		path := ""
+		if config.Files != nil {
+			path = config.Files.VmPathName
+		}
+
+		dsName, _, ok := parseDatastorePath(path)
+		if !ok {
+			return nil, &types.InvalidDatastorePath{DatastorePath: path}
+		}
+
+		pool, ok := ctx.Map.Get(c.Pool).(*ResourcePool)
+		if !ok {
+			return nil, &types.ManagedObjectNotFound{Obj: c.Pool}
+		}
+
+		var host *HostSystem
+
+		if c.Host != nil {
+			host, ok = ctx.Map.Get(*c.Host).(*HostSystem)
+			if !ok {
+				return nil, &types.ManagedObjectNotFound{Obj: *c.Host}
+			}
+		} else {
+			owner := ctx.Map.Get(pool.Owner).(mo.Entity)
+			hosts := computeResourceHosts(owner)
+			if len(hosts) == 0 {
+				return nil, &types.NoHost{}
+			}
+			host = ctx.Map.Get(hosts[0]).(*HostSystem)
+		}
+
+		dc := ctx.Map.getEntityDatacenter(f)
+
+		ds := ctx.Map.FindByName(dsName, dc.Datastore)
+		if ds == nil {
+			return nil, &types.InvalidDatastorePath{DatastorePath: path}
+		}
+
+		vm, err := NewVirtualMachine(config)
+		if err != nil {
+			return nil, err
+		}
+
+		f.putChild(ctx.Map, vm)
+
+		vm.attach(ctx, pool, host, ds.(*Datastore))
+
+		return vm.Self, nil
+	})
+
+	body.Res = &types.CreateVM_TaskResponse{
+		Returnval: task.Run(ctx),
+	}
+
+	return body
+}
+
+func (f *Folder) RenameTask(ctx *Context, r *types.Rename_Task) soap.HasFault {
+	return renameTask(ctx, f, r)
+}
+
+// DestroyTask destroys the Folder, which must not contain any child entities
+func (f *Folder) DestroyTask(ctx *Context, r *types.Destroy_Task) soap.HasFault {
+	task := CreateTask(f, "destroy", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if f.Parent == nil {
+			return nil, &types.NotSupported{} // root Folder
+		}
+
+		if len(f.ChildEntity) != 0 {
+			return nil, &types.ResourceInUse{Type: "Folder", Name: f.Name}
+		}
+
+		parent := ctx.Map.Get(*f.Parent).(*Folder)
+		parent.removeChild(ctx.Map, f.Self)
+
+		return nil, nil
+	})
+
+	return &methods.Destroy_TaskBody{
+		Res: &types.Destroy_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+// renameTask implements the Rename_Task method for any entity,
+// failing with DuplicateName if a sibling has the requested name.
+func renameTask(ctx *Context, e mo.Entity, r *types.Rename_Task) soap.HasFault {
+	task := CreateTask(e, "rename", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if r.NewName == "" {
+			return nil, &types.InvalidName{Name: r.NewName}
+		}
+
+		if parent := e.Entity().Parent; parent != nil {
+			if f, ok := ctx.Map.Get(*parent).(*Folder); ok {
+				if err := f.duplicateName(ctx, r.NewName); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		e.Entity().Name = r.NewName
+
+		if n, ok := e.(interface {
+			rename(string)
+		}); ok {
+			n.rename(r.NewName)
+		}
+
+		return nil, nil
+	})
+
+	return &methods.Rename_TaskBody{
+		Res: &types.Rename_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"time"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// esx is the AboutInfo of a simulated ESX host
+var esx = types.AboutInfo{
+	Name:                  "VMware ESXi",
+	FullName:              "VMware ESXi 6.0.0 build-3620759",
+	Vendor:                "VMware, Inc.",
+	Version:               "6.0.0",
+	Build:                 "3620759",
+	LocaleVersion:         "INTL",
+	LocaleBuild:           "000",
+	OsType:                "vmnix-x86",
+	ProductLineId:         "embeddedEsx",
+	ApiType:               "HostAgent",
+	ApiVersion:            "6.0",
+	LicenseProductName:    "VMware ESX Server",
+	LicenseProductVersion: "6.0",
+}
+
+// HostSystem simulates the vim.HostSystem managed object
+type HostSystem struct {
+	mo.HostSystem
+}
+
+// NewHostSystem returns a connected and powered on HostSystem with the given name
+func NewHostSystem(name string) *HostSystem {
+	h := &HostSystem{}
+
+	now := time.Now()
+	product := esx
+
+	h.Name = name
+	h.OverallStatus = types.ManagedEntityStatusGreen
+
+	h.Runtime = types.HostRuntimeInfo{
+		ConnectionState: types.HostSystemConnectionStateConnected,
+		PowerState:      types.HostSystemPowerStatePoweredOn,
+		BootTime:        &now,
+	}
+
+	h.Summary = types.HostListSummary{
+		Hardware: &types.HostHardwareSummary{
+			Vendor:        "VMware, Inc.",
+			Model:         "VMware Virtual Platform",
+			Uuid:          newUUID(),
+			MemorySize:    4 * 1024 * 1024 * 1024,
+			CpuModel:      "Intel(R) Core(TM) i7-3615QM CPU @ 2.30GHz",
+			CpuMhz:        2294,
+			NumCpuPkgs:    2,
+			NumCpuCores:   2,
+			NumCpuThreads: 2,
+			NumNics:       1,
+			NumHBAs:       3,
+		},
+		Runtime: &h.Runtime,
+		Config: types.HostConfigSummary{
+			Name:    name,
+			Port:    443,
+			Product: &product,
+		},
+		OverallStatus: types.ManagedEntityStatusGreen,
+	}
+
+	return h
+}
+
+// addHost adds the given host to a ComputeResource, updating its summary totals
+func addHost(r *Registry, cr *mo.ComputeResource, summary *types.ComputeResourceSummary, host *HostSystem) {
+	dc := r.getEntityDatacenter(cr)
+	if dc.isESX() {
+		host.Self = types.ManagedObjectReference{Type: "HostSystem", Value: "ha-host"}
+	}
+
+	r.Put(host)
+
+	host.Parent = &cr.Self
+	host.Summary.Host = &host.Self
+
+	cr.Host = append(cr.Host, host.Self)
+
+	hw := host.Summary.Hardware
+	cpu := hw.CpuMhz * int32(hw.NumCpuCores)
+	mem := hw.MemorySize
+
+	summary.TotalCpu += cpu
+	summary.TotalMemory += mem
+	summary.NumCpuCores += hw.NumCpuCores
+	summary.NumCpuThreads += hw.NumCpuThreads
+	summary.EffectiveCpu += cpu
+	summary.EffectiveMemory += mem / (1024 * 1024)
+	summary.NumHosts++
+	summary.NumEffectiveHosts++
+}
@@ -0,0 +1,345 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Model is used to populate a Model with an initial set of managed entities.
+// This is a simple helper for tests running against a simulator, to populate an inventory
+// with commonly used models.
+type Model struct {
+	types.ServiceContent
+
+	// Datacenter specifies the number of Datacenter entities to create
+	Datacenter int
+
+	// Datastore specifies the number of Datastore entities to create in each Datacenter
+	Datastore int
+
+	// Host specifies the number of standalone HostSystems entities to create per Datacenter
+	Host int
+
+	// Cluster specifies the number of ClusterComputeResource entities to create per Datacenter
+	Cluster int
+
+	// ClusterHost specifies the number of HostSystems entities to create within a Cluster
+	ClusterHost int
+
+	// Pool specifies the number of ResourcePool entities to create per Cluster
+	Pool int
+
+	// Machine specifies the number of VirtualMachine entities to create per ResourcePool
+	// of each standalone host and cluster
+	Machine int
+
+	// Service is the simulator Service created by the Create method
+	Service *Service
+}
+
+// ESX is the default Model for a standalone ESX instance
+func ESX() *Model {
+	return &Model{
+		ServiceContent: types.ServiceContent{
+			RootFolder:        types.ManagedObjectReference{Type: "Folder", Value: "ha-folder-root"},
+			PropertyCollector: types.ManagedObjectReference{Type: "PropertyCollector", Value: "ha-property-collector"},
+			ViewManager:       &types.ManagedObjectReference{Type: "ViewManager", Value: "ViewManager"},
+			About:             esx,
+			SessionManager:    &types.ManagedObjectReference{Type: "SessionManager", Value: "ha-sessionmgr"},
+			SearchIndex:       &types.ManagedObjectReference{Type: "SearchIndex", Value: "ha-searchindex"},
+		},
+		Datastore: 1,
+		Machine:   2,
+	}
+}
+
+// VPX is the default Model for a vCenter instance
+func VPX() *Model {
+	return &Model{
+		ServiceContent: types.ServiceContent{
+			RootFolder:        types.ManagedObjectReference{Type: "Folder", Value: "group-d1"},
+			PropertyCollector: types.ManagedObjectReference{Type: "PropertyCollector", Value: "propertyCollector"},
+			ViewManager:       &types.ManagedObjectReference{Type: "ViewManager", Value: "ViewManager"},
+			About: types.AboutInfo{
+				Name:                  "VMware vCenter Server",
+				FullName:              "VMware vCenter Server 6.0.0 build-3634794",
+				Vendor:                "VMware, Inc.",
+				Version:               "6.0.0",
+				Build:                 "3634794",
+				LocaleVersion:         "INTL",
+				LocaleBuild:           "000",
+				OsType:                "linux-x64",
+				ProductLineId:         "vpx",
+				ApiType:               "VirtualCenter",
+				ApiVersion:            "6.0",
+				InstanceUuid:          newUUID(),
+				LicenseProductName:    "VMware VirtualCenter Server",
+				LicenseProductVersion: "6.0",
+			},
+			SessionManager: &types.ManagedObjectReference{Type: "SessionManager", Value: "SessionManager"},
+			SearchIndex:    &types.ManagedObjectReference{Type: "SearchIndex", Value: "SearchIndex"},
+		},
+		Datacenter:  1,
+		Datastore:   1,
+		Host:        1,
+		Cluster:     1,
+		ClusterHost: 3,
+		Pool:        1,
+		Machine:     2,
+	}
+}
+
+// IsESX returns true if the Model is a standalone ESX instance
+func (m *Model) IsESX() bool {
+	return m.About.ApiType == esx.ApiType
+}
+
+// placement is a location for the VMs created by the Model
+type placement struct {
+	name string
+	pool *ResourcePool
+}
+
+// Create populates the Model with the given ServiceContent and inventory,
+// setting the Service field to a simulator Service for the resulting Registry.
+func (m *Model) Create() error {
+	r := NewRegistry()
+
+	NewServiceInstance(m.ServiceContent, r)
+
+	// Methods of the simulated objects are used to populate the inventory
+	ctx := &Context{Map: r}
+
+	root := &Folder{}
+	root.Self = m.RootFolder
+	root.Name = "Datacenters"
+	root.ChildType = []string{"Folder", "Datacenter"}
+
+	datacenters := m.Datacenter
+	hosts := m.Host
+	clusters := m.Cluster
+
+	if m.IsESX() {
+		root.Name = m.RootFolder.Value
+		root.ChildType = []string{"Datacenter"}
+		datacenters, hosts, clusters = 1, 1, 0
+	}
+
+	r.Put(root)
+
+	for i := 0; i < datacenters; i++ {
+		dc := &Datacenter{}
+		dc.Name = fmt.Sprintf("DC%d", i)
+
+		if m.IsESX() {
+			dc.Self = esxDatacenter
+			dc.Name = esxDatacenter.Value
+		}
+
+		createDatacenter(r, root, dc)
+
+		folder := dc.folder(r, dc.HostFolder)
+
+		var targets []placement
+		var all []*HostSystem
+
+		for j := 0; j < hosts; j++ {
+			name := fmt.Sprintf("%s_H%d", dc.Name, j)
+			if m.IsESX() {
+				name = "localhost.localdomain"
+			}
+
+			host := CreateStandaloneHost(r, folder, name)
+			all = append(all, host)
+
+			if m.IsESX() {
+				name = host.Self.Value
+			}
+
+			pool := r.Get(*hostComputeResource(r, host).ResourcePool).(*ResourcePool)
+			targets = append(targets, placement{name, pool})
+		}
+
+		for j := 0; j < clusters; j++ {
+			name := fmt.Sprintf("%s_C%d", dc.Name, j)
+
+			cluster := CreateClusterComputeResource(r, folder, name)
+
+			for k := 0; k < m.ClusterHost; k++ {
+				all = append(all, cluster.addHost(r, fmt.Sprintf("%s_H%d", name, k)))
+			}
+
+			pool := r.Get(*cluster.ResourcePool).(*ResourcePool)
+			targets = append(targets, placement{name, pool})
+
+			for k := 1; k <= m.Pool; k++ {
+				res := pool.CreateResourcePool(ctx, &types.CreateResourcePool{
+					Name: fmt.Sprintf("%s_RP%d", name, k),
+					Spec: newResourceConfigSpec(),
+				})
+
+				if f := res.Fault(); f != nil {
+					return soap.WrapSoapFault(f)
+				}
+			}
+		}
+
+		network := createNetwork(r, dc, "VM Network")
+
+		var datastores []*Datastore
+		for j := 0; j < m.Datastore; j++ {
+			datastores = append(datastores, createDatastore(r, dc, fmt.Sprintf("LocalDS_%d", j)))
+		}
+
+		for _, host := range all {
+			network.attach(r, host)
+
+			for _, ds := range datastores {
+				ds.mount(r, host)
+			}
+		}
+
+		if len(datastores) == 0 {
+			continue
+		}
+
+		vmFolder := dc.folder(r, dc.VmFolder)
+
+		for _, target := range targets {
+			for k := 0; k < m.Machine; k++ {
+				spec := m.machineSpec(fmt.Sprintf("%s_VM%d", target.name, k), datastores[0].Name, network.Name)
+
+				res := vmFolder.CreateVMTask(ctx, &types.CreateVM_Task{
+					Config: spec,
+					Pool:   target.pool.Self,
+				})
+
+				task := r.Get(res.(*methods.CreateVM_TaskBody).Res.Returnval).(*Task)
+				if task.Info.Error != nil {
+					return soap.WrapVimFault(task.Info.Error.Fault)
+				}
+			}
+		}
+	}
+
+	m.Service = New(r)
+
+	return nil
+}
+
+// Run calls f with a vim25.Client of a new Server for the Model, logged in using the
+// credentials of the Server URL. The Model is created if its Service is not yet set,
+// and the Server is closed once f returns.
+func (m *Model) Run(f func(context.Context, *vim25.Client) error) error {
+	ctx := context.Background()
+
+	if m.Service == nil {
+		if err := m.Create(); err != nil {
+			return err
+		}
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	password, _ := s.URL.User.Password()
+	req := types.Login{
+		This:     *c.ServiceContent.SessionManager,
+		UserName: s.URL.User.Username(),
+		Password: password,
+	}
+
+	if _, err = methods.Login(ctx, c, &req); err != nil {
+		return err
+	}
+
+	return f(ctx, c)
+}
+
+// machineSpec returns the config spec for VMs created by the Model:
+// a SCSI controller with a single disk and an ethernet card on the given network.
+func (m *Model) machineSpec(name string, datastore string, network string) types.VirtualMachineConfigSpec {
+	return types.VirtualMachineConfigSpec{
+		Name:    name,
+		GuestId: string(types.VirtualMachineGuestOsIdentifierOtherGuest),
+		Files: &types.VirtualMachineFileInfo{
+			VmPathName: fmt.Sprintf("[%s]", datastore),
+		},
+		DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device: &types.VirtualLsiLogicController{
+					VirtualSCSIController: types.VirtualSCSIController{
+						VirtualController: types.VirtualController{
+							VirtualDevice: types.VirtualDevice{Key: -1},
+						},
+						SharedBus: types.VirtualSCSISharingNoSharing,
+					},
+				},
+			},
+			&types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+				FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+				Device: &types.VirtualDisk{
+					VirtualDevice: types.VirtualDevice{
+						Key:           -2,
+						ControllerKey: -1,
+						Backing: &types.VirtualDiskFlatVer2BackingInfo{
+							VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+								FileName: fmt.Sprintf("[%s]", datastore),
+							},
+							DiskMode:        string(types.VirtualDiskModePersistent),
+							ThinProvisioned: types.NewBool(true),
+						},
+					},
+					CapacityInKB: 1024 * 1024,
+				},
+			},
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device: &types.VirtualE1000{
+					VirtualEthernetCard: types.VirtualEthernetCard{
+						VirtualDevice: types.VirtualDevice{
+							Key: -3,
+							Backing: &types.VirtualEthernetCardNetworkBackingInfo{
+								VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+									DeviceName: network,
+								},
+							},
+							Connectable: &types.VirtualDeviceConnectInfo{
+								StartConnected:    true,
+								AllowGuestControl: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import "testing"
+
+func TestModel(t *testing.T) {
+	tests := []struct {
+		model  *Model
+		counts map[string]int
+	}{
+		{
+			ESX(),
+			map[string]int{
+				"Datacenter":             1,
+				"ComputeResource":        1,
+				"ClusterComputeResource": 0,
+				"HostSystem":             1,
+				"ResourcePool":           1,
+				"Datastore":              1,
+				"Network":                1,
+				"VirtualMachine":         2,
+			},
+		},
+		{
+			VPX(),
+			map[string]int{
+				"Datacenter":             1,
+				"ComputeResource":        1,
+				"ClusterComputeResource": 1,
+				"HostSystem":             4,
+				"ResourcePool":           3,
+				"Datastore":              1,
+				"Network":                1,
+				"VirtualMachine":         4,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.model.Create()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := test.model.Service.Map
+
+		for kind, count := range test.counts {
+			n := len(r.All(kind))
+			if n != count {
+				t.Errorf("%s %s: %d != %d", test.model.About.ApiType, kind, n, count)
+			}
+		}
+	}
+}
+
+func TestModelESX(t *testing.T) {
+	m := ESX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := []string{"ha-folder-root", "ha-datacenter", "ha-folder-vm", "ha-compute-res", "ha-host", "ha-root-pool"}
+
+	for _, ref := range refs {
+		found := false
+
+		for obj := range m.Service.Map.objects {
+			if obj.Value == ref {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("%s not found", ref)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Network simulates the vim.Network managed object
+type Network struct {
+	mo.Network
+}
+
+// createNetwork creates a Network in the network Folder of the given Datacenter
+func createNetwork(r *Registry, dc *Datacenter, name string) *Network {
+	n := &Network{}
+
+	// mo.Network shadows the ManagedEntity Name field
+	n.Name = name
+	n.ManagedEntity.Name = name
+	n.OverallStatus = types.ManagedEntityStatusGreen
+
+	dc.folder(r, dc.NetworkFolder).putChild(r, n)
+
+	n.Summary = &types.NetworkSummary{
+		Network:    &n.Self,
+		Name:       name,
+		Accessible: true,
+	}
+
+	dc.Network = append(dc.Network, n.Self)
+
+	return n
+}
+
+// attach makes the Network available to the given host and its ComputeResource
+func (n *Network) attach(r *Registry, host *HostSystem) {
+	n.Host = AddReference(host.Self, n.Host)
+
+	host.Network = AddReference(n.Self, host.Network)
+
+	if cr := hostComputeResource(r, host); cr != nil {
+		cr.Network = AddReference(n.Self, cr.Network)
+	}
+}
+
+func (n *Network) rename(name string) {
+	n.Name = name
+	n.Summary.GetNetworkSummary().Name = name
+}
@@ -0,0 +1,884 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// waitInterval is how often WaitForUpdatesEx polls for changes while blocked
+var waitInterval = 50 * time.Millisecond
+
+// PropertyCollector simulates the vmodl.query.PropertyCollector managed object
+type PropertyCollector struct {
+	mo.PropertyCollector
+
+	version int
+	counter int
+	pending map[string]*pendingResult
+	cancel  bool
+}
+
+// pendingResult holds the objects remaining to be returned by ContinueRetrievePropertiesEx
+type pendingResult struct {
+	objects []types.ObjectContent
+	max     int
+}
+
+// NewPropertyCollector returns a PropertyCollector with the given reference
+func NewPropertyCollector(ref types.ManagedObjectReference) mo.Reference {
+	s := &PropertyCollector{
+		pending: make(map[string]*pendingResult),
+	}
+
+	s.Self = ref
+
+	return s
+}
+
+var errInvalidField = errors.New("invalid field")
+
+// refresher is implemented by objects whose property values are derived when they are collected,
+// for example the SessionManager.currentSession property or the ContainerView.view property.
+type refresher interface {
+	refresh(*Context)
+}
+
+// getObject returns the object for the given reference, refreshing its derived properties if needed.
+func getObject(ctx *Context, ref types.ManagedObjectReference) mo.Reference {
+	obj := ctx.Map.Get(ref)
+
+	if o, ok := obj.(refresher); ok {
+		o.refresh(ctx)
+	}
+
+	return obj
+}
+
+// fieldName returns the vmodl property name of the given struct field
+func fieldName(f reflect.StructField) string {
+	if tag := f.Tag.Get("mo"); tag != "" {
+		return tag
+	}
+
+	if tag := f.Tag.Get("xml"); tag != "" {
+		return strings.Split(tag, ",")[0]
+	}
+
+	return ""
+}
+
+// lookupField returns the field of struct v with the given property name,
+// searching the fields of v before those of its embedded structs.
+func lookupField(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+
+	var embedded []int
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			embedded = append(embedded, i)
+			continue
+		}
+
+		if fieldName(f) == name {
+			return v.Field(i)
+		}
+	}
+
+	for _, i := range embedded {
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() != reflect.Struct {
+			continue
+		}
+
+		if x := lookupField(fv, name); x.IsValid() {
+			return x
+		}
+	}
+
+	return reflect.Value{}
+}
+
+// indirect dereferences pointer and interface values, returning an invalid Value if nil.
+func indirect(rval reflect.Value) reflect.Value {
+	for rval.Kind() == reflect.Ptr || rval.Kind() == reflect.Interface {
+		if rval.IsNil() {
+			return reflect.Value{}
+		}
+		rval = rval.Elem()
+	}
+
+	return rval
+}
+
+// fieldValue returns the value of the property at path p. An invalid Value is
+// returned if the property is unset, errInvalidField if the property does not exist.
+func fieldValue(rval reflect.Value, p string) (reflect.Value, error) {
+	for _, name := range strings.Split(p, ".") {
+		rval = indirect(rval)
+		if !rval.IsValid() {
+			return rval, nil
+		}
+
+		if rval.Kind() != reflect.Struct {
+			return reflect.Value{}, errInvalidField
+		}
+
+		rval = lookupField(rval, name)
+		if !rval.IsValid() {
+			return rval, errInvalidField
+		}
+	}
+
+	rval = indirect(rval)
+
+	if !rval.IsValid() || isUnset(rval) {
+		return reflect.Value{}, nil
+	}
+
+	return rval, nil
+}
+
+var managedObjectRefType = reflect.TypeOf((*types.ManagedObjectReference)(nil)).Elem()
+
+// isUnset returns true for property values that are not reported by the collector
+func isUnset(rval reflect.Value) bool {
+	switch rval.Kind() {
+	case reflect.Slice:
+		return rval.IsNil()
+	case reflect.Struct:
+		if rval.Type() == managedObjectRefType {
+			return rval.Interface().(types.ManagedObjectReference).Value == ""
+		}
+	}
+
+	return false
+}
+
+// fieldRefs returns the references found in the given property value
+func fieldRefs(rval reflect.Value) []types.ManagedObjectReference {
+	if !rval.IsValid() {
+		return nil
+	}
+
+	switch v := rval.Interface().(type) {
+	case types.ManagedObjectReference:
+		return []types.ManagedObjectReference{v}
+	case []types.ManagedObjectReference:
+		return v
+	}
+
+	return nil
+}
+
+// deepCopy returns a copy of the given value that shares no memory with it,
+// such that the copy can be encoded while the original is modified.
+func deepCopy(src reflect.Value) reflect.Value {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.New(src.Type().Elem())
+		dst.Elem().Set(deepCopy(src.Elem()))
+		return dst
+	case reflect.Interface:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(deepCopy(src.Elem()))
+		return dst
+	case reflect.Struct:
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(src) // unexported fields, such as those of time.Time, are copied as-is
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(deepCopy(src.Field(i)))
+			}
+		}
+		return dst
+	case reflect.Slice:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(deepCopy(src.Index(i)))
+		}
+		return dst
+	case reflect.Map:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.MakeMap(src.Type())
+		for _, key := range src.MapKeys() {
+			dst.SetMapIndex(key, deepCopy(src.MapIndex(key)))
+		}
+		return dst
+	}
+
+	return src
+}
+
+// wrapValue converts slices to their types.ArrayOf* equivalent, as required for encoding property values.
+func wrapValue(rval reflect.Value) interface{} {
+	pval := rval.Interface()
+
+	if rval.Kind() != reflect.Slice {
+		return pval
+	}
+
+	switch v := pval.(type) {
+	case []string:
+		return types.ArrayOfString{String: v}
+	case []uint8:
+		return types.ArrayOfByte{Byte: v}
+	case []int16:
+		return types.ArrayOfShort{Short: v}
+	case []int32:
+		return types.ArrayOfInt{Int: v}
+	case []int64:
+		return types.ArrayOfLong{Long: v}
+	case []bool:
+		return types.ArrayOfBoolean{Boolean: v}
+	}
+
+	kind := rval.Type().Elem().Name()
+	// Remove govmomi interface prefix name
+	kind = strings.TrimPrefix(kind, "Base")
+
+	if akind, ok := types.TypeFunc()("ArrayOf" + kind); ok {
+		a := reflect.New(akind).Elem()
+		f := a.FieldByName(kind)
+		if f.IsValid() && rval.Type().AssignableTo(f.Type()) {
+			f.Set(rval)
+			return a.Interface()
+		}
+	}
+
+	if rval.Type().Elem().Kind() == reflect.String {
+		// For example, an array of enum values
+		s := make([]string, rval.Len())
+		for i := range s {
+			s[i] = rval.Index(i).String()
+		}
+		return types.ArrayOfString{String: s}
+	}
+
+	return pval
+}
+
+var moPkgPath = reflect.TypeOf((*mo.Folder)(nil)).Elem().PkgPath()
+
+// isKind returns true if the given object is of type kind, or extends type kind.
+func isKind(obj mo.Reference, kind string) bool {
+	if obj.Reference().Type == kind {
+		return true
+	}
+
+	return embedsType(reflect.TypeOf(obj).Elem(), kind)
+}
+
+func embedsType(t reflect.Type, kind string) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if ft.PkgPath() == moPkgPath && ft.Name() == kind {
+			return true
+		}
+
+		if embedsType(ft, kind) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type visitKey struct {
+	ref  types.ManagedObjectReference
+	spec *types.TraversalSpec
+}
+
+// retrieveResult collects the objects and properties for a single PropertyFilterSpec
+type retrieveResult struct {
+	ctx       *Context
+	spec      *types.PropertyFilterSpec
+	objects   []types.ObjectContent
+	specs     map[string]*types.TraversalSpec
+	collected map[types.ManagedObjectReference]bool
+	visited   map[visitKey]bool
+
+	// ignoreMissing skips ObjectSpec objects that no longer exist rather than failing,
+	// as needed by PropertyFilter updates
+	ignoreMissing bool
+}
+
+func newRetrieveResult(ctx *Context, spec *types.PropertyFilterSpec) *retrieveResult {
+	rr := &retrieveResult{
+		ctx:       ctx,
+		spec:      spec,
+		specs:     make(map[string]*types.TraversalSpec),
+		collected: make(map[types.ManagedObjectReference]bool),
+		visited:   make(map[visitKey]bool),
+	}
+
+	for _, o := range spec.ObjectSet {
+		rr.registerSpecs(o.SelectSet)
+	}
+
+	return rr
+}
+
+// registerSpecs indexes the named TraversalSpecs, so they can be referenced by SelectionSpec name
+func (rr *retrieveResult) registerSpecs(set []types.BaseSelectionSpec) {
+	for _, ss := range set {
+		ts, ok := ss.(*types.TraversalSpec)
+		if !ok {
+			continue
+		}
+
+		if ts.Name != "" {
+			if _, ok := rr.specs[ts.Name]; ok {
+				continue
+			}
+			rr.specs[ts.Name] = ts
+		}
+
+		rr.registerSpecs(ts.SelectSet)
+	}
+}
+
+func (rr *retrieveResult) run() types.BaseMethodFault {
+	for _, o := range rr.spec.ObjectSet {
+		if getObject(rr.ctx, o.Obj) == nil {
+			if rr.ignoreMissing {
+				continue
+			}
+			return &types.ManagedObjectNotFound{Obj: o.Obj}
+		}
+
+		if o.Skip == nil || !*o.Skip {
+			if fault := rr.collect(o.Obj); fault != nil {
+				return fault
+			}
+		}
+
+		if fault := rr.selectSet(o.Obj, o.SelectSet); fault != nil {
+			return fault
+		}
+	}
+
+	return nil
+}
+
+func (rr *retrieveResult) selectSet(ref types.ManagedObjectReference, set []types.BaseSelectionSpec) types.BaseMethodFault {
+	for _, ss := range set {
+		ts, ok := ss.(*types.TraversalSpec)
+		if !ok {
+			name := ss.GetSelectionSpec().Name
+			if ts, ok = rr.specs[name]; !ok {
+				return &types.InvalidArgument{InvalidProperty: name}
+			}
+		}
+
+		key := visitKey{ref, ts}
+		if rr.visited[key] {
+			continue
+		}
+		rr.visited[key] = true
+
+		obj := getObject(rr.ctx, ref)
+		if obj == nil || !isKind(obj, ts.Type) {
+			continue
+		}
+
+		rval, err := fieldValue(reflect.ValueOf(obj), ts.Path)
+		if err != nil {
+			return &types.InvalidProperty{Name: ts.Path}
+		}
+
+		for _, child := range fieldRefs(rval) {
+			if rr.ctx.Map.Get(child) == nil {
+				continue
+			}
+
+			if ts.Skip == nil || !*ts.Skip {
+				if fault := rr.collect(child); fault != nil {
+					return fault
+				}
+			}
+
+			if fault := rr.selectSet(child, ts.SelectSet); fault != nil {
+				return fault
+			}
+		}
+	}
+
+	return nil
+}
+
+// collect adds the properties of the given object to the result,
+// if its type matches any of the PropertySpecs.
+func (rr *retrieveResult) collect(ref types.ManagedObjectReference) types.BaseMethodFault {
+	if rr.collected[ref] {
+		return nil
+	}
+
+	obj := getObject(rr.ctx, ref)
+	if obj == nil {
+		return nil
+	}
+
+	matched := false
+	all := false
+	var paths []string
+
+	for _, spec := range rr.spec.PropSet {
+		if !isKind(obj, spec.Type) {
+			continue
+		}
+
+		matched = true
+
+		if spec.All != nil && *spec.All {
+			all = true
+		}
+
+		paths = append(paths, spec.PathSet...)
+	}
+
+	if !matched {
+		return nil
+	}
+
+	rr.collected[ref] = true
+
+	content := types.ObjectContent{
+		Obj: ref,
+	}
+
+	rval := reflect.ValueOf(obj).Elem()
+
+	if all {
+		collectAll(rval, map[string]bool{}, &content)
+	} else {
+		seen := make(map[string]bool)
+
+		for _, p := range paths {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+
+			val, err := fieldValue(rval, p)
+			if err != nil {
+				return &types.InvalidProperty{Name: p}
+			}
+
+			if !val.IsValid() {
+				continue
+			}
+
+			content.PropSet = append(content.PropSet, types.DynamicProperty{
+				Name: p,
+				Val:  wrapValue(deepCopy(val)),
+			})
+		}
+	}
+
+	rr.objects = append(rr.objects, content)
+
+	return nil
+}
+
+// collectAll adds all properties of the given object, where properties of embedded
+// types are shadowed by those of the embedding type (for example, Network.name).
+func collectAll(rval reflect.Value, seen map[string]bool, content *types.ObjectContent) {
+	t := rval.Type()
+
+	var embedded []int
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			embedded = append(embedded, i)
+			continue
+		}
+
+		name := f.Tag.Get("mo")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		val := indirect(rval.Field(i))
+		if !val.IsValid() || isUnset(val) {
+			continue
+		}
+
+		content.PropSet = append(content.PropSet, types.DynamicProperty{
+			Name: name,
+			Val:  wrapValue(deepCopy(val)),
+		})
+	}
+
+	for _, i := range embedded {
+		fv := indirect(rval.Field(i))
+		if fv.IsValid() && fv.Kind() == reflect.Struct {
+			collectAll(fv, seen, content)
+		}
+	}
+}
+
+// collect returns the ObjectContent for each of the given specs
+func (pc *PropertyCollector) collect(ctx *Context, specs []types.PropertyFilterSpec) ([]types.ObjectContent, types.BaseMethodFault) {
+	var objects []types.ObjectContent
+
+	for i := range specs {
+		rr := newRetrieveResult(ctx, &specs[i])
+
+		if fault := rr.run(); fault != nil {
+			return nil, fault
+		}
+
+		objects = append(objects, rr.objects...)
+	}
+
+	return objects, nil
+}
+
+func (pc *PropertyCollector) RetrieveProperties(ctx *Context, r *types.RetrieveProperties) soap.HasFault {
+	body := &methods.RetrievePropertiesBody{}
+
+	objects, fault := pc.collect(ctx, r.SpecSet)
+	if fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	body.Res = &types.RetrievePropertiesResponse{
+		Returnval: objects,
+	}
+
+	return body
+}
+
+// page returns the first max objects of the given set as a RetrieveResult, with a token
+// to retrieve the remaining objects if needed.
+func (pc *PropertyCollector) page(objects []types.ObjectContent, max int) *types.RetrieveResult {
+	if len(objects) == 0 {
+		return nil
+	}
+
+	res := &types.RetrieveResult{
+		Objects: objects,
+	}
+
+	if max > 0 && len(objects) > max {
+		pc.counter++
+		res.Token = strconv.Itoa(pc.counter)
+		res.Objects = objects[:max]
+		pc.pending[res.Token] = &pendingResult{objects[max:], max}
+	}
+
+	return res
+}
+
+func (pc *PropertyCollector) RetrievePropertiesEx(ctx *Context, r *types.RetrievePropertiesEx) soap.HasFault {
+	body := &methods.RetrievePropertiesExBody{}
+
+	objects, fault := pc.collect(ctx, r.SpecSet)
+	if fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	body.Res = &types.RetrievePropertiesExResponse{
+		Returnval: pc.page(objects, int(r.Options.MaxObjects)),
+	}
+
+	return body
+}
+
+// ContinueRetrievePropertiesEx returns the next page of results, using the page size of the initial request.
+func (pc *PropertyCollector) ContinueRetrievePropertiesEx(r *types.ContinueRetrievePropertiesEx) soap.HasFault {
+	body := &methods.ContinueRetrievePropertiesExBody{}
+
+	pending, ok := pc.pending[r.Token]
+	if !ok {
+		body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "token"})
+		return body
+	}
+
+	delete(pc.pending, r.Token)
+
+	res := pc.page(pending.objects, pending.max)
+	body.Res = &types.ContinueRetrievePropertiesExResponse{
+		Returnval: *res,
+	}
+
+	return body
+}
+
+func (pc *PropertyCollector) CancelRetrievePropertiesEx(r *types.CancelRetrievePropertiesEx) soap.HasFault {
+	delete(pc.pending, r.Token)
+
+	return &methods.CancelRetrievePropertiesExBody{
+		Res: &types.CancelRetrievePropertiesExResponse{},
+	}
+}
+
+func (pc *PropertyCollector) CreateFilter(ctx *Context, c *types.CreateFilter) soap.HasFault {
+	body := &methods.CreateFilterBody{}
+
+	filter := &PropertyFilter{pc: pc}
+	filter.PartialUpdates = c.PartialUpdates
+	filter.Spec = c.Spec
+
+	// Validate the spec, as the real PropertyCollector does when a filter is created
+	rr := newRetrieveResult(ctx, &filter.Spec)
+	rr.ignoreMissing = true
+	if fault := rr.run(); fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	ctx.Map.Put(filter)
+
+	pc.Filter = append(pc.Filter, filter.Self)
+
+	body.Res = &types.CreateFilterResponse{
+		Returnval: filter.Self,
+	}
+
+	return body
+}
+
+func (pc *PropertyCollector) CreatePropertyCollector(ctx *Context, c *types.CreatePropertyCollector) soap.HasFault {
+	cpc := NewPropertyCollector(types.ManagedObjectReference{})
+
+	ctx.Map.Put(cpc)
+
+	return &methods.CreatePropertyCollectorBody{
+		Res: &types.CreatePropertyCollectorResponse{
+			Returnval: cpc.Reference(),
+		},
+	}
+}
+
+func (pc *PropertyCollector) DestroyPropertyCollector(ctx *Context, c *types.DestroyPropertyCollector) soap.HasFault {
+	for _, ref := range pc.Filter {
+		ctx.Map.Remove(ref)
+	}
+
+	pc.Filter = nil
+	pc.cancel = true
+
+	ctx.Map.Remove(c.This)
+
+	return &methods.DestroyPropertyCollectorBody{
+		Res: &types.DestroyPropertyCollectorResponse{},
+	}
+}
+
+// updates returns the changes of all filters since they were last reported,
+// or nil if there are no changes. If max is greater than zero, no more than max
+// object updates are returned and the UpdateSet is marked as truncated.
+func (pc *PropertyCollector) updates(ctx *Context, max int) (*types.UpdateSet, types.BaseMethodFault) {
+	var set types.UpdateSet
+	count := 0
+	truncated := false
+
+	for _, ref := range pc.Filter {
+		filter, ok := ctx.Map.Get(ref).(*PropertyFilter)
+		if !ok {
+			continue
+		}
+
+		limit := 0
+		if max > 0 {
+			limit = max - count
+			if limit == 0 {
+				limit = -1 // check for pending changes only
+			}
+		}
+
+		updates, trunc, fault := filter.update(ctx, limit)
+		if fault != nil {
+			return nil, fault
+		}
+
+		truncated = truncated || trunc
+
+		if len(updates) == 0 {
+			continue
+		}
+
+		count += len(updates)
+
+		set.FilterSet = append(set.FilterSet, types.PropertyFilterUpdate{
+			Filter:    ref,
+			ObjectSet: updates,
+		})
+	}
+
+	if len(set.FilterSet) == 0 {
+		return nil, nil
+	}
+
+	pc.version++
+	set.Version = strconv.Itoa(pc.version)
+
+	if truncated {
+		set.Truncated = types.NewBool(true)
+	}
+
+	return &set, nil
+}
+
+// waitForUpdates blocks until there are changes to report, until maxWait has elapsed if maxWait is greater than zero,
+// or returns immediately if maxWait is zero.
+func (pc *PropertyCollector) waitForUpdates(ctx *Context, version string, maxWait time.Duration, max int) (*types.UpdateSet, types.BaseMethodFault) {
+	if version == "" {
+		// Start over, reporting the current state of all objects
+		for _, ref := range pc.Filter {
+			if filter, ok := ctx.Map.Get(ref).(*PropertyFilter); ok {
+				filter.reset()
+			}
+		}
+	}
+
+	pc.cancel = false
+	start := time.Now()
+
+	for {
+		set, fault := pc.updates(ctx, max)
+		if fault != nil || set != nil {
+			return set, fault
+		}
+
+		if maxWait == 0 || (maxWait > 0 && time.Since(start) >= maxWait) {
+			return nil, nil
+		}
+
+		if !ctx.sleep(waitInterval) || pc.cancel {
+			pc.cancel = false
+			return nil, &types.RequestCanceled{}
+		}
+	}
+}
+
+func (pc *PropertyCollector) WaitForUpdatesEx(ctx *Context, r *types.WaitForUpdatesEx) soap.HasFault {
+	body := &methods.WaitForUpdatesExBody{}
+
+	maxWait := time.Duration(-1)
+	max := 0
+
+	if r.Options != nil {
+		if r.Options.MaxWaitSeconds > 0 {
+			maxWait = time.Duration(r.Options.MaxWaitSeconds) * time.Second
+		}
+		max = int(r.Options.MaxObjectUpdates)
+	}
+
+	set, fault := pc.waitForUpdates(ctx, r.Version, maxWait, max)
+	if fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	body.Res = &types.WaitForUpdatesExResponse{
+		Returnval: set,
+	}
+
+	return body
+}
+
+func (pc *PropertyCollector) WaitForUpdates(ctx *Context, r *types.WaitForUpdates) soap.HasFault {
+	body := &methods.WaitForUpdatesBody{}
+
+	set, fault := pc.waitForUpdates(ctx, r.Version, -1, 0)
+	if fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	body.Res = &types.WaitForUpdatesResponse{
+		Returnval: *set,
+	}
+
+	return body
+}
+
+func (pc *PropertyCollector) CheckForUpdates(ctx *Context, r *types.CheckForUpdates) soap.HasFault {
+	body := &methods.CheckForUpdatesBody{}
+
+	set, fault := pc.waitForUpdates(ctx, r.Version, 0, 0)
+	if fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	body.Res = &types.CheckForUpdatesResponse{
+		Returnval: set,
+	}
+
+	return body
+}
+
+func (pc *PropertyCollector) CancelWaitForUpdates(r *types.CancelWaitForUpdates) soap.HasFault {
+	pc.cancel = true
+
+	return &methods.CancelWaitForUpdatesBody{
+		Res: &types.CancelWaitForUpdatesResponse{},
+	}
+}
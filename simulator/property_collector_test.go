@@ -0,0 +1,266 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func newTestClient(ctx context.Context, t *testing.T, m *Model) (*govmomi.Client, *Server) {
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+
+	c, err := govmomi.NewClient(ctx, s.URL, true)
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+
+	return c, s
+}
+
+func TestRetrieveProperties(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	finder := find.NewFinder(c.Client, false)
+
+	vm, err := finder.VirtualMachine(ctx, "/DC0/vm/DC0_C0_VM0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var props mo.VirtualMachine
+	err = vm.Properties(ctx, vm.Reference(), []string{"name", "config.hardware", "runtime.host"}, &props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if props.Name != "DC0_C0_VM0" {
+		t.Errorf("name=%s", props.Name)
+	}
+
+	if props.Config == nil || props.Config.Hardware.NumCPU != 1 {
+		t.Errorf("config=%#v", props.Config)
+	}
+
+	if props.Runtime.Host == nil {
+		t.Error("runtime.host not set")
+	}
+
+	// Invalid property
+	err = vm.Properties(ctx, vm.Reference(), []string{"enoent"}, &props)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if _, ok := soap.ToSoapFault(err).VimFault().(types.InvalidProperty); !ok {
+		t.Errorf("unexpected fault: %#v", soap.ToSoapFault(err).VimFault())
+	}
+}
+
+func TestRetrievePropertiesEx(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	// Traverse from the root folder to all VMs
+	req := types.RetrievePropertiesEx{
+		This: c.ServiceContent.PropertyCollector,
+		SpecSet: []types.PropertyFilterSpec{
+			{
+				ObjectSet: []types.ObjectSpec{
+					{
+						Obj:  c.ServiceContent.RootFolder,
+						Skip: types.NewBool(true),
+						SelectSet: []types.BaseSelectionSpec{
+							&types.TraversalSpec{
+								SelectionSpec: types.SelectionSpec{Name: "folderTraversal"},
+								Type:          "Folder",
+								Path:          "childEntity",
+								SelectSet: []types.BaseSelectionSpec{
+									&types.SelectionSpec{Name: "folderTraversal"},
+									&types.SelectionSpec{Name: "datacenterTraversal"},
+								},
+							},
+							&types.TraversalSpec{
+								SelectionSpec: types.SelectionSpec{Name: "datacenterTraversal"},
+								Type:          "Datacenter",
+								Path:          "vmFolder",
+								SelectSet: []types.BaseSelectionSpec{
+									&types.SelectionSpec{Name: "folderTraversal"},
+								},
+							},
+						},
+					},
+				},
+				PropSet: []types.PropertySpec{
+					{Type: "VirtualMachine", PathSet: []string{"name"}},
+				},
+			},
+		},
+		Options: types.RetrieveOptions{MaxObjects: 1},
+	}
+
+	res, err := methods.RetrievePropertiesEx(ctx, c, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	result := res.Returnval
+
+	for result != nil {
+		for _, o := range result.Objects {
+			if o.Obj.Type != "VirtualMachine" {
+				t.Errorf("unexpected object: %s", o.Obj)
+			}
+			names[o.PropSet[0].Val.(string)] = true
+		}
+
+		if result.Token == "" {
+			break
+		}
+
+		cres, err := methods.ContinueRetrievePropertiesEx(ctx, c, &types.ContinueRetrievePropertiesEx{
+			This:  req.This,
+			Token: result.Token,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result = &cres.Returnval
+	}
+
+	if len(names) != 4 {
+		t.Errorf("names=%v", names)
+	}
+}
+
+func TestWaitForUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	finder := find.NewFinder(c.Client, false)
+
+	vm, err := finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pc, err := property.DefaultCollector(c.Client).Create(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(2 * waitInterval)
+
+		task, err := vm.PowerOn(ctx)
+		if err == nil {
+			_ = task.Wait(ctx)
+		}
+	}()
+
+	wctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var states []types.VirtualMachinePowerState
+
+	err = property.Wait(wctx, pc, vm.Reference(), []string{"runtime.powerState"}, func(changes []types.PropertyChange) bool {
+		for _, change := range changes {
+			state := change.Val.(types.VirtualMachinePowerState)
+			states = append(states, state)
+			if state == types.VirtualMachinePowerStatePoweredOn {
+				return true
+			}
+		}
+
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(states) != 2 || states[0] != types.VirtualMachinePowerStatePoweredOff {
+		t.Errorf("states=%v", states)
+	}
+}
+
+func TestCheckForUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	pc, err := property.DefaultCollector(c.Client).Create(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = pc.CreateFilter(ctx, types.CreateFilter{
+		Spec: types.PropertyFilterSpec{
+			ObjectSet: []types.ObjectSpec{{Obj: c.ServiceContent.RootFolder}},
+			PropSet:   []types.PropertySpec{{Type: "Folder", PathSet: []string{"name"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := types.CheckForUpdates{This: pc.Reference()}
+
+	res, err := methods.CheckForUpdates(ctx, c, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Returnval == nil || len(res.Returnval.FilterSet) != 1 {
+		t.Fatalf("unexpected result: %#v", res.Returnval)
+	}
+
+	req.Version = res.Returnval.Version
+
+	res, err = methods.CheckForUpdates(ctx, c, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Returnval != nil {
+		t.Errorf("unexpected update: %#v", res.Returnval)
+	}
+}
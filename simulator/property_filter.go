@@ -0,0 +1,159 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// PropertyFilter simulates the vmodl.query.PropertyCollector.Filter managed object
+type PropertyFilter struct {
+	mo.PropertyFilter
+
+	pc *PropertyCollector
+
+	// reported holds the property values last reported for each object, by property name
+	reported map[types.ManagedObjectReference]map[string]types.AnyType
+}
+
+// reset discards the state last reported, such that the next update reports all objects
+func (f *PropertyFilter) reset() {
+	f.reported = nil
+}
+
+// update returns the changes since the last update. If limit is greater than zero, no more than
+// limit updates are returned and truncated is true if more were pending. If limit is less than zero,
+// no updates are returned and truncated is true if any were pending.
+func (f *PropertyFilter) update(ctx *Context, limit int) ([]types.ObjectUpdate, bool, types.BaseMethodFault) {
+	rr := newRetrieveResult(ctx, &f.Spec)
+	rr.ignoreMissing = true
+
+	if fault := rr.run(); fault != nil {
+		return nil, false, fault
+	}
+
+	if f.reported == nil {
+		f.reported = make(map[types.ManagedObjectReference]map[string]types.AnyType)
+	}
+
+	var updates []types.ObjectUpdate
+	truncated := false
+
+	add := func(u types.ObjectUpdate) bool {
+		if limit < 0 || (limit > 0 && len(updates) == limit) {
+			truncated = true
+			return false
+		}
+		updates = append(updates, u)
+		return true
+	}
+
+	current := make(map[types.ManagedObjectReference]bool)
+
+	for _, o := range rr.objects {
+		current[o.Obj] = true
+
+		props := make(map[string]types.AnyType)
+		for _, p := range o.PropSet {
+			props[p.Name] = p.Val
+		}
+
+		u := types.ObjectUpdate{
+			Kind: types.ObjectUpdateKindModify,
+			Obj:  o.Obj,
+		}
+
+		prev, ok := f.reported[o.Obj]
+		if !ok {
+			u.Kind = types.ObjectUpdateKindEnter
+		}
+
+		for _, p := range o.PropSet {
+			if ok {
+				if val, exists := prev[p.Name]; exists && reflect.DeepEqual(val, p.Val) {
+					continue
+				}
+			}
+
+			u.ChangeSet = append(u.ChangeSet, types.PropertyChange{
+				Name: p.Name,
+				Op:   types.PropertyChangeOpAssign,
+				Val:  p.Val,
+			})
+		}
+
+		var unset []string
+		for name := range prev {
+			if _, exists := props[name]; !exists {
+				unset = append(unset, name)
+			}
+		}
+		sort.Strings(unset)
+
+		for _, name := range unset {
+			u.ChangeSet = append(u.ChangeSet, types.PropertyChange{
+				Name: name,
+				Op:   types.PropertyChangeOpAssign,
+			})
+		}
+
+		if ok && len(u.ChangeSet) == 0 {
+			continue
+		}
+
+		if add(u) {
+			f.reported[o.Obj] = props
+		}
+	}
+
+	var gone []types.ManagedObjectReference
+	for ref := range f.reported {
+		if !current[ref] {
+			gone = append(gone, ref)
+		}
+	}
+	sort.Sort(byReference(gone))
+
+	for _, ref := range gone {
+		u := types.ObjectUpdate{
+			Kind: types.ObjectUpdateKindLeave,
+			Obj:  ref,
+		}
+
+		if add(u) {
+			delete(f.reported, ref)
+		}
+	}
+
+	return updates, truncated, nil
+}
+
+func (f *PropertyFilter) DestroyPropertyFilter(ctx *Context, c *types.DestroyPropertyFilter) soap.HasFault {
+	f.pc.Filter = RemoveReference(c.This, f.pc.Filter)
+
+	ctx.Map.Remove(c.This)
+
+	return &methods.DestroyPropertyFilterBody{
+		Res: &types.DestroyPropertyFilterResponse{},
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// This is a map from a reference type name to a reference value name prefix.
+// It's a convention that VirtualCenter follows. The map is not complete, but
+// it should cover the most popular objects.
+var refValueMap = map[string]string{
+	"DistributedVirtualPortgroup":    "dvportgroup",
+	"EnvironmentBrowser":             "envbrowser",
+	"HostSystem":                     "host",
+	"ResourcePool":                   "resgroup",
+	"VirtualMachine":                 "vm",
+	"VirtualMachineSnapshot":         "snapshot",
+	"VmwareDistributedVirtualSwitch": "dvs",
+	"ClusterComputeResource":         "domain-c",
+	"ComputeResource":                "domain-s",
+	"Folder":                         "group",
+}
+
+// Registry manages a map of mo.Reference objects
+type Registry struct {
+	m       sync.Mutex
+	objects map[types.ManagedObjectReference]mo.Reference
+	counter int
+}
+
+// NewRegistry creates a new instances of Registry
+func NewRegistry() *Registry {
+	r := &Registry{
+		objects: make(map[types.ManagedObjectReference]mo.Reference),
+	}
+
+	return r
+}
+
+// typeName returns the type of the given object.
+func typeName(item mo.Reference) string {
+	return reflect.TypeOf(item).Elem().Name()
+}
+
+// valuePrefix returns the value name prefix of a given object
+func valuePrefix(typeName string) string {
+	if v, ok := refValueMap[typeName]; ok {
+		if strings.HasSuffix(v, "-c") || strings.HasSuffix(v, "-s") {
+			return v
+		}
+
+		return v + "-"
+	}
+
+	return strings.ToLower(typeName) + "-"
+}
+
+// newReference returns a new MOR, where Type defaults to type of the given item
+// and Value defaults to a unique id for the given type.
+func (r *Registry) newReference(item mo.Reference) types.ManagedObjectReference {
+	ref := item.Reference()
+
+	if ref.Type == "" {
+		ref.Type = typeName(item)
+	}
+
+	if ref.Value == "" {
+		r.counter++
+		ref.Value = fmt.Sprintf("%s%d", valuePrefix(ref.Type), r.counter)
+	}
+
+	return ref
+}
+
+// setReference sets the Self field of the given item
+func setReference(item mo.Reference, ref types.ManagedObjectReference) {
+	reflect.ValueOf(item).Elem().FieldByName("Self").Set(reflect.ValueOf(ref))
+}
+
+// Get returns the object for the given reference or nil if not found.
+func (r *Registry) Get(ref types.ManagedObjectReference) mo.Reference {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.objects[ref]
+}
+
+// Any returns the first instance of entity type specified by kind.
+func (r *Registry) Any(kind string) mo.Entity {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	var refs []types.ManagedObjectReference
+
+	for ref, val := range r.objects {
+		if ref.Type == kind {
+			if _, ok := val.(mo.Entity); ok {
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	// The map is unordered; return the lowest reference for a stable result.
+	min := refs[0]
+	for _, ref := range refs[1:] {
+		if refLess(ref, min) {
+			min = ref
+		}
+	}
+
+	return r.objects[min].(mo.Entity)
+}
+
+// All returns all entities of type specified by kind.
+func (r *Registry) All(kind string) []mo.Entity {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	var entities []mo.Entity
+
+	for ref, val := range r.objects {
+		if ref.Type == kind {
+			if e, ok := val.(mo.Entity); ok {
+				entities = append(entities, e)
+			}
+		}
+	}
+
+	return entities
+}
+
+// Put adds a new object to Registry, generating a ManagedObjectReference if not already set.
+func (r *Registry) Put(item mo.Reference) mo.Reference {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	ref := item.Reference()
+	if ref.Type == "" || ref.Value == "" {
+		ref = r.newReference(item)
+		setReference(item, ref)
+	}
+
+	r.objects[ref] = item
+
+	return item
+}
+
+// Remove removes an object from the Registry.
+func (r *Registry) Remove(item types.ManagedObjectReference) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	delete(r.objects, item)
+}
+
+// getEntityParent traverses up the inventory and returns the first object of type kind.
+// If no object of type kind is found, the method will panic when it reaches the
+// inventory root Folder where the Parent field is nil.
+func (r *Registry) getEntityParent(item mo.Entity, kind string) mo.Entity {
+	for {
+		parent := item.Entity().Parent
+
+		item = r.Get(*parent).(mo.Entity)
+
+		if item.Reference().Type == kind {
+			return item
+		}
+	}
+}
+
+// getEntityDatacenter returns the Datacenter containing the given item
+func (r *Registry) getEntityDatacenter(item mo.Entity) *Datacenter {
+	return r.getEntityParent(item, "Datacenter").(*Datacenter)
+}
+
+// FindByName returns the first mo.Entity of the given refs whose Name field is equal to the given name.
+// If there is no match, nil is returned.
+func (r *Registry) FindByName(name string, refs []types.ManagedObjectReference) mo.Entity {
+	for _, ref := range refs {
+		if e, ok := r.Get(ref).(mo.Entity); ok {
+			if name == entityName(e) {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
+
+// entityName returns the name of the given entity, including types such as
+// Network that shadow the ManagedEntity.Name field.
+func entityName(e mo.Entity) string {
+	if name := e.Entity().Name; name != "" {
+		return name
+	}
+
+	if f := reflect.ValueOf(e).Elem().FieldByName("Name"); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+
+	return ""
+}
+
+func refLess(a, b types.ManagedObjectReference) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+
+	if len(a.Value) != len(b.Value) {
+		return len(a.Value) < len(b.Value)
+	}
+
+	return a.Value < b.Value
+}
+
+// byReference implements sort.Interface, ordering references by type and value
+type byReference []types.ManagedObjectReference
+
+func (r byReference) Len() int           { return len(r) }
+func (r byReference) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byReference) Less(i, j int) bool { return refLess(r[i], r[j]) }
+
+// RemoveReference returns a slice with ref removed from refs
+func RemoveReference(ref types.ManagedObjectReference, refs []types.ManagedObjectReference) []types.ManagedObjectReference {
+	var result []types.ManagedObjectReference
+
+	for _, r := range refs {
+		if r != ref {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// AddReference returns a slice with ref appended if not already in refs.
+func AddReference(ref types.ManagedObjectReference, refs []types.ManagedObjectReference) []types.ManagedObjectReference {
+	for _, r := range refs {
+		if r == ref {
+			return refs
+		}
+	}
+
+	return append(refs, ref)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ResourcePool simulates the vim.ResourcePool managed object
+type ResourcePool struct {
+	mo.ResourcePool
+}
+
+// NewResourcePool returns a ResourcePool with the default (unlimited) resource configuration
+func NewResourcePool() *ResourcePool {
+	pool := &ResourcePool{}
+
+	pool.Config = newResourceConfigSpec()
+	pool.Summary = &types.ResourcePoolSummary{
+		Config: pool.Config,
+	}
+	pool.OverallStatus = types.ManagedEntityStatusGreen
+	pool.Runtime.OverallStatus = types.ManagedEntityStatusGreen
+
+	return pool
+}
+
+func newResourceAllocationInfo(shares int32) *types.ResourceAllocationInfo {
+	return &types.ResourceAllocationInfo{
+		Reservation:           0,
+		ExpandableReservation: types.NewBool(true),
+		Limit:                 -1,
+		Shares: &types.SharesInfo{
+			Level:  types.SharesLevelNormal,
+			Shares: shares,
+		},
+	}
+}
+
+func newResourceConfigSpec() types.ResourceConfigSpec {
+	return types.ResourceConfigSpec{
+		CpuAllocation:    newResourceAllocationInfo(4000),
+		MemoryAllocation: newResourceAllocationInfo(163840),
+	}
+}
+
+// createRootPool creates the root "Resources" pool of the given ComputeResource
+func createRootPool(r *Registry, cr *mo.ComputeResource) *ResourcePool {
+	pool := NewResourcePool()
+	pool.Name = "Resources"
+	pool.Owner = cr.Self
+
+	dc := r.getEntityDatacenter(cr)
+	if dc.isESX() {
+		pool.Self = types.ManagedObjectReference{Type: "ResourcePool", Value: "ha-root-pool"}
+	}
+
+	r.Put(pool)
+
+	pool.Parent = &cr.Self
+	pool.Summary.GetResourcePoolSummary().Name = pool.Name
+
+	cr.ResourcePool = &pool.Self
+
+	return pool
+}
+
+func (p *ResourcePool) CreateResourcePool(ctx *Context, c *types.CreateResourcePool) soap.HasFault {
+	body := &methods.CreateResourcePoolBody{}
+
+	if e := ctx.Map.FindByName(c.Name, p.ResourcePool.ResourcePool); e != nil {
+		body.Fault_ = Fault("", &types.DuplicateName{
+			Name:   c.Name,
+			Object: e.Reference(),
+		})
+		return body
+	}
+
+	child := NewResourcePool()
+
+	child.Name = c.Name
+	child.Owner = p.Owner
+	child.Config = c.Spec
+
+	// Fill in any allocation not given in the spec
+	defaults := newResourceConfigSpec()
+	if child.Config.CpuAllocation == nil {
+		child.Config.CpuAllocation = defaults.CpuAllocation
+	}
+	if child.Config.MemoryAllocation == nil {
+		child.Config.MemoryAllocation = defaults.MemoryAllocation
+	}
+
+	summary := child.Summary.GetResourcePoolSummary()
+	summary.Name = c.Name
+	summary.Config = child.Config
+
+	ctx.Map.Put(child)
+	child.Parent = &p.Self
+
+	p.ResourcePool.ResourcePool = append(p.ResourcePool.ResourcePool, child.Self)
+	p.ChildConfiguration = append(p.ChildConfiguration, child.Config)
+
+	body.Res = &types.CreateResourcePoolResponse{
+		Returnval: child.Self,
+	}
+
+	return body
+}
+
+// DestroyTask destroys the ResourcePool, moving its child pools and VMs to the parent pool.
+// The root pool of a ComputeResource cannot be destroyed.
+func (p *ResourcePool) DestroyTask(ctx *Context, req *types.Destroy_Task) soap.HasFault {
+	task := CreateTask(p, "destroy", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		parent, ok := ctx.Map.Get(*p.Parent).(*ResourcePool)
+		if !ok {
+			return nil, &types.InvalidArgument{InvalidProperty: "pool"}
+		}
+
+		for _, ref := range p.ResourcePool.ResourcePool {
+			child := ctx.Map.Get(ref).(*ResourcePool)
+			child.Parent = &parent.Self
+			parent.ResourcePool.ResourcePool = append(parent.ResourcePool.ResourcePool, ref)
+			parent.ChildConfiguration = append(parent.ChildConfiguration, child.Config)
+		}
+
+		for _, ref := range p.Vm {
+			vm := ctx.Map.Get(ref).(*VirtualMachine)
+			vm.ResourcePool = &parent.Self
+			parent.Vm = append(parent.Vm, ref)
+		}
+
+		var configs []types.ResourceConfigSpec
+		for i, ref := range parent.ResourcePool.ResourcePool {
+			if ref != p.Self {
+				configs = append(configs, parent.ChildConfiguration[i])
+			}
+		}
+
+		parent.ResourcePool.ResourcePool = RemoveReference(p.Self, parent.ResourcePool.ResourcePool)
+		parent.ChildConfiguration = configs
+
+		ctx.Map.Remove(p.Self)
+
+		return nil, nil
+	})
+
+	return &methods.Destroy_TaskBody{
+		Res: &types.Destroy_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
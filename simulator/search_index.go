@@ -0,0 +1,160 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// SearchIndex simulates the vim.SearchIndex managed object
+type SearchIndex struct {
+	mo.SearchIndex
+}
+
+// NewSearchIndex returns a SearchIndex with the given reference
+func NewSearchIndex(ref types.ManagedObjectReference) mo.Reference {
+	m := &SearchIndex{}
+	m.Self = ref
+	return m
+}
+
+// searchChildren returns the children of the given object, as defined by the FindChild method
+func searchChildren(obj mo.Reference) []types.ManagedObjectReference {
+	switch e := obj.(type) {
+	case *ResourcePool:
+		return e.ResourcePool.ResourcePool
+	case *HostSystem:
+		return nil
+	}
+
+	return childEntities(obj)
+}
+
+func (s *SearchIndex) FindChild(ctx *Context, req *types.FindChild) soap.HasFault {
+	body := &methods.FindChildBody{}
+
+	obj := ctx.Map.Get(req.Entity)
+	if obj == nil {
+		body.Fault_ = Fault("", &types.ManagedObjectNotFound{Obj: req.Entity})
+		return body
+	}
+
+	body.Res = &types.FindChildResponse{}
+
+	if e := ctx.Map.FindByName(req.Name, searchChildren(obj)); e != nil {
+		ref := e.Reference()
+		body.Res.Returnval = &ref
+	}
+
+	return body
+}
+
+// FindByInventoryPath resolves a path of the form "/dc/vm/folder/name", starting from the root Folder
+func (s *SearchIndex) FindByInventoryPath(ctx *Context, req *types.FindByInventoryPath) soap.HasFault {
+	body := &methods.FindByInventoryPathBody{
+		Res: &types.FindByInventoryPathResponse{},
+	}
+
+	si := ctx.Map.Get(serviceInstance).(*ServiceInstance)
+	ref := si.Content.RootFolder
+
+	for _, name := range strings.Split(strings.Trim(req.InventoryPath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+
+		e := ctx.Map.FindByName(name, searchChildren(ctx.Map.Get(ref)))
+		if e == nil {
+			return body
+		}
+
+		ref = e.Reference()
+	}
+
+	body.Res.Returnval = &ref
+
+	return body
+}
+
+// inDatacenter returns true if dc is nil or the given entity is contained by dc
+func inDatacenter(ctx *Context, e mo.Entity, dc *types.ManagedObjectReference) bool {
+	if dc == nil {
+		return true
+	}
+
+	return ctx.Map.getEntityDatacenter(e).Self == *dc
+}
+
+func (s *SearchIndex) FindByUuid(ctx *Context, req *types.FindByUuid) soap.HasFault {
+	body := &methods.FindByUuidBody{
+		Res: &types.FindByUuidResponse{},
+	}
+
+	if req.VmSearch {
+		instance := req.InstanceUuid != nil && *req.InstanceUuid
+
+		for _, e := range ctx.Map.All("VirtualMachine") {
+			vm := e.(*VirtualMachine)
+
+			id := vm.Config.Uuid
+			if instance {
+				id = vm.Config.InstanceUuid
+			}
+
+			if id == req.Uuid && inDatacenter(ctx, vm, req.Datacenter) {
+				ref := vm.Self
+				body.Res.Returnval = &ref
+				break
+			}
+		}
+	} else {
+		for _, e := range ctx.Map.All("HostSystem") {
+			host := e.(*HostSystem)
+
+			if host.Summary.Hardware.Uuid == req.Uuid && inDatacenter(ctx, host, req.Datacenter) {
+				ref := host.Self
+				body.Res.Returnval = &ref
+				break
+			}
+		}
+	}
+
+	return body
+}
+
+func (s *SearchIndex) FindByDatastorePath(ctx *Context, req *types.FindByDatastorePath) soap.HasFault {
+	body := &methods.FindByDatastorePathBody{
+		Res: &types.FindByDatastorePathResponse{},
+	}
+
+	for _, e := range ctx.Map.All("VirtualMachine") {
+		vm := e.(*VirtualMachine)
+
+		if vm.Config.Files.VmPathName == req.Path && inDatacenter(ctx, vm, &req.Datacenter) {
+			ref := vm.Self
+			body.Res.Returnval = &ref
+			break
+		}
+	}
+
+	return body
+}
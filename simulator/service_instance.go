@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var serviceInstance = types.ManagedObjectReference{
+	Type:  "ServiceInstance",
+	Value: "ServiceInstance",
+}
+
+// ServiceInstance simulates the vim.ServiceInstance managed object
+type ServiceInstance struct {
+	mo.ServiceInstance
+}
+
+// NewServiceInstance creates a ServiceInstance and the singleton managed objects
+// referenced by the given content, adding them all to the given Registry.
+func NewServiceInstance(content types.ServiceContent, r *Registry) *ServiceInstance {
+	s := &ServiceInstance{}
+
+	s.Self = serviceInstance
+	s.Content = content
+
+	r.Put(s)
+
+	objects := []mo.Reference{
+		NewSessionManager(*s.Content.SessionManager),
+		NewPropertyCollector(s.Content.PropertyCollector),
+	}
+
+	if s.Content.ViewManager != nil {
+		objects = append(objects, NewViewManager(*s.Content.ViewManager))
+	}
+
+	if s.Content.SearchIndex != nil {
+		objects = append(objects, NewSearchIndex(*s.Content.SearchIndex))
+	}
+
+	for _, o := range objects {
+		r.Put(o)
+	}
+
+	return s
+}
+
+// sessionManagerReference returns the SessionManager reference of the ServiceInstance in the given Registry
+func sessionManagerReference(r *Registry) types.ManagedObjectReference {
+	if s, ok := r.Get(serviceInstance).(*ServiceInstance); ok && s.Content.SessionManager != nil {
+		return *s.Content.SessionManager
+	}
+
+	return types.ManagedObjectReference{}
+}
+
+func (s *ServiceInstance) RetrieveServiceContent(*types.RetrieveServiceContent) soap.HasFault {
+	return &methods.RetrieveServiceContentBody{
+		Res: &types.RetrieveServiceContentResponse{
+			Returnval: s.Content,
+		},
+	}
+}
+
+func (*ServiceInstance) CurrentTime(*types.CurrentTime) soap.HasFault {
+	return &methods.CurrentTimeBody{
+		Res: &types.CurrentTimeResponse{
+			Returnval: time.Now(),
+		},
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// SessionCookie is the name of the cookie used to track the session of a client
+const SessionCookie = "vmware_soap_session"
+
+// Session is an authenticated client session
+type Session struct {
+	types.UserSession
+}
+
+// SessionManager simulates the vim.SessionManager managed object.
+// Any non-empty user name is accepted by Login, along with any password.
+type SessionManager struct {
+	mo.SessionManager
+
+	sessions map[string]*Session
+	tickets  map[string]string // clone ticket -> user name
+}
+
+// NewSessionManager returns a SessionManager with the given reference
+func NewSessionManager(ref types.ManagedObjectReference) mo.Reference {
+	s := &SessionManager{
+		sessions: make(map[string]*Session),
+		tickets:  make(map[string]string),
+	}
+
+	s.Self = ref
+	s.DefaultLocale = "en"
+	s.SupportedLocaleList = []string{"en", "de", "fr", "ja", "ko", "zh_CN", "zh_TW"}
+	s.MessageLocaleList = s.SupportedLocaleList
+
+	return s
+}
+
+// getSession returns the Session for the cookie sent with the request, if any
+func (s *SessionManager) getSession(ctx *Context) *Session {
+	cookie, err := ctx.req.Cookie(SessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	session, ok := s.sessions[cookie.Value]
+	if !ok {
+		return nil
+	}
+
+	session.LastActiveTime = time.Now()
+	session.CallCount++
+
+	return session
+}
+
+// newSession creates a new Session for the given user and sets the client's session cookie
+func (s *SessionManager) newSession(ctx *Context, user string, locale string) *Session {
+	if locale == "" {
+		locale = s.DefaultLocale
+	}
+
+	now := time.Now()
+
+	session := &Session{
+		UserSession: types.UserSession{
+			Key:            newUUID(),
+			UserName:       user,
+			FullName:       user,
+			LoginTime:      now,
+			LastActiveTime: now,
+			Locale:         locale,
+			MessageLocale:  locale,
+			UserAgent:      ctx.req.UserAgent(),
+		},
+	}
+
+	if host, _, err := net.SplitHostPort(ctx.req.RemoteAddr); err == nil {
+		session.IpAddress = host
+	}
+
+	s.sessions[session.Key] = session
+
+	http.SetCookie(ctx.res, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    session.Key,
+		Path:     "/",
+		HttpOnly: true,
+	})
+
+	ctx.Session = session
+
+	return session
+}
+
+// refresh updates the properties that depend on the session calling the PropertyCollector
+func (s *SessionManager) refresh(ctx *Context) {
+	s.SessionList = nil
+	for _, session := range s.sessions {
+		s.SessionList = append(s.SessionList, session.UserSession)
+	}
+
+	s.CurrentSession = nil
+	if ctx.Session != nil {
+		current := ctx.Session.UserSession
+		s.CurrentSession = &current
+	}
+}
+
+func (s *SessionManager) Login(ctx *Context, req *types.Login) soap.HasFault {
+	body := &methods.LoginBody{}
+
+	if req.UserName == "" {
+		body.Fault_ = Fault("Cannot complete login due to an incorrect user name or password.", &types.InvalidLogin{})
+		return body
+	}
+
+	session := s.newSession(ctx, req.UserName, req.Locale)
+
+	body.Res = &types.LoginResponse{
+		Returnval: session.UserSession,
+	}
+
+	return body
+}
+
+// LoginExtensionByCertificate accepts any extension key, the client certificate is not verified.
+func (s *SessionManager) LoginExtensionByCertificate(ctx *Context, req *types.LoginExtensionByCertificate) soap.HasFault {
+	body := &methods.LoginExtensionByCertificateBody{}
+
+	if req.ExtensionKey == "" {
+		body.Fault_ = Fault("", &types.InvalidLogin{})
+		return body
+	}
+
+	session := s.newSession(ctx, req.ExtensionKey, req.Locale)
+	session.ExtensionSession = types.NewBool(true)
+
+	body.Res = &types.LoginExtensionByCertificateResponse{
+		Returnval: session.UserSession,
+	}
+
+	return body
+}
+
+// LoginByToken accepts any SAML token issued by the STS stand-in, the token is not verified.
+// Holder-of-key tokens require the request to be signed, but the signature is not verified.
+func (s *SessionManager) LoginByToken(ctx *Context, req *types.LoginByToken) soap.HasFault {
+	body := &methods.LoginByTokenBody{}
+
+	env, err := parseSecurity(ctx.body)
+	if err != nil {
+		body.Fault_ = Fault(err.Error(), &types.InvalidLogin{})
+		return body
+	}
+
+	token := env.Header.Security.Assertion
+	if token == nil || token.Subject.NameID == "" {
+		body.Fault_ = Fault("", &types.InvalidLogin{})
+		return body
+	}
+
+	if token.holderOfKey() && env.Header.Security.Signature == nil {
+		body.Fault_ = Fault("holder-of-key token requires a signed request", &types.InvalidLogin{})
+		return body
+	}
+
+	session := s.newSession(ctx, token.Subject.NameID, req.Locale)
+
+	body.Res = &types.LoginByTokenResponse{
+		Returnval: session.UserSession,
+	}
+
+	return body
+}
+
+func (s *SessionManager) Logout(ctx *Context, _ *types.Logout) soap.HasFault {
+	delete(s.sessions, ctx.Session.Key)
+	ctx.Session = nil
+
+	return &methods.LogoutBody{
+		Res: &types.LogoutResponse{},
+	}
+}
+
+func (s *SessionManager) SessionIsActive(ctx *Context, req *types.SessionIsActive) soap.HasFault {
+	session, ok := s.sessions[req.SessionID]
+
+	return &methods.SessionIsActiveBody{
+		Res: &types.SessionIsActiveResponse{
+			Returnval: ok && session.UserName == req.UserName,
+		},
+	}
+}
+
+func (s *SessionManager) TerminateSession(ctx *Context, req *types.TerminateSession) soap.HasFault {
+	body := &methods.TerminateSessionBody{}
+
+	for _, id := range req.SessionId {
+		if id == ctx.Session.Key {
+			body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "sessionId"})
+			return body
+		}
+
+		if _, ok := s.sessions[id]; !ok {
+			body.Fault_ = Fault("", &types.NotFound{})
+			return body
+		}
+
+		delete(s.sessions, id)
+	}
+
+	body.Res = &types.TerminateSessionResponse{}
+
+	return body
+}
+
+func (s *SessionManager) AcquireCloneTicket(ctx *Context, _ *types.AcquireCloneTicket) soap.HasFault {
+	ticket := newUUID()
+
+	s.tickets[ticket] = ctx.Session.UserName
+
+	return &methods.AcquireCloneTicketBody{
+		Res: &types.AcquireCloneTicketResponse{
+			Returnval: ticket,
+		},
+	}
+}
+
+// CloneSession creates a new session for the user that acquired the given ticket.
+// Tickets can only be used once.
+func (s *SessionManager) CloneSession(ctx *Context, req *types.CloneSession) soap.HasFault {
+	body := &methods.CloneSessionBody{}
+
+	user, ok := s.tickets[req.CloneTicket]
+	if !ok {
+		body.Fault_ = Fault("", &types.InvalidLogin{})
+		return body
+	}
+
+	delete(s.tickets, req.CloneTicket)
+
+	session := s.newSession(ctx, user, "")
+
+	body.Res = &types.CloneSessionResponse{
+		Returnval: session.UserSession,
+	}
+
+	return body
+}
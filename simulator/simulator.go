@@ -0,0 +1,429 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// Trace when set to true, writes SOAP traffic to stderr
+var Trace = false
+
+// Method encapsulates a decoded SOAP client request
+type Method struct {
+	Name string
+	This types.ManagedObjectReference
+	Body types.AnyType
+}
+
+// Service decodes incoming requests and dispatches to a Handler
+type Service struct {
+	Map *Registry
+
+	lock sync.Mutex
+	done chan struct{}
+}
+
+// Server provides a simulator Service over HTTP
+type Server struct {
+	*httptest.Server
+	URL *url.URL
+
+	service *Service
+}
+
+// New returns an initialized simulator Service instance
+func New(r *Registry) *Service {
+	s := &Service{
+		Map:  r,
+		done: make(chan struct{}),
+	}
+
+	return s
+}
+
+// Context provides per-request state to the simulated methods
+type Context struct {
+	context.Context
+
+	// Map is the Registry of the Service handling the request
+	Map *Registry
+
+	// Session is the session associated with the request, nil if not authenticated
+	Session *Session
+
+	svc  *Service
+	req  *http.Request
+	res  http.ResponseWriter
+	body []byte // raw SOAP request envelope
+}
+
+// sleep releases the Service lock for the given duration, such that other requests
+// can be processed while a method such as WaitForUpdatesEx is blocked.
+// Returns false if the request or the Service was cancelled while waiting.
+func (c *Context) sleep(d time.Duration) bool {
+	done := c.svc.done
+
+	c.svc.lock.Unlock()
+	defer c.svc.lock.Lock()
+
+	select {
+	case <-time.After(d):
+		return true
+	case <-c.Done():
+		return false
+	case <-done:
+		return false
+	}
+}
+
+// serverFaultBody is used to return a fault from methods that are not (or cannot be) dispatched
+type serverFaultBody struct {
+	Reason *soap.Fault `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *serverFaultBody) Fault() *soap.Fault { return b.Reason }
+
+func serverFault(msg string) soap.HasFault {
+	return &serverFaultBody{Reason: Fault(msg, &types.InvalidRequest{})}
+}
+
+// Fault wraps the given message and fault in a soap.Fault
+func Fault(msg string, fault types.BaseMethodFault) *soap.Fault {
+	f := &soap.Fault{
+		Code:   "ServerFaultCode",
+		String: msg,
+	}
+
+	if msg == "" {
+		f.String = faultMessage(fault)
+	}
+
+	f.Detail.Fault = fault
+
+	return f
+}
+
+// faultMessage returns a generic message for the given fault, based on its type name
+func faultMessage(fault types.BaseMethodFault) string {
+	return reflect.TypeOf(fault).Elem().Name()
+}
+
+// methods that can be invoked without an authenticated session
+var sessionlessMethods = map[string]bool{
+	"RetrieveServiceContent":      true,
+	"Login":                       true,
+	"LoginByToken":                true,
+	"LoginExtensionByCertificate": true,
+	"CloneSession":                true,
+}
+
+var (
+	contextType   = reflect.TypeOf((*Context)(nil))
+	hasFaultType  = reflect.TypeOf((*soap.HasFault)(nil)).Elem()
+	taskSuffix    = "_Task"
+	taskGoSuffix  = "Task"
+	errNoBodyElem = errors.New("no SOAP Body element found")
+)
+
+func (s *Service) call(ctx *Context, method *Method) soap.HasFault {
+	handler := ctx.Map.Get(method.This)
+
+	if handler == nil {
+		msg := fmt.Sprintf("managed object not found: %s", method.This)
+		return &serverFaultBody{Reason: Fault(msg, &types.ManagedObjectNotFound{Obj: method.This})}
+	}
+
+	if ctx.Session == nil && !sessionlessMethods[method.Name] {
+		fault := &types.NotAuthenticated{
+			NoPermission: types.NoPermission{
+				Object:      method.This,
+				PrivilegeId: "System.View",
+			},
+		}
+		return &serverFaultBody{Reason: Fault("", fault)}
+	}
+
+	name := method.Name
+
+	if strings.HasSuffix(name, taskSuffix) {
+		// Method "Foo_Task" is implemented by a Go method named "FooTask"
+		name = name[:len(name)-len(taskSuffix)] + taskGoSuffix
+	}
+
+	m := reflect.ValueOf(handler).MethodByName(name)
+	if !validMethod(m, method.Body) {
+		msg := fmt.Sprintf("%s does not implement: %s", method.This, method.Name)
+		fault := &types.MethodNotFound{
+			Receiver: method.This,
+			Method:   method.Name,
+		}
+		return &serverFaultBody{Reason: Fault(msg, fault)}
+	}
+
+	args := []reflect.Value{reflect.ValueOf(method.Body)}
+	if m.Type().NumIn() == 2 {
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+
+	res := m.Call(args)
+
+	return res[0].Interface().(soap.HasFault)
+}
+
+// validMethod checks that m has the signature of a simulated method:
+// func([*Context,] *types.Method) soap.HasFault
+func validMethod(m reflect.Value, body interface{}) bool {
+	if !m.IsValid() {
+		return false
+	}
+
+	t := m.Type()
+
+	if t.NumOut() != 1 || t.Out(0) != hasFaultType {
+		return false
+	}
+
+	switch t.NumIn() {
+	case 1:
+	case 2:
+		if t.In(0) != contextType {
+			return false
+		}
+	default:
+		return false
+	}
+
+	return t.In(t.NumIn()-1) == reflect.TypeOf(body)
+}
+
+// ServeHTTP implements the http.Handler interface
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if Trace {
+		fmt.Fprintf(os.Stderr, "Request: %s\n", string(body))
+	}
+
+	var res soap.HasFault
+	name := "unknown"
+
+	method, err := UnmarshalBody(body)
+	if err != nil {
+		res = serverFault(err.Error())
+	} else {
+		name = method.Name
+
+		ctx := &Context{
+			Context: r.Context(),
+			Map:     s.Map,
+			svc:     s,
+			req:     r,
+			res:     w,
+			body:    body,
+		}
+
+		s.lock.Lock()
+		ctx.Session = s.session(ctx)
+		res = s.call(ctx, method)
+		s.lock.Unlock()
+	}
+
+	var out bytes.Buffer
+
+	fmt.Fprint(&out, xml.Header)
+	e := xml.NewEncoder(&out)
+	err = e.Encode(&soap.Envelope{Body: res})
+	if err == nil {
+		err = e.Flush()
+	}
+
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode %s response: %s", name, err))
+	}
+
+	if Trace {
+		fmt.Fprintf(os.Stderr, "Response: %s\n", out.String())
+	}
+
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+
+	if f := res.Fault(); f != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_, _ = w.Write(out.Bytes())
+}
+
+// session returns the Session associated with the request cookie, if any.
+func (s *Service) session(ctx *Context) *Session {
+	sm, ok := s.Map.Get(sessionManagerReference(s.Map)).(*SessionManager)
+	if !ok {
+		return nil
+	}
+
+	return sm.getSession(ctx)
+}
+
+// UnmarshalBody extracts the Body from a soap.Envelope and unmarshals to the corresponding govmomi type
+func UnmarshalBody(data []byte) (*Method, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	typeFunc := types.TypeFunc()
+	dec.TypeFunc = typeFunc // required to decode interface types
+
+	var start *xml.StartElement
+	inBody := false
+
+	for start == nil {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errNoBodyElem
+		}
+
+		t, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if inBody {
+			start = &t
+		} else if t.Name.Local == "Body" {
+			inBody = true
+		}
+	}
+
+	kind := start.Name.Local
+
+	rtype, ok := typeFunc(kind)
+	if !ok {
+		return nil, fmt.Errorf("no vmomi type defined for '%s'", kind)
+	}
+
+	val := reflect.New(rtype).Interface()
+
+	err := dec.DecodeElement(val, start)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %s", kind, err)
+	}
+
+	method := &Method{Name: kind, Body: val}
+
+	field := reflect.ValueOf(val).Elem().FieldByName("This")
+	if !field.IsValid() {
+		return nil, fmt.Errorf("%s is not a method request", kind)
+	}
+
+	method.This = field.Interface().(types.ManagedObjectReference)
+
+	return method, nil
+}
+
+// NewServer returns an http Server instance for the given service
+func (s *Service) NewServer() *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/sdk", s)
+	mux.Handle(STSPath, new(STS))
+	mux.Handle(STSPath+"/", new(STS))
+
+	ts := httptest.NewServer(mux)
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		panic(err)
+	}
+
+	u.Path = "/sdk"
+	u.User = url.UserPassword("user", "pass")
+
+	s.lock.Lock()
+	select {
+	case <-s.done:
+		// Service was used by a Server that has since been closed
+		s.done = make(chan struct{})
+	default:
+	}
+	s.lock.Unlock()
+
+	return &Server{
+		Server:  ts,
+		URL:     u,
+		service: s,
+	}
+}
+
+// Close shuts down the server, cancelling any requests that are blocked waiting for updates.
+func (s *Server) Close() {
+	s.service.shutdown()
+	s.Server.Close()
+}
+
+// NewClient returns a vim25.Client connected to the server, which is not logged in.
+func (s *Server) NewClient(ctx context.Context) (*vim25.Client, error) {
+	return vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+}
+
+func (s *Service) shutdown() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+// newUUID returns a random (version 4) UUID string
+func newUUID() string {
+	var b [16]byte
+
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestServeHTTP(t *testing.T) {
+	ctx := context.Background()
+
+	for _, m := range []*Model{ESX(), VPX()} {
+		err := m.Create()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := m.Service.NewServer()
+
+		c, err := govmomi.NewClient(ctx, s.URL, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.IsVC() == m.IsESX() {
+			t.Errorf("IsVC=%t", c.IsVC())
+		}
+
+		var folder mo.Folder
+		err = c.RetrieveOne(ctx, c.ServiceContent.RootFolder, []string{"name", "childEntity"}, &folder)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(folder.ChildEntity) != 1 {
+			t.Errorf("childEntity=%s", folder.ChildEntity)
+		}
+
+		err = c.Logout(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+
+		s.Close()
+	}
+}
+
+func TestNotAuthenticated(t *testing.T) {
+	ctx := context.Background()
+
+	m := VPX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	c, err := vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var folder mo.Folder
+	err = mo.RetrieveProperties(ctx, c, c.ServiceContent.PropertyCollector, c.ServiceContent.RootFolder, &folder)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !soap.IsSoapFault(err) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, ok := soap.ToSoapFault(err).VimFault().(types.NotAuthenticated); !ok {
+		t.Errorf("unexpected fault: %#v", soap.ToSoapFault(err).VimFault())
+	}
+}
+
+func TestMethodNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	m := VPX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	c, err := govmomi.NewClient(ctx, s.URL, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := types.CurrentTime{This: c.ServiceContent.RootFolder}
+
+	_, err = methods.CurrentTime(ctx, c, &req)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if _, ok := soap.ToSoapFault(err).VimFault().(types.MethodNotFound); !ok {
+		t.Errorf("unexpected fault: %#v", soap.ToSoapFault(err).VimFault())
+	}
+
+	req.This = types.ManagedObjectReference{Type: "Folder", Value: "enoent"}
+
+	_, err = methods.CurrentTime(ctx, c, &req)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if _, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound); !ok {
+		t.Errorf("unexpected fault: %#v", soap.ToSoapFault(err).VimFault())
+	}
+}
+
+func TestUnmarshalBody(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>
+<RetrieveServiceContent xmlns="urn:vim25"><_this type="ServiceInstance">ServiceInstance</_this></RetrieveServiceContent>
+</soapenv:Body>
+</soapenv:Envelope>`
+
+	method, err := UnmarshalBody([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method.Name != "RetrieveServiceContent" {
+		t.Errorf("name=%s", method.Name)
+	}
+
+	if method.This != serviceInstance {
+		t.Errorf("this=%s", method.This)
+	}
+
+	if _, ok := method.Body.(*types.RetrieveServiceContent); !ok {
+		t.Errorf("body=%T", method.Body)
+	}
+
+	_, err = UnmarshalBody([]byte("<Envelope><Body><Enoent/></Body></Envelope>"))
+	if err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// STSPath is the path of the simulated Security Token Service endpoint
+const STSPath = "/sts/STSService"
+
+const (
+	stsTime        = "2006-01-02T15:04:05.000Z"
+	stsBearer      = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	stsHolderOfKey = "urn:oasis:names:tc:SAML:2.0:cm:holder-of-key"
+)
+
+type stsAssertion struct {
+	ID      string `xml:"ID,attr"`
+	Subject struct {
+		NameID              string `xml:"NameID"`
+		SubjectConfirmation struct {
+			Method string `xml:"Method,attr"`
+		} `xml:"SubjectConfirmation"`
+	} `xml:"Subject"`
+}
+
+type stsSecurity struct {
+	UsernameToken *struct {
+		Username string `xml:"Username"`
+		Password string `xml:"Password"`
+	} `xml:"UsernameToken"`
+	BinarySecurityToken string        `xml:"BinarySecurityToken"`
+	Assertion           *stsAssertion `xml:"Assertion"`
+	Signature           *struct{}     `xml:"Signature"`
+}
+
+type stsEnvelope struct {
+	Header struct {
+		Security stsSecurity `xml:"Security"`
+	} `xml:"Header"`
+	Body struct {
+		RequestSecurityToken struct {
+			RequestType string `xml:"RequestType"`
+			KeyType     string `xml:"KeyType"`
+			Lifetime    struct {
+				Expires string `xml:"Expires"`
+			} `xml:"Lifetime"`
+			RenewTarget struct {
+				Assertion *stsAssertion `xml:"Assertion"`
+			} `xml:"RenewTarget"`
+		} `xml:"RequestSecurityToken"`
+	} `xml:"Body"`
+}
+
+// parseSecurity returns the WS-Security header of the given SOAP request envelope
+func parseSecurity(body []byte) (*stsEnvelope, error) {
+	var env stsEnvelope
+
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(&env); err != nil {
+		return nil, err
+	}
+
+	return &env, nil
+}
+
+// holderOfKey returns true if the token requires requests to be signed
+func (a *stsAssertion) holderOfKey() bool {
+	return a.Subject.SubjectConfirmation.Method == stsHolderOfKey
+}
+
+// STS is a stand-in for the vCenter Security Token Service.
+// Tokens are issued for any user name with a non-empty password, or for the subject
+// common name of a BinarySecurityToken certificate. Request signatures are required
+// when a certificate is used, but are not verified, and issued tokens are not signed.
+type STS struct{}
+
+func (s *STS) fault(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusInternalServerError)
+
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(msg))
+
+	fmt.Fprintf(w, `%s<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>`+
+		`<faultcode>wst:InvalidRequest</faultcode><faultstring>%s</faultstring></soap:Fault></soap:Body></soap:Envelope>`,
+		xml.Header, buf.String())
+}
+
+// subject returns the authenticated subject of an Issue request
+func (s *STS) subject(sec *stsSecurity) (string, error) {
+	if sec.UsernameToken != nil {
+		if sec.UsernameToken.Username == "" || sec.UsernameToken.Password == "" {
+			return "", fmt.Errorf("invalid credentials")
+		}
+
+		return sec.UsernameToken.Username, nil
+	}
+
+	if sec.BinarySecurityToken == "" {
+		return "", fmt.Errorf("no credentials")
+	}
+
+	if sec.Signature == nil {
+		return "", fmt.Errorf("request is not signed")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sec.BinarySecurityToken))
+	if err != nil {
+		return "", err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return "", err
+	}
+
+	return cert.Subject.CommonName, nil
+}
+
+func (s *STS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	env, err := parseSecurity(body)
+	if err != nil {
+		s.fault(w, err.Error())
+		return
+	}
+
+	rst := &env.Body.RequestSecurityToken
+	sec := &env.Header.Security
+
+	var subject, method string
+
+	switch {
+	case strings.HasSuffix(rst.RequestType, "/Issue"):
+		subject, err = s.subject(sec)
+		if err != nil {
+			s.fault(w, err.Error())
+			return
+		}
+
+		method = stsBearer
+		if strings.HasSuffix(rst.KeyType, "/PublicKey") {
+			if sec.Signature == nil {
+				s.fault(w, "holder-of-key request is not signed")
+				return
+			}
+			method = stsHolderOfKey
+		}
+	case strings.HasSuffix(rst.RequestType, "/Renew"):
+		target := rst.RenewTarget.Assertion
+		if target == nil || sec.Signature == nil {
+			s.fault(w, "renew requires a signed request with a RenewTarget")
+			return
+		}
+
+		subject = target.Subject.NameID
+		method = target.Subject.SubjectConfirmation.Method
+	default:
+		s.fault(w, fmt.Sprintf("unsupported request type %q", rst.RequestType))
+		return
+	}
+
+	now := time.Now().UTC()
+	expires, err := time.Parse(stsTime, rst.Lifetime.Expires)
+	if err != nil {
+		expires = now.Add(10 * time.Minute)
+	}
+
+	var name bytes.Buffer
+	_ = xml.EscapeText(&name, []byte(subject))
+
+	token := fmt.Sprintf(`<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="_%s" IssueInstant="%s" Version="2.0">`+
+		`<saml2:Issuer>%s</saml2:Issuer><saml2:Subject><saml2:NameID>%s</saml2:NameID>`+
+		`<saml2:SubjectConfirmation Method="%s"></saml2:SubjectConfirmation></saml2:Subject>`+
+		`<saml2:Conditions NotBefore="%s" NotOnOrAfter="%s"></saml2:Conditions></saml2:Assertion>`,
+		newUUID(), now.Format(stsTime), r.Host, name.String(), method, now.Format(stsTime), expires.Format(stsTime))
+
+	res := fmt.Sprintf(`<wst:RequestSecurityTokenResponse><wst:TokenType>urn:oasis:names:tc:SAML:2.0:assertion</wst:TokenType>`+
+		`<wst:Lifetime><wsu:Created xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">%s</wsu:Created>`+
+		`<wsu:Expires xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">%s</wsu:Expires></wst:Lifetime>`+
+		`<wst:RequestedSecurityToken>%s</wst:RequestedSecurityToken></wst:RequestSecurityTokenResponse>`,
+		now.Format(stsTime), expires.Format(stsTime), token)
+
+	if strings.HasSuffix(rst.RequestType, "/Issue") {
+		res = "<wst:RequestSecurityTokenResponseCollection>" + res + "</wst:RequestSecurityTokenResponseCollection>"
+	}
+
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+
+	fmt.Fprintf(w, `%s<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:wst="http://docs.oasis-open.org/ws-sx/ws-trust/200512">`+
+		`<soap:Body>%s</soap:Body></soap:Envelope>`, xml.Header, res)
+}
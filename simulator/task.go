@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Task simulates the vim.Task managed object.
+// Simulated tasks run to completion before the method that created them returns.
+type Task struct {
+	mo.Task
+
+	Execute func(*Task) (types.AnyType, types.BaseMethodFault)
+}
+
+// CreateTask returns a Task for the given entity, where name is the method name
+// (for example "powerOn") and run is the function that performs the operation.
+func CreateTask(e mo.Reference, name string, run func(*Task) (types.AnyType, types.BaseMethodFault)) *Task {
+	ref := e.Reference()
+
+	task := &Task{
+		Execute: run,
+	}
+
+	task.Info.Name = name
+	task.Info.DescriptionId = fmt.Sprintf("%s.%s", ref.Type, name)
+	task.Info.Entity = &ref
+	task.Info.QueueTime = time.Now()
+	task.Info.State = types.TaskInfoStateQueued
+
+	if entity, ok := e.(mo.Entity); ok {
+		task.Info.EntityName = entityName(entity)
+	}
+
+	return task
+}
+
+// Run adds the Task to the Registry and executes it, returning the Task reference
+func (t *Task) Run(ctx *Context) types.ManagedObjectReference {
+	ctx.Map.Put(t)
+
+	t.Info.Task = t.Self
+	t.Info.Key = t.Self.Value
+
+	if ctx.Session != nil {
+		t.Info.Reason = &types.TaskReasonUser{UserName: ctx.Session.UserName}
+	}
+
+	start := time.Now()
+	t.Info.StartTime = &start
+	t.Info.State = types.TaskInfoStateRunning
+
+	res, err := t.Execute(t)
+
+	done := time.Now()
+	t.Info.CompleteTime = &done
+
+	if err != nil {
+		t.Info.State = types.TaskInfoStateError
+		t.Info.Error = &types.LocalizedMethodFault{
+			Fault:            err,
+			LocalizedMessage: faultMessage(err),
+		}
+	} else {
+		t.Info.State = types.TaskInfoStateSuccess
+		t.Info.Result = res
+		t.Info.Progress = 100
+	}
+
+	return t.Self
+}
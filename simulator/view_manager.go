@@ -0,0 +1,346 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ViewManager simulates the vim.view.ViewManager managed object
+type ViewManager struct {
+	mo.ViewManager
+}
+
+// NewViewManager returns a ViewManager with the given reference
+func NewViewManager(ref types.ManagedObjectReference) mo.Reference {
+	s := &ViewManager{}
+	s.Self = ref
+	return s
+}
+
+// destroyView removes the given view from the ViewManager and Registry
+func (m *ViewManager) destroyView(ctx *Context, ref types.ManagedObjectReference) {
+	m.ViewList = RemoveReference(ref, m.ViewList)
+	ctx.Map.Remove(ref)
+}
+
+func (m *ViewManager) CreateContainerView(ctx *Context, req *types.CreateContainerView) soap.HasFault {
+	body := &methods.CreateContainerViewBody{}
+
+	if _, ok := ctx.Map.Get(req.Container).(mo.Entity); !ok {
+		body.Fault_ = Fault("", &types.ManagedObjectNotFound{Obj: req.Container})
+		return body
+	}
+
+	view := &ContainerView{
+		manager: m,
+	}
+
+	view.Container = req.Container
+	view.Type = req.Type
+	view.Recursive = req.Recursive
+
+	ctx.Map.Put(view)
+	m.ViewList = append(m.ViewList, view.Self)
+
+	body.Res = &types.CreateContainerViewResponse{
+		Returnval: view.Self,
+	}
+
+	return body
+}
+
+func (m *ViewManager) CreateListView(ctx *Context, req *types.CreateListView) soap.HasFault {
+	body := &methods.CreateListViewBody{}
+
+	view := &ListView{
+		manager: m,
+	}
+
+	ctx.Map.Put(view)
+	m.ViewList = append(m.ViewList, view.Self)
+
+	// References that do not resolve to an object are ignored
+	view.add(ctx, req.Obj)
+
+	body.Res = &types.CreateListViewResponse{
+		Returnval: view.Self,
+	}
+
+	return body
+}
+
+func (m *ViewManager) CreateInventoryView(ctx *Context, req *types.CreateInventoryView) soap.HasFault {
+	view := &InventoryView{
+		manager: m,
+	}
+
+	view.refresh(ctx)
+
+	ctx.Map.Put(view)
+	m.ViewList = append(m.ViewList, view.Self)
+
+	return &methods.CreateInventoryViewBody{
+		Res: &types.CreateInventoryViewResponse{
+			Returnval: view.Self,
+		},
+	}
+}
+
+// ContainerView simulates the vim.view.ContainerView managed object
+type ContainerView struct {
+	mo.ContainerView
+
+	manager *ViewManager
+}
+
+// childEntities returns the children of the given object, as traversed by a ContainerView
+func childEntities(obj mo.Reference) []types.ManagedObjectReference {
+	var refs []types.ManagedObjectReference
+
+	switch e := obj.(type) {
+	case *Folder:
+		refs = append(refs, e.ChildEntity...)
+	case *Datacenter:
+		refs = append(refs, e.VmFolder, e.HostFolder, e.DatastoreFolder, e.NetworkFolder)
+	case *ComputeResource:
+		refs = append(refs, e.Host...)
+		refs = append(refs, *e.ResourcePool)
+	case *ClusterComputeResource:
+		refs = append(refs, e.Host...)
+		refs = append(refs, *e.ResourcePool)
+	case *ResourcePool:
+		refs = append(refs, e.ResourcePool.ResourcePool...)
+		refs = append(refs, e.Vm...)
+	case *HostSystem:
+		refs = append(refs, e.Vm...)
+	}
+
+	return refs
+}
+
+func (v *ContainerView) include(obj mo.Reference) bool {
+	if len(v.Type) == 0 {
+		return true
+	}
+
+	for _, kind := range v.Type {
+		if isKind(obj, kind) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *ContainerView) collect(ctx *Context, ref types.ManagedObjectReference, seen map[types.ManagedObjectReference]bool) {
+	for _, child := range childEntities(ctx.Map.Get(ref)) {
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+
+		obj := ctx.Map.Get(child)
+		if obj == nil {
+			continue
+		}
+
+		if v.include(obj) {
+			v.View = append(v.View, child)
+		}
+
+		if v.Recursive {
+			v.collect(ctx, child, seen)
+		}
+	}
+}
+
+// refresh computes the view from the current state of the inventory
+func (v *ContainerView) refresh(ctx *Context) {
+	v.View = nil
+
+	seen := map[types.ManagedObjectReference]bool{
+		v.Container: true,
+	}
+
+	v.collect(ctx, v.Container, seen)
+}
+
+func (v *ContainerView) DestroyView(ctx *Context, c *types.DestroyView) soap.HasFault {
+	v.manager.destroyView(ctx, v.Self)
+
+	return &methods.DestroyViewBody{
+		Res: &types.DestroyViewResponse{},
+	}
+}
+
+// ListView simulates the vim.view.ListView managed object
+type ListView struct {
+	mo.ListView
+
+	manager *ViewManager
+}
+
+// add appends the given references to the view, returning those that do not resolve to an object
+func (v *ListView) add(ctx *Context, refs []types.ManagedObjectReference) []types.ManagedObjectReference {
+	var unresolved []types.ManagedObjectReference
+
+	for _, ref := range refs {
+		if ctx.Map.Get(ref) == nil {
+			unresolved = append(unresolved, ref)
+			continue
+		}
+
+		v.View = AddReference(ref, v.View)
+	}
+
+	return unresolved
+}
+
+func (v *ListView) ModifyListView(ctx *Context, req *types.ModifyListView) soap.HasFault {
+	unresolved := v.add(ctx, req.Add)
+
+	for _, ref := range req.Remove {
+		v.View = RemoveReference(ref, v.View)
+	}
+
+	return &methods.ModifyListViewBody{
+		Res: &types.ModifyListViewResponse{
+			Returnval: unresolved,
+		},
+	}
+}
+
+func (v *ListView) ResetListView(ctx *Context, req *types.ResetListView) soap.HasFault {
+	v.View = nil
+
+	return &methods.ResetListViewBody{
+		Res: &types.ResetListViewResponse{
+			Returnval: v.add(ctx, req.Obj),
+		},
+	}
+}
+
+func (v *ListView) DestroyView(ctx *Context, c *types.DestroyView) soap.HasFault {
+	v.manager.destroyView(ctx, v.Self)
+
+	return &methods.DestroyViewBody{
+		Res: &types.DestroyViewResponse{},
+	}
+}
+
+// InventoryView simulates the vim.view.InventoryView managed object
+type InventoryView struct {
+	mo.InventoryView
+
+	manager *ViewManager
+
+	// open is the list of expanded entities, in the order they were opened
+	open []types.ManagedObjectReference
+}
+
+// refresh computes the view from the root folder and the children of each open entity
+func (v *InventoryView) refresh(ctx *Context) {
+	si := ctx.Map.Get(serviceInstance).(*ServiceInstance)
+
+	v.View = []types.ManagedObjectReference{si.Content.RootFolder}
+
+	for _, ref := range v.open {
+		for _, child := range childEntities(ctx.Map.Get(ref)) {
+			if ctx.Map.Get(child) != nil {
+				v.View = AddReference(child, v.View)
+			}
+		}
+	}
+}
+
+// contains returns true if the view includes ref
+func (v *InventoryView) contains(ref types.ManagedObjectReference) bool {
+	for _, r := range v.View {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *InventoryView) OpenInventoryViewFolder(ctx *Context, req *types.OpenInventoryViewFolder) soap.HasFault {
+	var unresolved []types.ManagedObjectReference
+
+	for _, ref := range req.Entity {
+		if ctx.Map.Get(ref) == nil {
+			unresolved = append(unresolved, ref)
+			continue
+		}
+
+		v.open = AddReference(ref, v.open)
+	}
+
+	v.refresh(ctx)
+
+	return &methods.OpenInventoryViewFolderBody{
+		Res: &types.OpenInventoryViewFolderResponse{
+			Returnval: unresolved,
+		},
+	}
+}
+
+func (v *InventoryView) CloseInventoryViewFolder(ctx *Context, req *types.CloseInventoryViewFolder) soap.HasFault {
+	var unresolved []types.ManagedObjectReference
+
+	for _, ref := range req.Entity {
+		if ctx.Map.Get(ref) == nil {
+			unresolved = append(unresolved, ref)
+			continue
+		}
+
+		v.open = RemoveReference(ref, v.open)
+	}
+
+	// Closing an entity also closes its open descendants, which are no longer in the view
+	for {
+		v.refresh(ctx)
+
+		n := len(v.open)
+		for _, ref := range v.open {
+			if !v.contains(ref) {
+				v.open = RemoveReference(ref, v.open)
+			}
+		}
+
+		if len(v.open) == n {
+			break
+		}
+	}
+
+	return &methods.CloseInventoryViewFolderBody{
+		Res: &types.CloseInventoryViewFolderResponse{
+			Returnval: unresolved,
+		},
+	}
+}
+
+func (v *InventoryView) DestroyView(ctx *Context, c *types.DestroyView) soap.HasFault {
+	v.manager.destroyView(ctx, v.Self)
+
+	return &methods.DestroyViewBody{
+		Res: &types.DestroyViewResponse{},
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestContainerView(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	tests := []struct {
+		kind      []string
+		recursive bool
+		count     int
+	}{
+		{[]string{"VirtualMachine"}, true, 4},
+		{[]string{"HostSystem"}, true, 4},
+		{[]string{"ComputeResource"}, true, 2},
+		{[]string{"ClusterComputeResource"}, true, 1},
+		{[]string{"Datacenter"}, false, 1},
+		{[]string{"VirtualMachine"}, false, 0},
+		{nil, false, 1},
+	}
+
+	for _, test := range tests {
+		res, err := methods.CreateContainerView(ctx, c, &types.CreateContainerView{
+			This:      *c.ServiceContent.ViewManager,
+			Container: c.ServiceContent.RootFolder,
+			Type:      test.kind,
+			Recursive: test.recursive,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var view mo.ContainerView
+		err = c.RetrieveOne(ctx, res.Returnval, nil, &view)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(view.View) != test.count {
+			t.Errorf("%v: %d != %d", test.kind, len(view.View), test.count)
+		}
+
+		_, err = methods.DestroyView(ctx, c, &types.DestroyView{This: res.Returnval})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.RetrieveOne(ctx, res.Returnval, nil, &view)
+		if err == nil {
+			t.Error("expected error")
+		}
+	}
+}
+
+func TestListView(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	enoent := types.ManagedObjectReference{Type: "Folder", Value: "enoent"}
+
+	res, err := methods.CreateListView(ctx, c, &types.CreateListView{
+		This: *c.ServiceContent.ViewManager,
+		Obj:  []types.ManagedObjectReference{c.ServiceContent.RootFolder, enoent},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var view mo.ListView
+	err = c.RetrieveOne(ctx, res.Returnval, nil, &view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(view.View) != 1 {
+		t.Errorf("view=%s", view.View)
+	}
+
+	mres, err := methods.ModifyListView(ctx, c, &types.ModifyListView{
+		This:   res.Returnval,
+		Add:    []types.ManagedObjectReference{enoent},
+		Remove: []types.ManagedObjectReference{c.ServiceContent.RootFolder},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mres.Returnval) != 1 || mres.Returnval[0] != enoent {
+		t.Errorf("unresolved=%s", mres.Returnval)
+	}
+
+	err = c.RetrieveOne(ctx, res.Returnval, nil, &view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(view.View) != 0 {
+		t.Errorf("view=%s", view.View)
+	}
+}
+
+func TestInventoryView(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	res, err := methods.CreateInventoryView(ctx, c, &types.CreateInventoryView{
+		This: *c.ServiceContent.ViewManager,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref := res.Returnval
+
+	view := func() []types.ManagedObjectReference {
+		var v mo.InventoryView
+		if err := c.RetrieveOne(ctx, ref, nil, &v); err != nil {
+			t.Fatal(err)
+		}
+		return v.View
+	}
+
+	if v := view(); len(v) != 1 || v[0] != c.ServiceContent.RootFolder {
+		t.Fatalf("view=%s", v)
+	}
+
+	enoent := types.ManagedObjectReference{Type: "Folder", Value: "enoent"}
+
+	ores, err := methods.OpenInventoryViewFolder(ctx, c, &types.OpenInventoryViewFolder{
+		This:   ref,
+		Entity: []types.ManagedObjectReference{c.ServiceContent.RootFolder, enoent},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ores.Returnval) != 1 || ores.Returnval[0] != enoent {
+		t.Errorf("unresolved=%s", ores.Returnval)
+	}
+
+	// root folder + datacenter
+	v := view()
+	if len(v) != 2 || v[1].Type != "Datacenter" {
+		t.Fatalf("view=%s", v)
+	}
+
+	_, err = methods.OpenInventoryViewFolder(ctx, c, &types.OpenInventoryViewFolder{
+		This:   ref,
+		Entity: []types.ManagedObjectReference{v[1]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// root folder + datacenter + {vm,host,datastore,network} folders
+	if v = view(); len(v) != 6 {
+		t.Errorf("view=%s", v)
+	}
+
+	_, err = methods.CloseInventoryViewFolder(ctx, c, &types.CloseInventoryViewFolder{
+		This:   ref,
+		Entity: []types.ManagedObjectReference{c.ServiceContent.RootFolder},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// closing the root folder also closes the datacenter
+	if v = view(); len(v) != 1 {
+		t.Errorf("view=%s", v)
+	}
+
+	_, err = methods.OpenInventoryViewFolder(ctx, c, &types.OpenInventoryViewFolder{
+		This:   ref,
+		Entity: []types.ManagedObjectReference{c.ServiceContent.RootFolder},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v = view(); len(v) != 2 {
+		t.Errorf("view=%s", v)
+	}
+
+	_, err = methods.DestroyView(ctx, c, &types.DestroyView{This: ref})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
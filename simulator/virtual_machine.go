@@ -0,0 +1,714 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// VirtualMachine simulates the vim.VirtualMachine managed object
+type VirtualMachine struct {
+	mo.VirtualMachine
+}
+
+// NewVirtualMachine returns a powered off VirtualMachine configured with the given spec,
+// which must specify a name and a vmPathName of the form "[datastore]" or "[datastore] path".
+func NewVirtualMachine(spec *types.VirtualMachineConfigSpec) (*VirtualMachine, types.BaseMethodFault) {
+	vm := &VirtualMachine{}
+
+	if spec.Name == "" {
+		return nil, &types.InvalidVmConfig{Property: "configSpec.name"}
+	}
+
+	if spec.Files == nil {
+		return nil, &types.InvalidVmConfig{Property: "configSpec.files"}
+	}
+
+	ds, p, ok := parseDatastorePath(spec.Files.VmPathName)
+	if !ok {
+		return nil, &types.InvalidDatastorePath{DatastorePath: spec.Files.VmPathName}
+	}
+
+	if p == "" {
+		p = fmt.Sprintf("%s/%s.vmx", spec.Name, spec.Name)
+	}
+
+	dir := fmt.Sprintf("[%s] %s", ds, path.Dir(p))
+	now := time.Now()
+
+	vm.OverallStatus = types.ManagedEntityStatusGreen
+
+	vm.Config = &types.VirtualMachineConfigInfo{
+		Files: types.VirtualMachineFileInfo{
+			VmPathName:        fmt.Sprintf("[%s] %s", ds, p),
+			SnapshotDirectory: dir,
+			SuspendDirectory:  dir,
+			LogDirectory:      dir,
+		},
+		Tools: &types.ToolsConfigInfo{},
+		Hardware: types.VirtualHardware{
+			Device: defaultDevices(),
+		},
+	}
+
+	vm.Runtime = types.VirtualMachineRuntimeInfo{
+		ConnectionState: types.VirtualMachineConnectionStateConnected,
+		PowerState:      types.VirtualMachinePowerStatePoweredOff,
+	}
+
+	vm.Guest = &types.GuestInfo{
+		ToolsStatus: types.VirtualMachineToolsStatusToolsNotInstalled,
+		GuestState:  "notRunning",
+	}
+
+	vm.Summary.Guest = &types.VirtualMachineGuestSummary{
+		ToolsStatus: types.VirtualMachineToolsStatusToolsNotInstalled,
+	}
+
+	vm.Summary.Storage = &types.VirtualMachineStorageSummary{
+		Timestamp: now,
+	}
+
+	vm.Summary.Config.VmPathName = vm.Config.Files.VmPathName
+	vm.Summary.OverallStatus = types.ManagedEntityStatusGreen
+	vm.Summary.Runtime = vm.Runtime
+
+	defaults := types.VirtualMachineConfigSpec{
+		NumCPUs:           1,
+		NumCoresPerSocket: 1,
+		MemoryMB:          32,
+		Uuid:              newUUID(),
+		InstanceUuid:      newUUID(),
+		Version:           "vmx-11",
+		GuestId:           string(types.VirtualMachineGuestOsIdentifierOtherGuest),
+	}
+
+	for _, s := range []*types.VirtualMachineConfigSpec{&defaults, spec} {
+		if err := vm.configure(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return vm, nil
+}
+
+// configure applies the given spec to the VM config and its derived summary properties
+func (vm *VirtualMachine) configure(spec *types.VirtualMachineConfigSpec) types.BaseMethodFault {
+	config := vm.Config
+	summary := &vm.Summary.Config
+
+	apply := []struct {
+		src string
+		dst []*string
+	}{
+		{spec.Name, []*string{&vm.Name, &config.Name, &summary.Name}},
+		{spec.GuestId, []*string{&config.GuestId, &config.GuestFullName, &summary.GuestId, &summary.GuestFullName,
+			&vm.Summary.Guest.GuestId, &vm.Summary.Guest.GuestFullName}},
+		{spec.AlternateGuestName, []*string{&config.AlternateGuestName}},
+		{spec.Annotation, []*string{&config.Annotation, &summary.Annotation}},
+		{spec.Uuid, []*string{&config.Uuid, &summary.Uuid}},
+		{spec.InstanceUuid, []*string{&config.InstanceUuid, &summary.InstanceUuid}},
+		{spec.Version, []*string{&config.Version}},
+	}
+
+	for _, f := range apply {
+		if f.src != "" {
+			for _, dst := range f.dst {
+				*dst = f.src
+			}
+		}
+	}
+
+	if spec.NumCPUs != 0 {
+		config.Hardware.NumCPU = spec.NumCPUs
+		summary.NumCpu = spec.NumCPUs
+	}
+
+	if spec.NumCoresPerSocket != 0 {
+		config.Hardware.NumCoresPerSocket = spec.NumCoresPerSocket
+	}
+
+	if spec.MemoryMB != 0 {
+		config.Hardware.MemoryMB = int32(spec.MemoryMB)
+		summary.MemorySizeMB = int32(spec.MemoryMB)
+	}
+
+	for _, o := range spec.ExtraConfig {
+		vm.setExtraConfig(o.GetOptionValue())
+	}
+
+	config.Modified = time.Now()
+
+	return vm.configureDevices(spec)
+}
+
+// setExtraConfig adds or updates the given option, an option with an empty value is removed.
+func (vm *VirtualMachine) setExtraConfig(o *types.OptionValue) {
+	remove := o.Value == nil || o.Value == ""
+
+	for i, x := range vm.Config.ExtraConfig {
+		if x.GetOptionValue().Key != o.Key {
+			continue
+		}
+
+		if remove {
+			vm.Config.ExtraConfig = append(vm.Config.ExtraConfig[:i], vm.Config.ExtraConfig[i+1:]...)
+		} else {
+			vm.Config.ExtraConfig[i] = o
+		}
+
+		return
+	}
+
+	if !remove {
+		vm.Config.ExtraConfig = append(vm.Config.ExtraConfig, o)
+	}
+}
+
+func newController(key int32, bus int32, label string, devices ...int32) types.VirtualController {
+	return types.VirtualController{
+		VirtualDevice: types.VirtualDevice{
+			Key: key,
+			DeviceInfo: &types.Description{
+				Label:   label,
+				Summary: label,
+			},
+		},
+		BusNumber: bus,
+		Device:    devices,
+	}
+}
+
+func newDevice(key int32, controller int32, unit int32, label string) types.VirtualDevice {
+	return types.VirtualDevice{
+		Key: key,
+		DeviceInfo: &types.Description{
+			Label:   label,
+			Summary: label,
+		},
+		ControllerKey: controller,
+		UnitNumber:    &unit,
+	}
+}
+
+// defaultDevices returns the devices created by vCenter for a VM with an empty device spec
+func defaultDevices() []types.BaseVirtualDevice {
+	return []types.BaseVirtualDevice{
+		&types.VirtualIDEController{VirtualController: newController(200, 0, "IDE 0")},
+		&types.VirtualIDEController{VirtualController: newController(201, 1, "IDE 1")},
+		&types.VirtualPS2Controller{VirtualController: newController(300, 0, "PS2 controller 0", 600, 700)},
+		&types.VirtualPCIController{VirtualController: newController(100, 0, "PCI controller 0", 500)},
+		&types.VirtualSIOController{VirtualController: newController(400, 0, "SIO controller 0")},
+		&types.VirtualKeyboard{VirtualDevice: newDevice(600, 300, 0, "Keyboard ")},
+		&types.VirtualPointingDevice{VirtualDevice: newDevice(700, 300, 1, "Pointing device")},
+		&types.VirtualMachineVideoCard{VirtualDevice: newDevice(500, 100, 0, "Video card "), VideoRamSizeInKB: 4096},
+	}
+}
+
+// findDevice returns the index of the device with the given key, or -1 if not found
+func findDevice(devices []types.BaseVirtualDevice, key int32) int {
+	for i, d := range devices {
+		if d.GetVirtualDevice().Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// newDeviceKey returns an unused device key, within the range vCenter uses for the type of device
+func newDeviceKey(devices []types.BaseVirtualDevice, device types.BaseVirtualDevice) int32 {
+	key := int32(9000)
+
+	switch device.(type) {
+	case types.BaseVirtualController:
+		key = 1000
+	case *types.VirtualDisk:
+		key = 2000
+	case *types.VirtualCdrom:
+		key = 3000
+	case types.BaseVirtualEthernetCard:
+		key = 4000
+	case *types.VirtualFloppy:
+		key = 8000
+	}
+
+	for findDevice(devices, key) != -1 {
+		key++
+	}
+
+	return key
+}
+
+// newUnitNumber returns the first unit number not used by devices attached to the given controller
+func newUnitNumber(devices []types.BaseVirtualDevice, c types.BaseVirtualController) int32 {
+	used := make(map[int32]bool)
+
+	if scsi, ok := c.(types.BaseVirtualSCSIController); ok {
+		// The SCSI controller sits on its own bus
+		used[scsi.GetVirtualSCSIController().ScsiCtlrUnitNumber] = true
+	}
+
+	key := c.GetVirtualController().Key
+
+	for _, device := range devices {
+		d := device.GetVirtualDevice()
+		if d.ControllerKey == key && d.UnitNumber != nil {
+			used[*d.UnitNumber] = true
+		}
+	}
+
+	unit := int32(0)
+	for used[unit] {
+		unit++
+	}
+
+	return unit
+}
+
+func countDevices(devices []types.BaseVirtualDevice, kind reflect.Type) int32 {
+	var n int32
+
+	for _, d := range devices {
+		t := reflect.TypeOf(d)
+
+		if t == kind || (kind.Kind() == reflect.Interface && t.Implements(kind)) {
+			n++
+		}
+	}
+
+	return n
+}
+
+var (
+	virtualDiskType         = reflect.TypeOf((*types.VirtualDisk)(nil))
+	virtualEthernetCardType = reflect.TypeOf((*types.BaseVirtualEthernetCard)(nil)).Elem()
+)
+
+// deviceLabel returns the default label for a new device
+func deviceLabel(devices []types.BaseVirtualDevice, device types.BaseVirtualDevice) string {
+	switch device.(type) {
+	case *types.VirtualDisk:
+		return fmt.Sprintf("Hard disk %d", countDevices(devices, virtualDiskType)+1)
+	case types.BaseVirtualEthernetCard:
+		return fmt.Sprintf("Network adapter %d", countDevices(devices, virtualEthernetCardType)+1)
+	}
+
+	return fmt.Sprintf("%s %d", reflect.TypeOf(device).Elem().Name(), device.GetVirtualDevice().Key)
+}
+
+// configureDevice fills in the properties that vCenter generates for a device being added to the VM
+func (vm *VirtualMachine) configureDevice(devices []types.BaseVirtualDevice, device types.BaseVirtualDevice) types.BaseMethodFault {
+	d := device.GetVirtualDevice()
+
+	if d.DeviceInfo == nil {
+		label := deviceLabel(devices, device)
+		d.DeviceInfo = &types.Description{
+			Label:   label,
+			Summary: label,
+		}
+	}
+
+	switch x := device.(type) {
+	case types.BaseVirtualSCSIController:
+		c := x.GetVirtualSCSIController()
+		if c.ScsiCtlrUnitNumber == 0 {
+			c.ScsiCtlrUnitNumber = 7
+		}
+	case types.BaseVirtualEthernetCard:
+		nic := x.GetVirtualEthernetCard()
+		if nic.MacAddress == "" {
+			nic.AddressType = string(types.VirtualEthernetCardMacTypeGenerated)
+			nic.MacAddress = fmt.Sprintf("00:50:56:%02x:%02x:%02x", rand.Intn(0x40), rand.Intn(0x100), rand.Intn(0x100))
+		}
+		if d.ControllerKey == 0 && findDevice(devices, 100) != -1 {
+			d.ControllerKey = 100 // PCI controller
+		}
+	case *types.VirtualDisk:
+		if x.CapacityInKB == 0 {
+			x.CapacityInKB = x.CapacityInBytes / 1024
+		} else if x.CapacityInBytes == 0 {
+			x.CapacityInBytes = x.CapacityInKB * 1024
+		}
+
+		if b, ok := x.Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+			vm.configureDiskFile(devices, b.GetVirtualDeviceFileBackingInfo())
+		}
+	}
+
+	if d.ControllerKey != 0 {
+		i := findDevice(devices, d.ControllerKey)
+		if i == -1 {
+			return &types.InvalidDeviceSpec{}
+		}
+
+		c, ok := devices[i].(types.BaseVirtualController)
+		if !ok {
+			return &types.InvalidDeviceSpec{}
+		}
+
+		if d.UnitNumber == nil || *d.UnitNumber < 0 {
+			unit := newUnitNumber(devices, c)
+			d.UnitNumber = &unit
+		}
+
+		controller := c.GetVirtualController()
+		controller.Device = append(controller.Device, d.Key)
+	}
+
+	return nil
+}
+
+// configureDiskFile generates a disk file name within the VM directory,
+// if the backing does not specify one or only specifies the datastore.
+func (vm *VirtualMachine) configureDiskFile(devices []types.BaseVirtualDevice, b *types.VirtualDeviceFileBackingInfo) {
+	ds, p, ok := parseDatastorePath(b.FileName)
+	if ok && p != "" {
+		return
+	}
+
+	vmds, vmx, _ := parseDatastorePath(vm.Config.Files.VmPathName)
+	if !ok {
+		ds = vmds
+	}
+
+	name := strings.TrimSuffix(path.Base(vmx), path.Ext(vmx))
+	if n := countDevices(devices, virtualDiskType); n != 0 {
+		name = fmt.Sprintf("%s_%d", name, n)
+	}
+
+	b.FileName = fmt.Sprintf("[%s] %s/%s.vmdk", ds, path.Dir(vmx), name)
+}
+
+// removeDevice removes the device at the given index and unlinks it from its controller
+func removeDevice(devices []types.BaseVirtualDevice, i int) []types.BaseVirtualDevice {
+	d := devices[i].GetVirtualDevice()
+
+	if j := findDevice(devices, d.ControllerKey); j != -1 {
+		if c, ok := devices[j].(types.BaseVirtualController); ok {
+			controller := c.GetVirtualController()
+
+			var keys []int32
+			for _, key := range controller.Device {
+				if key != d.Key {
+					keys = append(keys, key)
+				}
+			}
+			controller.Device = keys
+		}
+	}
+
+	return append(devices[:i], devices[i+1:]...)
+}
+
+// configureDevices applies the DeviceChange list of the given spec
+func (vm *VirtualMachine) configureDevices(spec *types.VirtualMachineConfigSpec) types.BaseMethodFault {
+	devices := vm.Config.Hardware.Device
+
+	// temporary (negative) keys used in the spec, mapped to the keys assigned here
+	keys := make(map[int32]int32)
+
+	for i, change := range spec.DeviceChange {
+		dspec := change.GetVirtualDeviceConfigSpec()
+		invalid := &types.InvalidDeviceSpec{DeviceIndex: int32(i)}
+
+		if dspec.Device == nil {
+			return invalid
+		}
+
+		device := dspec.Device.GetVirtualDevice()
+
+		switch dspec.Operation {
+		case types.VirtualDeviceConfigSpecOperationAdd:
+			key := device.Key
+
+			if key <= 0 || findDevice(devices, key) != -1 {
+				device.Key = newDeviceKey(devices, dspec.Device)
+				if key < 0 {
+					keys[key] = device.Key
+				}
+			}
+
+			if ckey, ok := keys[device.ControllerKey]; ok {
+				device.ControllerKey = ckey
+			}
+
+			if err := vm.configureDevice(devices, dspec.Device); err != nil {
+				return invalid
+			}
+
+			devices = append(devices, dspec.Device)
+		case types.VirtualDeviceConfigSpecOperationEdit:
+			j := findDevice(devices, device.Key)
+			if j == -1 {
+				return invalid
+			}
+
+			devices[j] = dspec.Device
+		case types.VirtualDeviceConfigSpecOperationRemove:
+			j := findDevice(devices, device.Key)
+			if j == -1 {
+				return invalid
+			}
+
+			devices = removeDevice(devices, j)
+		default:
+			return invalid
+		}
+	}
+
+	vm.Config.Hardware.Device = devices
+
+	vm.Summary.Config.NumVirtualDisks = countDevices(devices, virtualDiskType)
+	vm.Summary.Config.NumEthernetCards = countDevices(devices, virtualEthernetCardType)
+
+	return nil
+}
+
+// attach links the VM with its resource pool, host and datastore
+func (vm *VirtualMachine) attach(ctx *Context, pool *ResourcePool, host *HostSystem, ds *Datastore) {
+	vm.Summary.Vm = &vm.Self
+
+	vm.ResourcePool = &pool.Self
+	pool.Vm = AddReference(vm.Self, pool.Vm)
+
+	vm.Runtime.Host = &host.Self
+	vm.Summary.Runtime = vm.Runtime
+	host.Vm = AddReference(vm.Self, host.Vm)
+
+	vm.Datastore = []types.ManagedObjectReference{ds.Self}
+	ds.Vm = AddReference(vm.Self, ds.Vm)
+
+	vm.refreshNetworks(ctx)
+}
+
+// detach unlinks the VM from the objects linked by attach
+func (vm *VirtualMachine) detach(ctx *Context) {
+	if pool, ok := ctx.Map.Get(*vm.ResourcePool).(*ResourcePool); ok {
+		pool.Vm = RemoveReference(vm.Self, pool.Vm)
+	}
+
+	if host, ok := ctx.Map.Get(*vm.Runtime.Host).(*HostSystem); ok {
+		host.Vm = RemoveReference(vm.Self, host.Vm)
+	}
+
+	for _, ref := range vm.Datastore {
+		if ds, ok := ctx.Map.Get(ref).(*Datastore); ok {
+			ds.Vm = RemoveReference(vm.Self, ds.Vm)
+		}
+	}
+
+	for _, ref := range vm.Network {
+		if n, ok := ctx.Map.Get(ref).(*Network); ok {
+			n.Vm = RemoveReference(vm.Self, n.Vm)
+		}
+	}
+}
+
+// refreshNetworks updates the VM network list based on its ethernet card backings,
+// resolving the network reference of backings that only specify a network name.
+func (vm *VirtualMachine) refreshNetworks(ctx *Context) {
+	dc := ctx.Map.getEntityDatacenter(vm)
+
+	var refs []types.ManagedObjectReference
+
+	for _, device := range vm.Config.Hardware.Device {
+		nic, ok := device.(types.BaseVirtualEthernetCard)
+		if !ok {
+			continue
+		}
+
+		b, ok := nic.GetVirtualEthernetCard().Backing.(*types.VirtualEthernetCardNetworkBackingInfo)
+		if !ok {
+			continue
+		}
+
+		if b.Network == nil {
+			if n := ctx.Map.FindByName(b.DeviceName, dc.Network); n != nil {
+				ref := n.Reference()
+				b.Network = &ref
+			}
+		}
+
+		if b.Network != nil {
+			refs = AddReference(*b.Network, refs)
+		}
+	}
+
+	for _, ref := range vm.Network {
+		if n, ok := ctx.Map.Get(ref).(*Network); ok {
+			n.Vm = RemoveReference(vm.Self, n.Vm)
+		}
+	}
+
+	vm.Network = refs
+
+	for _, ref := range vm.Network {
+		if n, ok := ctx.Map.Get(ref).(*Network); ok {
+			n.Vm = AddReference(vm.Self, n.Vm)
+		}
+	}
+}
+
+func (vm *VirtualMachine) rename(name string) {
+	vm.Config.Name = name
+	vm.Summary.Config.Name = name
+}
+
+// setPowerState updates the runtime and guest properties for the given power state
+func (vm *VirtualMachine) setPowerState(state types.VirtualMachinePowerState) {
+	vm.Runtime.PowerState = state
+
+	switch state {
+	case types.VirtualMachinePowerStatePoweredOn:
+		now := time.Now()
+		vm.Runtime.BootTime = &now
+		vm.Runtime.SuspendTime = nil
+		vm.Guest.GuestState = "running"
+	case types.VirtualMachinePowerStatePoweredOff:
+		vm.Runtime.BootTime = nil
+		vm.Runtime.SuspendTime = nil
+		vm.Guest.GuestState = "notRunning"
+	case types.VirtualMachinePowerStateSuspended:
+		now := time.Now()
+		vm.Runtime.SuspendTime = &now
+		vm.Guest.GuestState = "notRunning"
+	}
+
+	vm.Summary.Runtime = vm.Runtime
+}
+
+// powerTask returns a Task that changes the VM power state to the given state,
+// failing with InvalidPowerState if the current state is not one of the allowed states.
+func (vm *VirtualMachine) powerTask(name string, state types.VirtualMachinePowerState, allowed ...types.VirtualMachinePowerState) *Task {
+	return CreateTask(vm, name, func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		current := vm.Runtime.PowerState
+
+		for _, s := range allowed {
+			if s == current {
+				vm.setPowerState(state)
+				return nil, nil
+			}
+		}
+
+		return nil, &types.InvalidPowerState{
+			RequestedState: state,
+			ExistingState:  current,
+		}
+	})
+}
+
+func (vm *VirtualMachine) PowerOnVMTask(ctx *Context, c *types.PowerOnVM_Task) soap.HasFault {
+	task := vm.powerTask("powerOn", types.VirtualMachinePowerStatePoweredOn,
+		types.VirtualMachinePowerStatePoweredOff, types.VirtualMachinePowerStateSuspended)
+
+	return &methods.PowerOnVM_TaskBody{
+		Res: &types.PowerOnVM_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func (vm *VirtualMachine) PowerOffVMTask(ctx *Context, c *types.PowerOffVM_Task) soap.HasFault {
+	task := vm.powerTask("powerOff", types.VirtualMachinePowerStatePoweredOff,
+		types.VirtualMachinePowerStatePoweredOn, types.VirtualMachinePowerStateSuspended)
+
+	return &methods.PowerOffVM_TaskBody{
+		Res: &types.PowerOffVM_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func (vm *VirtualMachine) SuspendVMTask(ctx *Context, c *types.SuspendVM_Task) soap.HasFault {
+	task := vm.powerTask("suspend", types.VirtualMachinePowerStateSuspended,
+		types.VirtualMachinePowerStatePoweredOn)
+
+	return &methods.SuspendVM_TaskBody{
+		Res: &types.SuspendVM_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func (vm *VirtualMachine) ResetVMTask(ctx *Context, c *types.ResetVM_Task) soap.HasFault {
+	task := vm.powerTask("reset", types.VirtualMachinePowerStatePoweredOn,
+		types.VirtualMachinePowerStatePoweredOn)
+
+	return &methods.ResetVM_TaskBody{
+		Res: &types.ResetVM_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func (vm *VirtualMachine) ReconfigVMTask(ctx *Context, req *types.ReconfigVM_Task) soap.HasFault {
+	task := CreateTask(vm, "reconfigVM", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if err := vm.configure(&req.Spec); err != nil {
+			return nil, err
+		}
+
+		vm.refreshNetworks(ctx)
+
+		return nil, nil
+	})
+
+	return &methods.ReconfigVM_TaskBody{
+		Res: &types.ReconfigVM_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func (vm *VirtualMachine) RenameTask(ctx *Context, r *types.Rename_Task) soap.HasFault {
+	return renameTask(ctx, vm, r)
+}
+
+// DestroyTask removes the VM from the inventory, the VM must be powered off
+func (vm *VirtualMachine) DestroyTask(ctx *Context, req *types.Destroy_Task) soap.HasFault {
+	task := CreateTask(vm, "destroy", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn {
+			return nil, &types.InvalidPowerState{
+				RequestedState: types.VirtualMachinePowerStatePoweredOff,
+				ExistingState:  vm.Runtime.PowerState,
+			}
+		}
+
+		vm.detach(ctx)
+
+		f := ctx.Map.Get(*vm.Parent).(*Folder)
+		f.removeChild(ctx.Map, vm.Self)
+
+		return nil, nil
+	})
+
+	return &methods.Destroy_TaskBody{
+		Res: &types.Destroy_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCreateVm(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, VPX())
+	defer s.Close()
+
+	finder := find.NewFinder(c.Client, false)
+
+	dc, err := finder.DefaultDatacenter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finder.SetDatacenter(dc)
+
+	folders, err := dc.Folders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := finder.ResourcePool(ctx, "DC0_C0/Resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := types.VirtualMachineConfigSpec{
+		Name:    "test-vm",
+		GuestId: string(types.VirtualMachineGuestOsIdentifierOtherGuest),
+		Files:   &types.VirtualMachineFileInfo{VmPathName: "[LocalDS_0]"},
+	}
+
+	// Missing datastore
+	spec.Files.VmPathName = "[enoent]"
+	task, err := folders.VmFolder.CreateVM(ctx, spec, pool, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = task.Wait(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+
+	spec.Files.VmPathName = "[LocalDS_0]"
+	task, err = folders.VmFolder.CreateVM(ctx, spec, pool, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := object.NewVirtualMachine(c.Client, info.Result.(types.ManagedObjectReference))
+
+	// Duplicate name
+	task, err = folders.VmFolder.CreateVM(ctx, spec, pool, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = task.Wait(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var props mo.VirtualMachine
+	err = vm.Properties(ctx, vm.Reference(), []string{"config.files", "resourcePool", "datastore"}, &props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if props.Config.Files.VmPathName != "[LocalDS_0] test-vm/test-vm.vmx" {
+		t.Errorf("vmPathName=%s", props.Config.Files.VmPathName)
+	}
+
+	if *props.ResourcePool != pool.Reference() {
+		t.Errorf("resourcePool=%s", props.ResourcePool)
+	}
+
+	if len(props.Datastore) != 1 {
+		t.Errorf("datastore=%s", props.Datastore)
+	}
+
+	ops := []struct {
+		op    func(context.Context) (*object.Task, error)
+		state types.VirtualMachinePowerState
+		fail  bool
+	}{
+		{vm.PowerOff, types.VirtualMachinePowerStatePoweredOff, true},
+		{vm.Suspend, types.VirtualMachinePowerStatePoweredOff, true},
+		{vm.PowerOn, types.VirtualMachinePowerStatePoweredOn, false},
+		{vm.PowerOn, types.VirtualMachinePowerStatePoweredOn, true},
+		{vm.Reset, types.VirtualMachinePowerStatePoweredOn, false},
+		{vm.Destroy, types.VirtualMachinePowerStatePoweredOn, true},
+		{vm.Suspend, types.VirtualMachinePowerStateSuspended, false},
+		{vm.PowerOff, types.VirtualMachinePowerStatePoweredOff, false},
+	}
+
+	for i, op := range ops {
+		task, err := op.op(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = task.Wait(ctx)
+		if op.fail != (err != nil) {
+			t.Errorf("%d: err=%v", i, err)
+		}
+
+		state, err := vm.PowerState(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if state != op.state {
+			t.Errorf("%d: state=%s", i, state)
+		}
+	}
+
+	task, err = vm.Destroy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = task.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = finder.VirtualMachine(ctx, spec.Name)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestReconfigVm(t *testing.T) {
+	ctx := context.Background()
+
+	c, s := newTestClient(ctx, t, ESX())
+	defer s.Close()
+
+	finder := find.NewFinder(c.Client, false)
+
+	dc, err := finder.DefaultDatacenter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finder.SetDatacenter(dc)
+
+	vm, err := finder.VirtualMachine(ctx, "ha-host_VM0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := vm.Device(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	if len(disks) != 1 {
+		t.Fatalf("disks=%d", len(disks))
+	}
+
+	if name := disks[0].(*types.VirtualDisk).Backing.(*types.VirtualDiskFlatVer2BackingInfo).FileName; name != "[LocalDS_0] ha-host_VM0/ha-host_VM0.vmdk" {
+		t.Errorf("disk=%s", name)
+	}
+
+	nics := devices.SelectByType((*types.VirtualEthernetCard)(nil))
+	if len(nics) != 1 {
+		t.Fatalf("nics=%d", len(nics))
+	}
+
+	controller, err := devices.FindSCSIController("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := finder.DefaultDatastore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disk := devices.CreateDisk(controller, ds.Reference(), "")
+	disk.CapacityInKB = 1024
+
+	err = vm.AddDevice(ctx, disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vm.RemoveDevice(ctx, false, nics...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err = vm.Device(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disks = devices.SelectByType((*types.VirtualDisk)(nil))
+	if len(disks) != 2 {
+		t.Fatalf("disks=%d", len(disks))
+	}
+
+	d := disks[1].GetVirtualDevice()
+	if d.Key != 2001 || *d.UnitNumber != 1 {
+		t.Errorf("key=%d unit=%d", d.Key, *d.UnitNumber)
+	}
+
+	if len(devices.SelectByType((*types.VirtualEthernetCard)(nil))) != 0 {
+		t.Error("nic not removed")
+	}
+
+	var props mo.VirtualMachine
+	err = vm.Properties(ctx, vm.Reference(), []string{"network", "summary.config"}, &props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(props.Network) != 0 {
+		t.Errorf("network=%s", props.Network)
+	}
+
+	if props.Summary.Config.NumVirtualDisks != 2 || props.Summary.Config.NumEthernetCards != 0 {
+		t.Errorf("summary=%#v", props.Summary.Config)
+	}
+
+	task, err := vm.Reconfigure(ctx, types.VirtualMachineConfigSpec{
+		DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationRemove,
+				Device:    &types.VirtualDisk{VirtualDevice: types.VirtualDevice{Key: 9999}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = task.Wait(ctx); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sts
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// c14nScope tracks the namespaces in scope and those rendered by output ancestors.
+type c14nScope struct {
+	declared map[string]string
+	rendered map[string]string
+}
+
+func (s *c14nScope) child() *c14nScope {
+	c := &c14nScope{
+		declared: make(map[string]string, len(s.declared)),
+		rendered: make(map[string]string, len(s.rendered)),
+	}
+
+	for k, v := range s.declared {
+		c.declared[k] = v
+	}
+	for k, v := range s.rendered {
+		c.rendered[k] = v
+	}
+
+	return c
+}
+
+type c14nAttr struct {
+	space string // namespace URI, used for sorting
+	name  string // qualified name
+	value string
+}
+
+// byNamespace sorts attributes by namespace URI and then local name
+type byNamespace []c14nAttr
+
+func (a byNamespace) Len() int      { return len(a) }
+func (a byNamespace) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byNamespace) Less(i, j int) bool {
+	if a[i].space != a[j].space {
+		return a[i].space < a[j].space
+	}
+	return a[i].name < a[j].name
+}
+
+// canonicalize returns the Exclusive XML Canonicalization (exc-c14n, without comments) of the given XML fragment.
+// The fragment must declare any namespace prefixes it uses, as is the case for the
+// SOAP Body and WS-Security elements signed by Signer.
+func canonicalize(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	stack := []*c14nScope{{
+		declared: map[string]string{"": ""},
+		rendered: map[string]string{"": ""},
+	}}
+
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			scope := stack[len(stack)-1].child()
+
+			var attrs []c14nAttr
+
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					scope.declared[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					scope.declared[""] = a.Value
+				default:
+					attrs = append(attrs, c14nAttr{name: qname(a.Name), value: a.Value, space: a.Name.Space})
+				}
+			}
+
+			// Namespaces visibly utilized by the element and its attributes
+			used := []string{t.Name.Space}
+			for i := range attrs {
+				if p := attrs[i].space; p != "" {
+					used = append(used, p)
+					attrs[i].space = scope.declared[p]
+				}
+			}
+
+			var decls []string
+			for _, p := range used {
+				if p == "xml" {
+					continue
+				}
+
+				uri := scope.declared[p]
+				if prev, ok := scope.rendered[p]; ok && prev == uri {
+					continue
+				}
+				scope.rendered[p] = uri
+				decls = append(decls, p)
+			}
+
+			sort.Strings(decls) // the default namespace ("") sorts first
+
+			sort.Sort(byNamespace(attrs))
+
+			buf.WriteString("<" + qname(t.Name))
+
+			for _, p := range decls {
+				name := "xmlns"
+				if p != "" {
+					name += ":" + p
+				}
+				buf.WriteString(" " + name + `="` + escapeAttr(scope.declared[p]) + `"`)
+			}
+
+			for _, a := range attrs {
+				buf.WriteString(" " + a.name + `="` + escapeAttr(a.value) + `"`)
+			}
+
+			buf.WriteString(">")
+
+			stack = append(stack, scope)
+		case xml.EndElement:
+			buf.WriteString("</" + qname(t.Name) + ">")
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 1 {
+				buf.WriteString(escapeText(string(t)))
+			}
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package sts implements the WS-Trust Issue and Renew exchange with the vCenter
+Security Token Service (STS), for use with SessionManager.LoginByToken.
+
+Bearer tokens are issued for a user name and password. Holder-of-key tokens are
+issued when a certificate is provided, such as a solution user certificate, in
+which case requests made with the token are signed using the certificate's
+private key.
+*/
+package sts
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/vmware/govmomi/sts/internal"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+const (
+	// Namespace of the STS requests
+	Namespace = internal.WSTrust
+
+	// Path of the STS endpoint. On vCenter 6.x the endpoint includes the SSO domain,
+	// for example "/sts/STSService/vsphere.local", see NewClientPath.
+	Path = "/sts/STSService"
+)
+
+// Client is a soap.Client targeting the STS endpoint.
+type Client struct {
+	*soap.Client
+}
+
+// NewClient returns a Client for the STS endpoint on the same host as the given vim25 Client.
+func NewClient(c *vim25.Client) *Client {
+	return NewClientPath(c, Path)
+}
+
+// NewClientPath returns a Client for the STS endpoint at the given path, on the same host as the given vim25 Client.
+func NewClientPath(c *vim25.Client, path string) *Client {
+	return &Client{c.Client.NewServiceClient(path, Namespace)}
+}
+
+// TokenRequest parameters for issuing or renewing a SAML token.
+// At least one of Userinfo or Certificate must be set.
+type TokenRequest struct {
+	Userinfo    *url.Userinfo    // Userinfo authenticates the request with a user name and password
+	Certificate *tls.Certificate // Certificate authenticates and signs the request, such as a solution user certificate
+	Lifetime    time.Duration    // Lifetime of the token, defaults to 10 minutes
+	Renewable   bool             // Renewable requests a token that can be renewed
+	Delegatable bool             // Delegatable requests a token that can be delegated
+	Token       string           // Token to renew, used by Renew only
+}
+
+func (r *TokenRequest) lifetime() *internal.Lifetime {
+	d := r.Lifetime
+	if d == 0 {
+		d = 10 * time.Minute
+	}
+
+	now := time.Now().UTC()
+
+	return &internal.Lifetime{
+		Created: now.Format(internal.Time),
+		Expires: now.Add(d).Format(internal.Time),
+	}
+}
+
+// Issue requests a SAML token from the STS.
+// A holder-of-key token is issued if req.Certificate is set, otherwise a bearer token.
+// The returned Signer can be used with session.Manager.LoginByToken.
+func (c *Client) Issue(ctx context.Context, req TokenRequest) (*Signer, error) {
+	if req.Userinfo == nil && req.Certificate == nil {
+		return nil, errors.New("sts: TokenRequest requires Userinfo or Certificate")
+	}
+
+	rst := internal.RequestSecurityToken{
+		TokenType:          internal.SAML,
+		RequestType:        internal.RequestTypeIssue,
+		Lifetime:           req.lifetime(),
+		Delegatable:        req.Delegatable,
+		KeyType:            internal.KeyTypeBearer,
+		SignatureAlgorithm: internal.RSASHA256,
+	}
+
+	if req.Renewable {
+		rst.Renewing = &internal.Renewing{Allow: true}
+	}
+
+	if req.Certificate != nil {
+		rst.KeyType = internal.KeyTypePublicKey
+	}
+
+	header := soap.Header{
+		Action: internal.ActionIssue,
+		Security: &Signer{
+			Certificate: req.Certificate,
+			user:        req.Userinfo,
+		},
+	}
+
+	res, err := internal.Issue(soap.WithHeader(ctx, header), c, &rst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{
+		Token:       res.RequestSecurityTokenResponse.RequestedSecurityToken.Token,
+		Certificate: req.Certificate,
+	}, nil
+}
+
+// Renew requests a new lifetime for the given req.Token, which must have been issued as renewable.
+// The request is signed using req.Certificate, which is required.
+func (c *Client) Renew(ctx context.Context, req TokenRequest) (string, error) {
+	if req.Certificate == nil || req.Token == "" {
+		return "", errors.New("sts: Renew requires Token and Certificate")
+	}
+
+	rst := internal.RequestSecurityToken{
+		TokenType:          internal.SAML,
+		RequestType:        internal.RequestTypeRenew,
+		Lifetime:           req.lifetime(),
+		RenewTarget:        &internal.RenewTarget{Token: req.Token},
+		SignatureAlgorithm: internal.RSASHA256,
+	}
+
+	header := soap.Header{
+		Action:   internal.ActionRenew,
+		Security: &Signer{Certificate: req.Certificate},
+	}
+
+	res, err := internal.Renew(soap.WithHeader(ctx, header), c, &rst)
+	if err != nil {
+		return "", err
+	}
+
+	return res.RequestedSecurityToken.Token, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// solutionUser returns a self-signed certificate for the given common name
+func solutionUser(t *testing.T, name string) *tls.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
+
+// loginByToken logs in a new client of s using the signer and returns the session user name.
+func loginByToken(t *testing.T, s *simulator.Server, signer *Signer) string {
+	ctx := context.Background()
+
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := session.NewManager(c)
+
+	header := soap.Header{Security: signer}
+	if err = m.LoginByToken(soap.WithHeader(ctx, header)); err != nil {
+		t.Fatal(err)
+	}
+
+	us, err := m.UserSession(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return us.UserName
+}
+
+func TestIssueBearer(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.ESX()
+	if err := m.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sts := NewClient(c)
+
+	_, err = sts.Issue(ctx, TokenRequest{Userinfo: url.UserPassword("user", "")})
+	if err == nil {
+		t.Error("expected error")
+	}
+
+	signer, err := sts.Issue(ctx, TokenRequest{Userinfo: url.UserPassword("user@vsphere.local", "pass")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := loginByToken(t, s, signer); name != "user@vsphere.local" {
+		t.Errorf("user=%s", name)
+	}
+
+	// LoginByToken without a token
+	err = session.NewManager(c).LoginByToken(ctx)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestIssueHolderOfKey(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+	if err := m.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sts := NewClient(c)
+	cert := solutionUser(t, "solution-user")
+
+	signer, err := sts.Issue(ctx, TokenRequest{Certificate: cert, Renewable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signer.Certificate != cert {
+		t.Error("expected holder-of-key certificate")
+	}
+
+	if name := loginByToken(t, s, signer); name != "solution-user" {
+		t.Errorf("user=%s", name)
+	}
+
+	// A holder-of-key token must be used to sign the request
+	bearer := &Signer{Token: signer.Token}
+	header := soap.Header{Security: bearer}
+	err = session.NewManager(c).LoginByToken(soap.WithHeader(ctx, header))
+	if err == nil {
+		t.Error("expected error")
+	}
+
+	token, err := sts.Renew(ctx, TokenRequest{Certificate: cert, Token: signer.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token == "" || token == signer.Token {
+		t.Error("expected renewed token")
+	}
+
+	renewed := &Signer{Token: token, Certificate: cert}
+	if name := loginByToken(t, s, renewed); name != "solution-user" {
+		t.Errorf("user=%s", name)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package internal contains the WS-Trust types used by the sts package.
+package internal
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Namespaces and URIs used by WS-Trust, WS-Security and XML Signature
+const (
+	WSTrust   = "http://docs.oasis-open.org/ws-sx/ws-trust/200512"
+	WSSE      = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
+	WSSE11    = "http://docs.oasis-open.org/wss/oasis-wss-wssecurity-secext-1.1.xsd"
+	WSU       = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+	DSig      = "http://www.w3.org/2000/09/xmldsig#"
+	SAML      = "urn:oasis:names:tc:SAML:2.0:assertion"
+	ExcC14N   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	RSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	SHA256    = "http://www.w3.org/2001/04/xmlenc#sha256"
+
+	Base64Binary = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+	X509v3       = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-x509-token-profile-1.0#X509v3"
+	SAMLTokenV2  = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLV2.0"
+	SAMLID       = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLID"
+
+	RequestTypeIssue = WSTrust + "/Issue"
+	RequestTypeRenew = WSTrust + "/Renew"
+	ActionIssue      = WSTrust + "/RST/Issue"
+	ActionRenew      = WSTrust + "/RST/Renew"
+	KeyTypeBearer    = WSTrust + "/Bearer"
+	KeyTypePublicKey = WSTrust + "/PublicKey"
+)
+
+// Time format used by Lifetime and wsu:Timestamp
+const Time = "2006-01-02T15:04:05.000Z"
+
+type Lifetime struct {
+	Created string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Created"`
+	Expires string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Expires"`
+}
+
+type Renewing struct {
+	Allow bool `xml:",attr"`
+	OK    bool `xml:",attr"`
+}
+
+type RenewTarget struct {
+	Token string `xml:",innerxml"`
+}
+
+type RequestSecurityToken struct {
+	TokenType          string       `xml:"TokenType,omitempty"`
+	RequestType        string       `xml:"RequestType"`
+	Lifetime           *Lifetime    `xml:"Lifetime,omitempty"`
+	RenewTarget        *RenewTarget `xml:"RenewTarget,omitempty"`
+	Renewing           *Renewing    `xml:"Renewing,omitempty"`
+	Delegatable        bool         `xml:"Delegatable,omitempty"`
+	KeyType            string       `xml:"KeyType,omitempty"`
+	SignatureAlgorithm string       `xml:"SignatureAlgorithm,omitempty"`
+}
+
+type RequestedSecurityToken struct {
+	Token string `xml:",innerxml"`
+}
+
+type RequestSecurityTokenResponse struct {
+	TokenType              string                 `xml:"TokenType"`
+	Lifetime               *Lifetime              `xml:"Lifetime"`
+	RequestedSecurityToken RequestedSecurityToken `xml:"RequestedSecurityToken"`
+	Renewing               *Renewing              `xml:"Renewing"`
+	Delegatable            bool                   `xml:"Delegatable"`
+	KeyType                string                 `xml:"KeyType"`
+}
+
+type RequestSecurityTokenResponseCollection struct {
+	RequestSecurityTokenResponse RequestSecurityTokenResponse `xml:"RequestSecurityTokenResponse"`
+}
+
+type IssueBody struct {
+	Req    *RequestSecurityToken                   `xml:"http://docs.oasis-open.org/ws-sx/ws-trust/200512 RequestSecurityToken,omitempty"`
+	Res    *RequestSecurityTokenResponseCollection `xml:"http://docs.oasis-open.org/ws-sx/ws-trust/200512 RequestSecurityTokenResponseCollection,omitempty"`
+	Fault_ *soap.Fault                             `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *IssueBody) Fault() *soap.Fault { return b.Fault_ }
+
+func Issue(ctx context.Context, r soap.RoundTripper, req *RequestSecurityToken) (*RequestSecurityTokenResponseCollection, error) {
+	var reqBody, resBody IssueBody
+
+	reqBody.Req = req
+
+	if err := r.RoundTrip(ctx, &reqBody, &resBody); err != nil {
+		return nil, err
+	}
+
+	return resBody.Res, nil
+}
+
+type RenewBody struct {
+	Req    *RequestSecurityToken         `xml:"http://docs.oasis-open.org/ws-sx/ws-trust/200512 RequestSecurityToken,omitempty"`
+	Res    *RequestSecurityTokenResponse `xml:"http://docs.oasis-open.org/ws-sx/ws-trust/200512 RequestSecurityTokenResponse,omitempty"`
+	Fault_ *soap.Fault                   `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *RenewBody) Fault() *soap.Fault { return b.Fault_ }
+
+func Renew(ctx context.Context, r soap.RoundTripper, req *RequestSecurityToken) (*RequestSecurityTokenResponse, error) {
+	var reqBody, resBody RenewBody
+
+	reqBody.Req = req
+
+	if err := r.RoundTrip(ctx, &reqBody, &resBody); err != nil {
+		return nil, err
+	}
+
+	return resBody.Res, nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sts
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/sts/internal"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+const envelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+
+// Signer implements the soap.Signer interface, adding a WS-Security header to requests.
+// A Signer is returned by Client.Issue and used as the soap.Header Security field
+// for session.Manager.LoginByToken, for example:
+//
+//	header := soap.Header{Security: signer}
+//	err = session.NewManager(c).LoginByToken(soap.WithHeader(ctx, header))
+type Signer struct {
+	Token       string           // Token is a SAML token, as returned by Client.Issue
+	Certificate *tls.Certificate // Certificate signs requests, required for holder-of-key tokens
+
+	user *url.Userinfo // user is sent as a UsernameToken by Client.Issue
+}
+
+// newID returns a random ID for use as a wsu:Id attribute value
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("_%x", b)
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// tokenID returns the ID attribute of the SAML assertion
+func (s *Signer) tokenID() (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(s.Token))
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("invalid SAML token: %s", err)
+		}
+
+		if t, ok := tok.(xml.StartElement); ok {
+			for _, a := range t.Attr {
+				if a.Name.Local == "ID" {
+					return a.Value, nil
+				}
+			}
+
+			return "", errors.New("invalid SAML token: no ID attribute")
+		}
+	}
+}
+
+// reference returns a ds:Reference to the canonical form of the given element
+func reference(id string, element []byte) (string, error) {
+	c, err := canonicalize(element)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(c)
+
+	return fmt.Sprintf(`<ds:Reference URI="#%s"><ds:Transforms><ds:Transform Algorithm="%s"></ds:Transform></ds:Transforms>`+
+		`<ds:DigestMethod Algorithm="%s"></ds:DigestMethod><ds:DigestValue>%s</ds:DigestValue></ds:Reference>`,
+		id, internal.ExcC14N, internal.SHA256, base64.StdEncoding.EncodeToString(sum[:])), nil
+}
+
+// signature returns a ds:Signature over the given body and timestamp elements, using the Signer's Certificate.
+func (s *Signer) signature(bodyID string, body []byte, tsID string, ts []byte, keyInfo string) (string, error) {
+	key, ok := s.Certificate.PrivateKey.(crypto.Signer)
+	if !ok {
+		return "", errors.New("sts: certificate private key does not implement crypto.Signer")
+	}
+
+	var refs []string
+
+	for _, r := range []struct {
+		id      string
+		element []byte
+	}{{bodyID, body}, {tsID, ts}} {
+		ref, err := reference(r.id, r.element)
+		if err != nil {
+			return "", err
+		}
+		refs = append(refs, ref)
+	}
+
+	info := fmt.Sprintf(`<ds:SignedInfo xmlns:ds="%s"><ds:CanonicalizationMethod Algorithm="%s"></ds:CanonicalizationMethod>`+
+		`<ds:SignatureMethod Algorithm="%s"></ds:SignatureMethod>%s</ds:SignedInfo>`,
+		internal.DSig, internal.ExcC14N, internal.RSASHA256, strings.Join(refs, ""))
+
+	c, err := canonicalize([]byte(info))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(c)
+
+	sig, err := key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`<ds:Signature xmlns:ds="%s">%s<ds:SignatureValue>%s</ds:SignatureValue><ds:KeyInfo>%s</ds:KeyInfo></ds:Signature>`,
+		internal.DSig, info, base64.StdEncoding.EncodeToString(sig), keyInfo), nil
+}
+
+// Sign implements the soap.Signer interface.
+// The request includes a wsu:Timestamp, along with a UsernameToken for Issue requests with a password,
+// and the SAML token if any. If Certificate is set, the Body and Timestamp are signed,
+// referencing the certificate via a BinarySecurityToken or, once issued, the holder-of-key SAML token.
+func (s *Signer) Sign(env soap.Envelope) ([]byte, error) {
+	var body bytes.Buffer
+
+	bodyID := newID()
+	start := xml.StartElement{
+		Name: xml.Name{Local: "soap:Body"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns:soap"}, Value: envelopeNamespace},
+			{Name: xml.Name{Local: "xmlns:wsu"}, Value: internal.WSU},
+			{Name: xml.Name{Local: "wsu:Id"}, Value: bodyID},
+		},
+	}
+
+	if err := xml.NewEncoder(&body).EncodeElement(env.Body, start); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	tsID := newID()
+	ts := fmt.Sprintf(`<wsu:Timestamp xmlns:wsu="%s" wsu:Id="%s"><wsu:Created>%s</wsu:Created><wsu:Expires>%s</wsu:Expires></wsu:Timestamp>`,
+		internal.WSU, tsID, now.Format(internal.Time), now.Add(5*time.Minute).Format(internal.Time))
+
+	var security bytes.Buffer
+
+	fmt.Fprintf(&security, `<wsse:Security xmlns:wsse="%s" xmlns:wsu="%s">`, internal.WSSE, internal.WSU)
+	security.WriteString(ts)
+
+	if s.user != nil {
+		password, _ := s.user.Password()
+		fmt.Fprintf(&security, `<wsse:UsernameToken><wsse:Username>%s</wsse:Username><wsse:Password>%s</wsse:Password></wsse:UsernameToken>`,
+			escape(s.user.Username()), escape(password))
+	}
+
+	if s.Certificate != nil {
+		var keyInfo string
+
+		if s.Token == "" {
+			if len(s.Certificate.Certificate) == 0 {
+				return nil, errors.New("sts: certificate is empty")
+			}
+
+			bstID := newID()
+			fmt.Fprintf(&security, `<wsse:BinarySecurityToken EncodingType="%s" ValueType="%s" wsu:Id="%s">%s</wsse:BinarySecurityToken>`,
+				internal.Base64Binary, internal.X509v3, bstID, base64.StdEncoding.EncodeToString(s.Certificate.Certificate[0]))
+
+			keyInfo = fmt.Sprintf(`<wsse:SecurityTokenReference><wsse:Reference URI="#%s" ValueType="%s"></wsse:Reference></wsse:SecurityTokenReference>`,
+				bstID, internal.X509v3)
+		} else {
+			id, err := s.tokenID()
+			if err != nil {
+				return nil, err
+			}
+
+			security.WriteString(s.Token)
+
+			keyInfo = fmt.Sprintf(`<wsse:SecurityTokenReference xmlns:wsse11="%s" wsse11:TokenType="%s">`+
+				`<wsse:KeyIdentifier ValueType="%s">%s</wsse:KeyIdentifier></wsse:SecurityTokenReference>`,
+				internal.WSSE11, internal.SAMLTokenV2, internal.SAMLID, escape(id))
+		}
+
+		sig, err := s.signature(bodyID, body.Bytes(), tsID, []byte(ts), keyInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		security.WriteString(sig)
+	} else if s.Token != "" {
+		security.WriteString(s.Token)
+	}
+
+	security.WriteString(`</wsse:Security>`)
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<soap:Envelope xmlns:soap="%s"><soap:Header>`, envelopeNamespace)
+	buf.Write(security.Bytes())
+	if env.Header != nil && env.Header.ID != "" {
+		fmt.Fprintf(&buf, `<operationID>%s</operationID>`, escape(env.Header.ID))
+	}
+	buf.WriteString(`</soap:Header>`)
+	buf.Write(body.Bytes())
+	buf.WriteString(`</soap:Envelope>`)
+
+	return buf.Bytes(), nil
+}
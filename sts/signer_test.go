@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sts
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{
+			`<a:x xmlns:b="urn:b" xmlns:a="urn:a" z="1" b:y="2" a="3"/>`,
+			`<a:x xmlns:a="urn:a" xmlns:b="urn:b" a="3" z="1" b:y="2"></a:x>`,
+		},
+		{
+			`<x xmlns="urn:x" xmlns:unused="urn:u"><y xmlns="urn:x">a &amp; "b" &#xD;</y></x>`,
+			`<x xmlns="urn:x"><y>a &amp; "b" &#xD;</y></x>`,
+		},
+		{
+			`<p:x xmlns:p="urn:p"><p:y attr="a&quot;b&#x9;"><z></z></p:y></p:x>`,
+			`<p:x xmlns:p="urn:p"><p:y attr="a&quot;b&#x9;"><z></z></p:y></p:x>`,
+		},
+	}
+
+	for _, test := range tests {
+		out, err := canonicalize([]byte(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(out) != test.out {
+			t.Errorf("expected %s, got %s", test.out, out)
+		}
+	}
+}
+
+var (
+	elementID   = regexp.MustCompile(`wsu:Id="([^"]+)"`)
+	digestValue = regexp.MustCompile(`<ds:Reference URI="#([^"]+)">.*?<ds:DigestValue>([^<]+)</ds:DigestValue>`)
+)
+
+// element returns the element starting with the given tag
+func element(t *testing.T, doc string, tag string) string {
+	start := strings.Index(doc, "<"+tag)
+	end := strings.Index(doc, "</"+tag+">")
+	if start < 0 || end < 0 {
+		t.Fatalf("%s not found", tag)
+	}
+	return doc[start : end+len(tag)+3]
+}
+
+func TestSignerSign(t *testing.T) {
+	cert := solutionUser(t, "solution-user")
+	signer := &Signer{Certificate: cert}
+
+	env := soap.Envelope{
+		Header: &soap.Header{ID: "op-1"},
+		Body: &methods.LoginByTokenBody{
+			Req: &types.LoginByToken{
+				This:   types.ManagedObjectReference{Type: "SessionManager", Value: "SessionManager"},
+				Locale: "en_US",
+			},
+		},
+	}
+
+	b, err := signer.Sign(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signed envelope must be well-formed
+	if err = xml.Unmarshal(b, new(struct{})); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := string(b)
+
+	elements := make(map[string]string)
+	for _, tag := range []string{"soap:Body", "wsu:Timestamp"} {
+		e := element(t, doc, tag)
+		id := elementID.FindStringSubmatch(e)
+		if id == nil {
+			t.Fatalf("%s has no wsu:Id", tag)
+		}
+		elements[id[1]] = e
+	}
+
+	refs := digestValue.FindAllStringSubmatch(doc, -1)
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 references, got %d", len(refs))
+	}
+
+	for _, ref := range refs {
+		e, ok := elements[ref[1]]
+		if !ok {
+			t.Fatalf("reference %s not found", ref[1])
+		}
+
+		c, err := canonicalize([]byte(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum := sha256.Sum256(c)
+		if base64.StdEncoding.EncodeToString(sum[:]) != ref[2] {
+			t.Errorf("digest mismatch for %s", ref[1])
+		}
+	}
+
+	info, err := canonicalize([]byte(element(t, doc, "ds:SignedInfo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := element(t, doc, "ds:SignatureValue")
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "<ds:SignatureValue>"), "</ds:SignatureValue>")
+	sig, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bst := element(t, doc, "wsse:BinarySecurityToken")
+	bst = bst[strings.Index(bst, ">")+1 : strings.LastIndex(bst, "<")]
+	der, err := base64.StdEncoding.DecodeString(bst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(info)
+	err = rsa.VerifyPKCS1v15(x.PublicKey.(*rsa.PublicKey), crypto.SHA256, sum[:], sig)
+	if err != nil {
+		t.Errorf("signature: %s", err)
+	}
+
+	if !bytes.Contains(b, []byte("<operationID>op-1</operationID>")) {
+		t.Error("missing operationID")
+	}
+}
@@ -16,17 +16,35 @@ limitations under the License.
 
 package task
 
-import "github.com/vmware/govmomi/vim25/types"
+import (
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
 
 type Error struct {
 	*types.LocalizedMethodFault
 }
 
-// Error returns the task's localized fault message.
+// Error returns the task's localized fault message, including any fault messages.
 func (e Error) Error() string {
-	return e.LocalizedMethodFault.LocalizedMessage
+	msg := soap.FaultMessage(e.LocalizedMethodFault.LocalizedMessage, e.Fault())
+	if msg == "" && e.Fault() != nil {
+		return soap.WrapVimFault(e.Fault()).Error()
+	}
+
+	return msg
 }
 
 func (e Error) Fault() types.BaseMethodFault {
 	return e.LocalizedMethodFault.Fault
 }
+
+// Unwrap returns the task's fault as a vim fault error, such that errors.Is
+// can be used with the soap package fault categories, for example soap.ErrNotFound.
+func (e Error) Unwrap() error {
+	if e.Fault() == nil {
+		return nil
+	}
+
+	return soap.WrapVimFault(e.Fault())
+}
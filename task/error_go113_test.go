@@ -0,0 +1,52 @@
+//go:build go1.13
+// +build go1.13
+
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestErrorIs(t *testing.T) {
+	fault := &types.FileNotFound{}
+
+	var err error = Error{&types.LocalizedMethodFault{Fault: fault}}
+
+	if !errors.Is(err, soap.ErrNotFound) {
+		t.Error("expected ErrNotFound")
+	}
+
+	if errors.Is(err, soap.ErrAlreadyExists) {
+		t.Error("unexpected ErrAlreadyExists")
+	}
+
+	var fe soap.FaultError
+	if !errors.As(err, &fe) || fe.Fault() != fault {
+		t.Errorf("errors.As(FaultError)=%#v", fe)
+	}
+
+	err = Error{&types.LocalizedMethodFault{Fault: &types.InvalidPowerState{}}}
+	if !errors.Is(err, soap.ErrInvalidState) {
+		t.Errorf("err=%s", err)
+	}
+}
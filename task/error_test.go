@@ -0,0 +1,44 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package task
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestError(t *testing.T) {
+	fault := &types.FileNotFound{}
+	fault.File = "[datastore1] vm-001/vm-001.vmx"
+	fault.FaultMessage = []types.LocalizableMessage{{Key: "msg.file", Message: "Unable to access the file."}}
+
+	var err error = Error{&types.LocalizedMethodFault{
+		Fault:            fault,
+		LocalizedMessage: "File [datastore1] vm-001/vm-001.vmx was not found",
+	}}
+
+	expect := "File [datastore1] vm-001/vm-001.vmx was not found; Unable to access the file."
+	if err.Error() != expect {
+		t.Errorf("expected %q, got %q", expect, err.Error())
+	}
+
+	err = Error{&types.LocalizedMethodFault{Fault: &types.InvalidPowerState{}}}
+	if err.Error() != "InvalidPowerState" {
+		t.Errorf("err=%s", err)
+	}
+}
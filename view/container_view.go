@@ -0,0 +1,35 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package view
+
+import (
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ContainerView contains the objects of the given managed object types within a container,
+// such as a Folder, Datacenter or ComputeResource, updated as objects are added or removed.
+type ContainerView struct {
+	ManagedObjectView
+}
+
+// NewContainerView returns the ContainerView for ref, as created by Manager.CreateContainerView.
+func NewContainerView(c *vim25.Client, ref types.ManagedObjectReference) *ContainerView {
+	return &ContainerView{
+		ManagedObjectView: *NewManagedObjectView(c, ref),
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package view
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+func TestContainerView(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		v, err := NewManager(c).CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var vms []mo.VirtualMachine
+
+		err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name", "runtime.powerState"}, &vms)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(vms) == 0 {
+			t.Fatal("no VMs")
+		}
+
+		for _, vm := range vms {
+			if vm.Name == "" || vm.Runtime.PowerState == "" || vm.Config != nil {
+				t.Errorf("vm=%#v", vm)
+			}
+		}
+
+		var all []interface{}
+
+		err = v.Retrieve(ctx, nil, []string{"name"}, &all)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(all) != len(vms) {
+			t.Errorf("%d entities, expected %d", len(all), len(vms))
+		}
+
+		err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"nope"}, &vms)
+		if err == nil {
+			t.Error("expected error")
+		}
+
+		refs, err := v.Find(ctx, "VirtualMachine", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(refs) != len(vms) {
+			t.Errorf("found %d VMs, expected %d", len(refs), len(vms))
+		}
+
+		refs, err = v.Find(ctx, "VirtualMachine", `name == "`+vms[0].Name+`"`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(refs) != 1 || refs[0] != vms[0].Self {
+			t.Errorf("refs=%v", refs)
+		}
+
+		if _, err = v.Find(ctx, "VirtualMachine", "nope"); err == nil {
+			t.Error("expected error")
+		}
+
+		if err = v.Destroy(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = v.Find(ctx, "VirtualMachine", ""); err == nil {
+			t.Error("expected error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
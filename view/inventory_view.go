@@ -0,0 +1,71 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package view
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// InventoryView contains the root folder and the child entities of each expanded entity,
+// such that a client can browse the inventory tree incrementally.  Combined with Wait, a client
+// is sent updates only for the entities in the view, that is, those of the expanded entities.
+type InventoryView struct {
+	ManagedObjectView
+}
+
+// NewInventoryView returns the InventoryView for ref, as created by Manager.CreateInventoryView.
+func NewInventoryView(c *vim25.Client, ref types.ManagedObjectReference) *InventoryView {
+	return &InventoryView{
+		ManagedObjectView: *NewManagedObjectView(c, ref),
+	}
+}
+
+// OpenFolder expands each entity, adding its child entities to the view.
+// The entities that could not be resolved are returned.
+func (v InventoryView) OpenFolder(ctx context.Context, entity []types.ManagedObjectReference) ([]types.ManagedObjectReference, error) {
+	req := types.OpenInventoryViewFolder{
+		This:   v.Reference(),
+		Entity: entity,
+	}
+
+	res, err := methods.OpenInventoryViewFolder(ctx, v.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Returnval, nil
+}
+
+// CloseFolder collapses each entity, removing its child entities from the view.
+// The entities that could not be resolved are returned.
+func (v InventoryView) CloseFolder(ctx context.Context, entity []types.ManagedObjectReference) ([]types.ManagedObjectReference, error) {
+	req := types.CloseInventoryViewFolder{
+		This:   v.Reference(),
+		Entity: entity,
+	}
+
+	res, err := methods.CloseInventoryViewFolder(ctx, v.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Returnval, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package view
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestInventoryView(t *testing.T) {
+	err := simulator.VPX().Run(func(ctx context.Context, c *vim25.Client) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		v, err := NewManager(c).CreateInventoryView(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer v.Destroy(context.Background())
+
+		root := c.ServiceContent.RootFolder
+
+		updates := make(chan types.ObjectUpdate, 100)
+		done := make(chan error, 1)
+
+		wctx, wcancel := context.WithCancel(ctx)
+		go func() {
+			done <- v.Wait(wctx, nil, []string{"name"}, func(u []types.ObjectUpdate) bool {
+				for _, update := range u {
+					updates <- update
+				}
+				return false
+			})
+		}()
+
+		// wait for n updates of the given kind, failing on any other kind
+		wait := func(n int, kind types.ObjectUpdateKind) []types.ManagedObjectReference {
+			var refs []types.ManagedObjectReference
+
+			for len(refs) < n {
+				select {
+				case u := <-updates:
+					if u.Kind != kind {
+						t.Fatalf("update=%#v", u)
+					}
+					refs = append(refs, u.Obj)
+				case err := <-done:
+					t.Fatalf("Wait returned: %v", err)
+				case <-ctx.Done():
+					t.Fatal(ctx.Err())
+				}
+			}
+
+			return refs
+		}
+
+		if refs := wait(1, types.ObjectUpdateKindEnter); refs[0] != root {
+			t.Fatalf("refs=%s", refs)
+		}
+
+		unresolved, err := v.OpenFolder(ctx, []types.ManagedObjectReference{root})
+		if err != nil || len(unresolved) != 0 {
+			t.Fatalf("unresolved=%s, err=%v", unresolved, err)
+		}
+
+		dc := wait(1, types.ObjectUpdateKindEnter)[0]
+		if dc.Type != "Datacenter" {
+			t.Fatalf("dc=%s", dc)
+		}
+
+		_, err = v.OpenFolder(ctx, []types.ManagedObjectReference{dc})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		folders := wait(4, types.ObjectUpdateKindEnter)
+
+		var objs []interface{}
+		err = v.Retrieve(ctx, nil, []string{"name"}, &objs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(objs) != 6 {
+			t.Errorf("%d objects in view", len(objs))
+		}
+
+		// open the vm folder, and rename one of its VMs
+		refs, err := v.Find(ctx, "Folder", `name == "vm"`)
+		if err != nil || len(refs) != 1 {
+			t.Fatalf("refs=%s, err=%v", refs, err)
+		}
+
+		_, err = v.OpenFolder(ctx, refs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vms := wait(1, types.ObjectUpdateKindEnter)
+
+		_, err = methods.Rename_Task(ctx, c, &types.Rename_Task{This: vms[0], NewName: "renamed"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the remaining VMs may enter before the rename is reported
+		for {
+			select {
+			case u := <-updates:
+				switch u.Kind {
+				case types.ObjectUpdateKindEnter:
+					vms = append(vms, u.Obj)
+					continue
+				case types.ObjectUpdateKindModify:
+					if u.Obj != vms[0] || u.ChangeSet[0].Val != "renamed" {
+						t.Errorf("update=%#v", u)
+					}
+				default:
+					t.Fatalf("update=%#v", u)
+				}
+			case <-ctx.Done():
+				t.Fatal(ctx.Err())
+			}
+			break
+		}
+
+		// closing the root folder removes everything else from the view
+		unresolved, err = v.CloseFolder(ctx, []types.ManagedObjectReference{root})
+		if err != nil || len(unresolved) != 0 {
+			t.Fatalf("unresolved=%s, err=%v", unresolved, err)
+		}
+
+		wait(1+len(folders)+len(vms), types.ObjectUpdateKindLeave)
+
+		wcancel()
+
+		if err = <-done; err == nil {
+			t.Error("expected error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
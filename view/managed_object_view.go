@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package view
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ManagedObjectView implements the methods common to the ContainerView and InventoryView types.
+type ManagedObjectView struct {
+	object.Common
+}
+
+// NewManagedObjectView returns the ManagedObjectView for ref, a view such as a ContainerView.
+func NewManagedObjectView(c *vim25.Client, ref types.ManagedObjectReference) *ManagedObjectView {
+	return &ManagedObjectView{
+		Common: object.NewCommon(c, ref),
+	}
+}
+
+// Destroy destroys the view, such that its objects are no longer tracked by the server.
+func (v ManagedObjectView) Destroy(ctx context.Context) error {
+	req := types.DestroyView{
+		This: v.Reference(),
+	}
+	_, err := methods.DestroyView(ctx, v.Client(), &req)
+	return err
+}
+
+// filterSpec selects the properties ps of the objects within the view of the given managed object types.
+// If kind is empty, the ManagedEntity properties of all objects in the view are selected.
+func (v ManagedObjectView) filterSpec(kind []string, ps []string) (types.PropertyFilterSpec, error) {
+	if len(kind) == 0 {
+		kind = []string{"ManagedEntity"}
+	}
+
+	filter := property.NewFilterBuilder(v.Reference()).Skip().Traverse("view")
+
+	for _, k := range kind {
+		filter.Properties(k, ps...)
+	}
+
+	return filter.Build()
+}
+
+// retrieve returns the properties ps of the objects within the view of the given managed object types.
+func (v ManagedObjectView) retrieve(ctx context.Context, kind []string, ps []string) ([]types.ObjectContent, error) {
+	spec, err := v.filterSpec(kind, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	return property.DefaultCollector(v.Client()).RetrieveAll(ctx, []types.PropertyFilterSpec{spec})
+}
+
+// Retrieve populates dst as property.Collector.Retrieve does, for all objects within the view
+// of the managed object types specified by kind.  All properties are retrieved if ps is empty.
+func (v ManagedObjectView) Retrieve(ctx context.Context, kind []string, ps []string, dst interface{}) error {
+	objects, err := v.retrieve(ctx, kind, ps)
+	if err != nil {
+		return err
+	}
+
+	res := types.RetrievePropertiesResponse{Returnval: objects}
+
+	return mo.LoadRetrievePropertiesResponse(&res, dst)
+}
+
+// Find returns the references of the objects within the view of type kind that match the
+// property.Query expression where, for example `name =~ "^web-"`.  Only the properties
+// referenced by the expression are retrieved.
+func (v ManagedObjectView) Find(ctx context.Context, kind string, where string) ([]types.ManagedObjectReference, error) {
+	q, err := property.NewQuery(kind, where)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := q.FilterSpec(v.Reference())
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := property.DefaultCollector(v.Client()).RetrieveAll(ctx, []types.PropertyFilterSpec{spec})
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := q.Filter(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []types.ManagedObjectReference
+
+	for _, obj := range objs {
+		refs = append(refs, obj.(mo.Reference).Reference())
+	}
+
+	return refs, nil
+}
+
+// Wait creates a property collector filter on the view and calls f with the updates of properties ps,
+// for objects within the view of the managed object types specified by kind, until f returns true.
+// The first call reports each object in the view as entering.  Objects added to or removed from the
+// view, such as by InventoryView.OpenFolder and CloseFolder, are reported as entering or leaving.
+// All properties are collected if ps is empty.
+func (v ManagedObjectView) Wait(ctx context.Context, kind []string, ps []string, f func([]types.ObjectUpdate) bool) error {
+	spec, err := v.filterSpec(kind, ps)
+	if err != nil {
+		return err
+	}
+
+	pc, err := property.DefaultCollector(v.Client()).Create(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Destroy the collector using the background context, as ctx may be done.
+	defer pc.Destroy(context.Background())
+
+	err = pc.CreateFilter(ctx, types.CreateFilter{Spec: spec})
+	if err != nil {
+		return err
+	}
+
+	for version := ""; ; {
+		set, err := pc.WaitForUpdatesEx(ctx, version, nil)
+		if err != nil {
+			return err
+		}
+
+		if set == nil {
+			continue
+		}
+
+		version = set.Version
+
+		var updates []types.ObjectUpdate
+		for _, fs := range set.FilterSet {
+			updates = append(updates, fs.ObjectSet...)
+		}
+
+		if len(updates) != 0 && f(updates) {
+			return nil
+		}
+	}
+}
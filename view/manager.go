@@ -50,3 +50,36 @@ func (m Manager) CreateListView(ctx context.Context, objects []types.ManagedObje
 
 	return NewListView(m.Client(), res.Returnval), nil
 }
+
+// CreateContainerView creates a ContainerView of the objects of the given managed object types
+// within container, including those of child folders and other containers if recursive is true.
+// All objects are included if managedObjectTypes is empty.
+func (m Manager) CreateContainerView(ctx context.Context, container types.ManagedObjectReference, managedObjectTypes []string, recursive bool) (*ContainerView, error) {
+	req := types.CreateContainerView{
+		This:      m.Common.Reference(),
+		Container: container,
+		Recursive: recursive,
+		Type:      managedObjectTypes,
+	}
+
+	res, err := methods.CreateContainerView(ctx, m.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewContainerView(m.Client(), res.Returnval), nil
+}
+
+// CreateInventoryView creates an InventoryView, which initially contains the root folder.
+func (m Manager) CreateInventoryView(ctx context.Context) (*InventoryView, error) {
+	req := types.CreateInventoryView{
+		This: m.Common.Reference(),
+	}
+
+	res, err := methods.CreateInventoryView(ctx, m.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInventoryView(m.Client(), res.Returnval), nil
+}
@@ -18,8 +18,9 @@ package vim25
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 
+	"github.com/vmware/govmomi/vim25/json"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
@@ -88,6 +89,10 @@ func (c *Client) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	if m.SoapClient == nil {
+		return errors.New("vim25: SoapClient missing from JSON")
+	}
+
 	*c = Client{
 		Client:         m.SoapClient,
 		ServiceContent: m.ServiceContent,
@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a single round trip, as written by JSONProvider.
+type Entry struct {
+	ID              string    `json:"id"`
+	Method          string    `json:"method,omitempty"`
+	Time            time.Time `json:"time"`
+	Duration        float64   `json:"duration_ms"`
+	RequestHeaders  string    `json:"request_headers,omitempty"`
+	Request         string    `json:"request,omitempty"`
+	ResponseHeaders string    `json:"response_headers,omitempty"`
+	Response        string    `json:"response,omitempty"`
+}
+
+// JSONProvider implements a debugging provider that writes each round trip as
+// a single line of JSON to the given Writer, rather than a file per request.
+// Passwords, tickets and cookies are redacted (see RedactXML and
+// RedactHeaders) and the XML bodies are pretty-printed.
+type JSONProvider struct {
+	mu      sync.Mutex
+	w       io.Writer
+	enc     *json.Encoder
+	entries map[string]*jsonEntry
+}
+
+type jsonEntry struct {
+	Entry
+
+	response time.Time
+}
+
+// NewJSONProvider returns a JSONProvider that writes to w.
+func NewJSONProvider(w io.Writer) *JSONProvider {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	return &JSONProvider{
+		w:       w,
+		enc:     enc,
+		entries: make(map[string]*jsonEntry),
+	}
+}
+
+// jsonFile buffers the content of a file created by NewFile until it is closed.
+type jsonFile struct {
+	bytes.Buffer
+
+	p      *JSONProvider
+	id     string
+	suffix string
+}
+
+func (f *jsonFile) Close() error {
+	return f.p.closeFile(f)
+}
+
+func (p *JSONProvider) NewFile(s string) io.WriteCloser {
+	// Files are named by the soap package as "<client>-<request>.<suffix>",
+	// the per-client log is not needed as timing is included in each entry.
+	i := strings.Index(s, ".")
+	if i < 0 || strings.HasSuffix(s, "client.log") {
+		return nopCloser{ioutil.Discard}
+	}
+
+	id, suffix := s[:i], s[i+1:]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.entries[id]
+	if !ok {
+		e = &jsonEntry{Entry: Entry{ID: id, Time: time.Now()}}
+		p.entries[id] = e
+	}
+
+	if suffix == "res.headers" {
+		e.response = time.Now()
+	}
+
+	return &jsonFile{p: p, id: id, suffix: suffix}
+}
+
+var methodName = regexp.MustCompile(`<(?:\w+:)?Body[^>]*>\s*<(?:\w+:)?(\w+)`)
+
+func (p *JSONProvider) closeFile(f *jsonFile) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.entries[f.id]
+	if !ok {
+		return nil
+	}
+
+	b := f.Bytes()
+
+	switch f.suffix {
+	case "req.headers":
+		e.RequestHeaders = string(RedactHeaders(b))
+	case "res.headers":
+		e.ResponseHeaders = string(RedactHeaders(b))
+	case "res.xml":
+		e.Response = string(IndentXML(RedactXML(b)))
+	case "req.xml":
+		if m := methodName.FindSubmatch(b); m != nil {
+			e.Method = string(m[1])
+		}
+		e.Request = string(IndentXML(RedactXML(b)))
+
+		// The request body is closed last, once the round trip is done.
+		return p.write(e)
+	}
+
+	return nil
+}
+
+// write encodes the given entry, must be called with p.mu held.
+func (p *JSONProvider) write(e *jsonEntry) error {
+	delete(p.entries, e.ID)
+
+	if !e.response.IsZero() {
+		e.Duration = float64(e.response.Sub(e.Time)) / float64(time.Millisecond)
+	}
+
+	return p.enc.Encode(&e.Entry)
+}
+
+// Flush writes any incomplete entries.
+func (p *JSONProvider) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.entries {
+		_ = p.write(e)
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/debug"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func TestJSONProvider(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	var buf bytes.Buffer
+	debug.SetProvider(debug.NewJSONProvider(&buf))
+	defer debug.SetProvider(nil)
+
+	c, err := vim25.NewClient(ctx, soap.NewClient(s.URL, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = session.NewManager(c).Login(ctx, url.UserPassword("user", "s3cr3t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = methods.GetCurrentTime(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	debug.Flush()
+
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") {
+		t.Error("output contains password")
+	}
+
+	if !strings.Contains(out, simulator.SessionCookie+"=[redacted]") {
+		t.Error("output does not contain redacted cookie")
+	}
+
+	var methods []string
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var e debug.Entry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		if e.Request == "" || e.Response == "" || e.ResponseHeaders == "" {
+			t.Errorf("incomplete entry: %#v", e)
+		}
+
+		methods = append(methods, e.Method)
+	}
+
+	expect := "RetrieveServiceContent Login CurrentTime"
+	if strings.Join(methods, " ") != expect {
+		t.Errorf("methods=%v", methods)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		{
+			`<Login><userName>root</userName><password>vmware</password></Login>`,
+			`<Login><userName>root</userName><password>[redacted]</password></Login>`,
+		},
+		{
+			`<auth xsi:type="NamePasswordAuthentication"><interactiveSession>false</interactiveSession><username>u</username><password>p</password></auth>`,
+			`<auth xsi:type="NamePasswordAuthentication"><interactiveSession>false</interactiveSession><username>u</username><password>[redacted]</password></auth>`,
+		},
+		{
+			`<AcquireGenericServiceTicketResponse><returnval><id>cst-abc</id><hostName>h</hostName></returnval></AcquireGenericServiceTicketResponse>`,
+			`<AcquireGenericServiceTicketResponse><returnval><id>[redacted]</id><hostName>h</hostName></returnval></AcquireGenericServiceTicketResponse>`,
+		},
+		{
+			`<AcquireCloneTicketResponse><returnval>cst-123</returnval></AcquireCloneTicketResponse>`,
+			`<AcquireCloneTicketResponse><returnval>[redacted]</returnval></AcquireCloneTicketResponse>`,
+		},
+		{
+			`<wsse:UsernameToken><wsse:Username>u</wsse:Username><wsse:Password>p</wsse:Password></wsse:UsernameToken>`,
+			`<wsse:UsernameToken><wsse:Username>u</wsse:Username><wsse:Password>[redacted]</wsse:Password></wsse:UsernameToken>`,
+		},
+		{
+			`<wst:RequestedSecurityToken><saml2:Assertion ID="_1"><saml2:Subject>u</saml2:Subject></saml2:Assertion></wst:RequestedSecurityToken>`,
+			`<wst:RequestedSecurityToken><saml2:Assertion ID="_1">[redacted]</saml2:Assertion></wst:RequestedSecurityToken>`,
+		},
+		{
+			`<RetrievePropertiesResponse><returnval><obj>vm-1</obj></returnval></RetrievePropertiesResponse>`,
+			`<RetrievePropertiesResponse><returnval><obj>vm-1</obj></returnval></RetrievePropertiesResponse>`,
+		},
+	}
+
+	for _, test := range tests {
+		out := string(debug.RedactXML([]byte(test.in)))
+		if out != test.out {
+			t.Errorf("expected: %s, got: %s", test.out, out)
+		}
+	}
+
+	headers := "HTTP/1.1 200 OK\r\nSet-Cookie: vmware_soap_session=\"abc\"; Path=/; HttpOnly\r\nCookie: a=1; b=2\r\n"
+	expect := "HTTP/1.1 200 OK\r\nSet-Cookie: vmware_soap_session=[redacted]; Path=/; HttpOnly\r\nCookie: a=[redacted]; b=[redacted]\r\n"
+	if out := string(debug.RedactHeaders([]byte(headers))); out != expect {
+		t.Errorf("expected: %q, got: %q", expect, out)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// Elements whose content is redacted, regardless of the method:
+// Login.Password, GuestAuthentication passwords and tickets, clone tickets,
+// SSPI tokens, SessionManagerGenericServiceTicket ids, WS-Security passwords and SAML assertions.
+var redactElements = regexp.MustCompile(`(?s)(<(\w+:)?(password|Password|pwd|ticket|cloneTicket|base64Token|samlToken|token|Assertion)(\s[^>]*)?>).*?(</(\w+:)?(password|Password|pwd|ticket|cloneTicket|base64Token|samlToken|token|Assertion)>)`)
+
+// Responses whose returnval content is a secret.
+var redactResponses = regexp.MustCompile(`(?s)(<(AcquireCloneTicketResponse|AcquireGenericServiceTicketResponse|AcquireTicketResponse|AcquireCimServicesTicketResponse|AcquireCredentialsInGuestResponse)[\s>].*?)(</(\w+:)?(AcquireCloneTicketResponse|AcquireGenericServiceTicketResponse|AcquireTicketResponse|AcquireCimServicesTicketResponse|AcquireCredentialsInGuestResponse)>)`)
+
+var redactReturnval = regexp.MustCompile(`(?s)(<(returnval|id|sessionId)(\s[^>]*)?>)[^<]*(</(returnval|id|sessionId)>)`)
+
+// RedactXML replaces the content of elements known to contain secrets, such
+// as Login passwords, guest credentials and service tickets.
+func RedactXML(b []byte) []byte {
+	b = redactElements.ReplaceAll(b, []byte("${1}"+redacted+"${5}"))
+
+	return redactResponses.ReplaceAllFunc(b, func(m []byte) []byte {
+		return redactReturnval.ReplaceAll(m, []byte("${1}"+redacted+"${4}"))
+	})
+}
+
+// RedactHeaders replaces cookie values and authorization credentials in the
+// given HTTP header dump, such as the vmware_soap_session cookie.
+func RedactHeaders(b []byte) []byte {
+	var out bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		i := strings.Index(line, ":")
+		if i > 0 {
+			name := strings.ToLower(line[:i])
+			value := strings.TrimSpace(line[i+1:])
+
+			switch name {
+			case "cookie":
+				line = line[:i] + ": " + redactCookies(value, false)
+			case "set-cookie":
+				line = line[:i] + ": " + redactCookies(value, true)
+			case "authorization", "proxy-authorization", "vmware-api-session-id":
+				line = line[:i] + ": " + redacted
+			}
+		}
+
+		out.WriteString(line)
+		out.WriteString("\r\n")
+	}
+
+	return out.Bytes()
+}
+
+// redactCookies replaces cookie values, for Set-Cookie only the first pair is
+// the cookie, the remaining pairs are attributes such as Path.
+func redactCookies(value string, set bool) string {
+	pairs := strings.Split(value, ";")
+
+	for i, pair := range pairs {
+		if set && i > 0 {
+			break
+		}
+
+		if j := strings.Index(pair, "="); j > 0 {
+			pairs[i] = pair[:j+1] + redacted
+		}
+	}
+
+	return strings.Join(pairs, ";")
+}
+
+// IndentXML returns a pretty-printed copy of the given XML document.
+// If the document cannot be parsed, it is returned as-is.
+func IndentXML(b []byte) []byte {
+	var out bytes.Buffer
+
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	depth := 0
+	open := false // last token was a start element
+	var text bytes.Buffer
+
+	indent := func() {
+		for i := 0; i < depth; i++ {
+			out.WriteString("  ")
+		}
+	}
+
+	name := func(n xml.Name) string {
+		if n.Space == "" {
+			return n.Local
+		}
+		return n.Space + ":" + n.Local
+	}
+
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return b
+		}
+
+		switch t := tok.(type) {
+		case xml.ProcInst:
+			out.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>\n")
+		case xml.StartElement:
+			if open {
+				out.WriteString("\n")
+			}
+			indent()
+			out.WriteString("<" + name(t.Name))
+			for _, a := range t.Attr {
+				out.WriteString(" " + name(a.Name) + `="`)
+				_ = xml.EscapeText(&out, []byte(a.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+			depth++
+			open = true
+			text.Reset()
+		case xml.CharData:
+			if open {
+				_ = xml.EscapeText(&text, t)
+			}
+		case xml.EndElement:
+			depth--
+			if open {
+				out.Write(text.Bytes())
+			} else {
+				indent()
+			}
+			out.WriteString("</" + name(t.Name) + ">\n")
+			open = false
+		}
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,284 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type decodeState struct {
+	typeFunc types.Func
+}
+
+func newDecodeState(f types.Func) *decodeState {
+	if f == nil {
+		f = types.TypeFunc()
+	}
+
+	return &decodeState{typeFunc: f}
+}
+
+func (d *decodeState) decode(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("json: Unmarshal(non-pointer %T)", v)
+	}
+
+	return d.value(bytes.TrimSpace(data), rv.Elem())
+}
+
+// unmarshaler returns true if v, which must be addressable, is decoded by its own UnmarshalJSON or UnmarshalText method.
+func unmarshaler(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		return false
+	}
+
+	p := reflect.PtrTo(v.Type())
+	return p.Implements(unmarshalerType) || p.Implements(textUnmarshalerType)
+}
+
+// value decodes data into v, which must be addressable.
+func (d *decodeState) value(data []byte, v reflect.Value) error {
+	if string(data) == "null" {
+		switch v.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	if unmarshaler(v) {
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return d.iface(data, v)
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.value(data, v.Elem())
+	case reflect.Struct:
+		return d.object(data, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := d.value(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+		return nil
+	case reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if i < len(items) {
+				if err := d.value(items[i], v.Index(i)); err != nil {
+					return err
+				}
+			} else {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		return d.mapping(data, v)
+	}
+
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+// iface decodes data into interface v, using the discriminator to determine the concrete type.
+func (d *decodeState) iface(data []byte, v reflect.Value) error {
+	var obj map[string]json.RawMessage
+
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+	}
+
+	kind, ok := obj[TypeKey]
+	if !ok {
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("json: cannot decode %s into %s without %s", data, v.Type(), TypeKey)
+		}
+
+		var x interface{}
+		if err := json.Unmarshal(data, &x); err != nil {
+			return err
+		}
+		if x != nil {
+			v.Set(reflect.ValueOf(x))
+		}
+
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(kind, &name); err != nil {
+		return err
+	}
+
+	typ, ok := primitives[name]
+	if !ok {
+		typ, ok = d.typeFunc(name)
+		if !ok {
+			return fmt.Errorf("json: unknown %s %q", TypeKey, name)
+		}
+	}
+
+	pval := reflect.New(typ)
+
+	// Structs are wrapped only if they have their own encoding, such as time.Time
+	if val, ok := obj[ValueKey]; ok && (typ.Kind() != reflect.Struct || unmarshaler(pval.Elem())) {
+		data = val
+	}
+
+	if err := d.value(data, pval.Elem()); err != nil {
+		return err
+	}
+
+	// As with the vim25 XML decoder, the value is stored if its type implements the interface,
+	// otherwise the pointer, such as for types.BaseVirtualDevice.
+	switch {
+	case typ.Implements(v.Type()):
+		v.Set(pval.Elem())
+	case pval.Type().Implements(v.Type()):
+		v.Set(pval)
+	default:
+		return fmt.Errorf("json: %s does not implement %s", name, v.Type())
+	}
+
+	return nil
+}
+
+// object decodes the fields of struct v, matching names exactly or otherwise case-insensitively.
+func (d *decodeState) object(data []byte, v reflect.Value) error {
+	var obj map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	for _, f := range cachedFields(v.Type()) {
+		val, ok := obj[f.name]
+		if !ok {
+			for key := range obj {
+				if strings.EqualFold(key, f.name) {
+					val, ok = obj[key], true
+					break
+				}
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := d.value(val, fieldByIndexAlloc(v, f.index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapping decodes the entries of map v.
+func (d *decodeState) mapping(data []byte, v reflect.Value) error {
+	var obj map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	typ := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(typ))
+	}
+
+	for key, val := range obj {
+		k := reflect.New(typ.Key()).Elem()
+
+		switch k.Kind() {
+		case reflect.String:
+			k.SetString(key)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				return err
+			}
+			k.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(key, 10, 64)
+			if err != nil {
+				return err
+			}
+			k.SetUint(n)
+		default:
+			return fmt.Errorf("json: unsupported map key type %s", typ.Key())
+		}
+
+		e := reflect.New(typ.Elem()).Elem()
+		if err := d.value(val, e); err != nil {
+			return err
+		}
+
+		v.SetMapIndex(k, e)
+	}
+
+	return nil
+}
+
+// fieldByIndexAlloc returns the field of v with the given index sequence,
+// allocating any nil embedded pointers along the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
@@ -0,0 +1,296 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type encodeState struct {
+	typeFunc types.Func
+}
+
+func newEncodeState(f types.Func) *encodeState {
+	if f == nil {
+		f = types.TypeFunc()
+	}
+
+	return &encodeState{typeFunc: f}
+}
+
+func (e *encodeState) encode(buf *bytes.Buffer, v interface{}) error {
+	// The top-level value is treated as held by an interface, so it can be decoded into a types.AnyType
+	return e.value(buf, reflect.ValueOf(v), true)
+}
+
+// typeName returns the discriminator name for the given type, or "" if it has none.
+func (e *encodeState) typeName(typ reflect.Type) string {
+	if name := primitiveName(typ); name != "" {
+		return name
+	}
+
+	if name := typ.Name(); name != "" {
+		if t, ok := e.typeFunc(name); ok && t == typ {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// marshaler returns true if v is encoded by its own MarshalJSON or MarshalText method.
+func marshaler(v reflect.Value) bool {
+	t := v.Type()
+
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+
+	if t.Kind() != reflect.Ptr && v.CanAddr() {
+		p := reflect.PtrTo(t)
+		return p.Implements(marshalerType) || p.Implements(textMarshalerType)
+	}
+
+	return false
+}
+
+// value writes the encoding of v, with a discriminator if v is held by an interface.
+func (e *encodeState) value(buf *bytes.Buffer, v reflect.Value, discriminate bool) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return e.value(buf, v.Elem(), true)
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if !marshaler(v) {
+			return e.value(buf, v.Elem(), discriminate)
+		}
+	}
+
+	var name string
+	if discriminate {
+		name = e.typeName(v.Type())
+	}
+
+	if v.Kind() == reflect.Struct && !marshaler(v) {
+		return e.object(buf, v, name)
+	}
+
+	if name != "" {
+		fmt.Fprintf(buf, `{"%s":%s,"%s":`, TypeKey, quote(name), ValueKey)
+	}
+
+	if err := e.plain(buf, v); err != nil {
+		return err
+	}
+
+	if name != "" {
+		buf.WriteByte('}')
+	}
+
+	return nil
+}
+
+// plain writes the encoding of any v other than a struct, without a discriminator.
+func (e *encodeState) plain(buf *bytes.Buffer, v reflect.Value) error {
+	if marshaler(v) {
+		return e.std(buf, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.std(buf, v)
+		}
+		fallthrough
+	case reflect.Array:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := e.value(buf, v.Index(i), false); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case reflect.Map:
+		return e.mapping(buf, v)
+	default:
+		return e.std(buf, v)
+	}
+}
+
+// std writes the encoding of v using the encoding/json package.
+func (e *encodeState) std(buf *bytes.Buffer, v reflect.Value) error {
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+
+	buf.Write(b)
+	return nil
+}
+
+// object writes the fields of struct v, preceded by the discriminator if name is not empty.
+func (e *encodeState) object(buf *bytes.Buffer, v reflect.Value, name string) error {
+	buf.WriteByte('{')
+
+	n := 0
+	if name != "" {
+		fmt.Fprintf(buf, `"%s":%s`, TypeKey, quote(name))
+		n++
+	}
+
+	for _, f := range cachedFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+
+		buf.WriteString(quote(f.name))
+		buf.WriteByte(':')
+
+		if err := e.value(buf, fv, false); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+// mapping writes the entries of map v, sorted by key.
+func (e *encodeState) mapping(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	var keys []string
+	index := make(map[string]reflect.Value, v.Len())
+
+	for _, k := range v.MapKeys() {
+		var key string
+
+		switch k.Kind() {
+		case reflect.String:
+			key = k.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			key = strconv.FormatInt(k.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			return fmt.Errorf("json: unsupported map key type %s", k.Type())
+		}
+
+		keys = append(keys, key)
+		index[key] = v.MapIndex(k)
+	}
+
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.WriteString(quote(key))
+		buf.WriteByte(':')
+
+		if err := e.value(buf, index[key], false); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// quote returns s as a JSON string
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// fieldByIndex returns the field of v with the given index sequence.
+// False is returned if the field is promoted through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
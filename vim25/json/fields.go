@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	marshalerType       = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf((*time.Time)(nil)).Elem()
+)
+
+// primitives maps discriminator names of non-struct values to their type,
+// using the xsd type names of the vim25 XML encoding.
+var primitives = map[string]reflect.Type{
+	"boolean":       reflect.TypeOf((*bool)(nil)).Elem(),
+	"byte":          reflect.TypeOf((*int8)(nil)).Elem(),
+	"short":         reflect.TypeOf((*int16)(nil)).Elem(),
+	"int":           reflect.TypeOf((*int32)(nil)).Elem(),
+	"long":          reflect.TypeOf((*int64)(nil)).Elem(),
+	"unsignedByte":  reflect.TypeOf((*uint8)(nil)).Elem(),
+	"unsignedShort": reflect.TypeOf((*uint16)(nil)).Elem(),
+	"unsignedInt":   reflect.TypeOf((*uint32)(nil)).Elem(),
+	"unsignedLong":  reflect.TypeOf((*uint64)(nil)).Elem(),
+	"float":         reflect.TypeOf((*float32)(nil)).Elem(),
+	"double":        reflect.TypeOf((*float64)(nil)).Elem(),
+	"string":        reflect.TypeOf((*string)(nil)).Elem(),
+	"dateTime":      timeType,
+	"base64Binary":  reflect.TypeOf((*[]byte)(nil)).Elem(),
+}
+
+// primitiveName returns the discriminator name for an unnamed basic type, or "" if there is none.
+func primitiveName(typ reflect.Type) string {
+	if typ == timeType {
+		return "dateTime"
+	}
+
+	if typ.PkgPath() != "" {
+		return ""
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8:
+		return "byte"
+	case reflect.Int16:
+		return "short"
+	case reflect.Int32:
+		return "int"
+	case reflect.Int, reflect.Int64:
+		return "long"
+	case reflect.Uint8:
+		return "unsignedByte"
+	case reflect.Uint16:
+		return "unsignedShort"
+	case reflect.Uint32:
+		return "unsignedInt"
+	case reflect.Uint, reflect.Uint64:
+		return "unsignedLong"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "base64Binary"
+		}
+	}
+
+	return ""
+}
+
+// field is an encoded struct field, which may be promoted from an embedded struct.
+type field struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+}
+
+var fieldCache struct {
+	sync.RWMutex
+	m map[reflect.Type][]field
+}
+
+// cachedFields returns the encoded fields of the given struct type.
+func cachedFields(typ reflect.Type) []field {
+	fieldCache.RLock()
+	f, ok := fieldCache.m[typ]
+	fieldCache.RUnlock()
+
+	if ok {
+		return f
+	}
+
+	f = typeFields(typ)
+
+	fieldCache.Lock()
+	if fieldCache.m == nil {
+		fieldCache.m = make(map[reflect.Type][]field)
+	}
+	fieldCache.m[typ] = f
+	fieldCache.Unlock()
+
+	return f
+}
+
+// typeFields returns the fields of the given struct type, applying the
+// encoding/json rules for json tags and fields promoted from embedded structs.
+func typeFields(typ reflect.Type) []field {
+	var fields []field
+
+	var walk func(reflect.Type, []int, map[reflect.Type]bool)
+
+	walk = func(t reflect.Type, index []int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+
+			ft := sf.Type
+			if sf.Anonymous && ft.Kind() == reflect.Ptr && ft.Name() == "" {
+				ft = ft.Elem()
+			}
+
+			if sf.PkgPath != "" && !(sf.Anonymous && ft.Kind() == reflect.Struct) {
+				continue // unexported
+			}
+
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			opts := strings.Split(tag, ",")
+			name := opts[0]
+
+			idx := make([]int, len(index)+1)
+			copy(idx, index)
+			idx[len(index)] = i
+
+			if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+				walk(ft, idx, visited)
+				continue
+			}
+
+			f := field{
+				name:   name,
+				index:  idx,
+				tagged: name != "",
+			}
+
+			if f.name == "" {
+				f.name = sf.Name
+			}
+
+			for _, opt := range opts[1:] {
+				if opt == "omitempty" {
+					f.omitEmpty = true
+				}
+			}
+
+			fields = append(fields, f)
+		}
+	}
+
+	walk(typ, nil, make(map[reflect.Type]bool))
+
+	// Of fields with the same name, the least nested wins, then a tagged field.
+	// Any remaining conflict hides all of the fields with that name.
+	sort.Stable(byName(fields))
+
+	var out []field
+
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+
+		dominant := fields[i]
+		if j-i == 1 ||
+			len(fields[i+1].index) > len(dominant.index) ||
+			(dominant.tagged && !fields[i+1].tagged) {
+			out = append(out, dominant)
+		}
+
+		i = j
+	}
+
+	// Preserve the struct field order
+	sort.Sort(byIndex(out))
+
+	return out
+}
+
+// byName sorts fields by name, then depth, then whether the field is tagged
+type byName []field
+
+func (f byName) Len() int      { return len(f) }
+func (f byName) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f byName) Less(i, j int) bool {
+	if f[i].name != f[j].name {
+		return f[i].name < f[j].name
+	}
+	if len(f[i].index) != len(f[j].index) {
+		return len(f[i].index) < len(f[j].index)
+	}
+	return f[i].tagged && !f[j].tagged
+}
+
+// byIndex sorts fields by their index sequence
+type byIndex []field
+
+func (f byIndex) Len() int      { return len(f) }
+func (f byIndex) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f byIndex) Less(i, j int) bool {
+	a, b := f[i].index, f[j].index
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
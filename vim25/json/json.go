@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package json encodes and decodes vim25 types as JSON, such that values held by
+interface typed fields, such as types.BaseVirtualDevice, types.BaseEvent and
+types.AnyType, can be decoded back to their concrete type.
+
+Values held by an interface are written with a "_typeName" discriminator, using
+the same type names as the vim25 XML encoding. Structs include the discriminator
+as their first field:
+
+	{"_typeName":"VirtualDisk","Key":2000,...}
+
+Other values, such as strings, numbers and enums, are wrapped:
+
+	{"_typeName":"string","_value":"foo"}
+
+When decoding, the discriminator is resolved using types.TypeFunc(). Otherwise
+the encoding is the same as the standard library encoding/json package, which
+is used for all values other than structs, pointers, interfaces, slices, arrays
+and maps, and for any type that implements json.Marshaler or json.Unmarshaler.
+*/
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// TypeKey is the name of the discriminator field.
+	TypeKey = "_typeName"
+
+	// ValueKey is the name of the field holding a non-struct value of a discriminated type.
+	ValueKey = "_value"
+)
+
+// Marshal returns the JSON encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := newEncodeState(types.TypeFunc()).encode(&buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalIndent is like Marshal, but applies json.Indent to format the output.
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	b, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = json.Indent(&buf, b, prefix, indent); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal parses the JSON encoded data and stores the result in the value pointed to by v.
+func Unmarshal(data []byte, v interface{}) error {
+	return newDecodeState(types.TypeFunc()).decode(data, v)
+}
+
+// An Encoder writes JSON values to an output stream.
+type Encoder struct {
+	TypeFunc types.Func
+
+	w      io.Writer
+	prefix string
+	indent string
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		TypeFunc: types.TypeFunc(),
+		w:        w,
+	}
+}
+
+// SetIndent instructs the encoder to format each subsequent encoded value as if indented by MarshalIndent.
+func (e *Encoder) SetIndent(prefix, indent string) {
+	e.prefix = prefix
+	e.indent = indent
+}
+
+// Encode writes the JSON encoding of v to the stream, followed by a newline character.
+func (e *Encoder) Encode(v interface{}) error {
+	var buf bytes.Buffer
+
+	if err := newEncodeState(e.TypeFunc).encode(&buf, v); err != nil {
+		return err
+	}
+
+	if e.prefix != "" || e.indent != "" {
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), e.prefix, e.indent); err != nil {
+			return err
+		}
+		buf = out
+	}
+
+	buf.WriteByte('\n')
+
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// A Decoder reads and decodes JSON values from an input stream.
+type Decoder struct {
+	TypeFunc types.Func
+
+	dec *json.Decoder
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		TypeFunc: types.TypeFunc(),
+		dec:      json.NewDecoder(r),
+	}
+}
+
+// Decode reads the next JSON value from its input and stores it in the value pointed to by v.
+func (d *Decoder) Decode(v interface{}) error {
+	var raw json.RawMessage
+
+	if err := d.dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	return newDecodeState(d.TypeFunc).decode(raw, v)
+}
@@ -0,0 +1,254 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func roundTrip(t *testing.T, in interface{}, out interface{}) {
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Unmarshal(b, out); err != nil {
+		t.Fatalf("%s: %s", err, b)
+	}
+
+	if !reflect.DeepEqual(in, reflect.ValueOf(out).Elem().Interface()) {
+		t.Errorf("round trip mismatch: %s", b)
+	}
+}
+
+func TestConfigSpec(t *testing.T) {
+	spec := types.VirtualMachineConfigSpec{
+		Name:     "vm-001",
+		NumCPUs:  1,
+		MemoryMB: 128,
+		DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device: &types.VirtualLsiLogicController{VirtualSCSIController: types.VirtualSCSIController{
+					SharedBus: types.VirtualSCSISharingNoSharing,
+					VirtualController: types.VirtualController{
+						VirtualDevice: types.VirtualDevice{Key: 1000},
+					},
+				}},
+			},
+			&types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+				FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+				Device: &types.VirtualDisk{
+					VirtualDevice: types.VirtualDevice{
+						ControllerKey: 1000,
+						UnitNumber:    new(int32),
+						Backing: &types.VirtualDiskFlatVer2BackingInfo{
+							DiskMode:        string(types.VirtualDiskModePersistent),
+							ThinProvisioned: types.NewBool(true),
+							VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+								FileName: "[datastore1]",
+							},
+						},
+					},
+					CapacityInKB: 4000000,
+				},
+			},
+		},
+		ExtraConfig: []types.BaseOptionValue{
+			&types.OptionValue{Key: "string", Value: "foo"},
+			&types.OptionValue{Key: "int", Value: int32(1)},
+			&types.OptionValue{Key: "long", Value: int64(2)},
+			&types.OptionValue{Key: "bool", Value: true},
+			&types.OptionValue{Key: "enum", Value: types.VirtualMachinePowerStatePoweredOn},
+			&types.OptionValue{Key: "array", Value: types.ArrayOfString{String: []string{"a", "b"}}},
+			&types.OptionValue{Key: "time", Value: time.Date(2017, 8, 1, 12, 0, 0, 0, time.UTC)},
+			&types.OptionValue{Key: "bytes", Value: []byte("foo")},
+			&types.OptionValue{Key: "nil"},
+		},
+	}
+
+	var out types.VirtualMachineConfigSpec
+	roundTrip(t, spec, &out)
+
+	// The top-level value can also be decoded into an interface
+	var any types.AnyType
+	roundTrip(t, spec, &any)
+}
+
+func TestDevices(t *testing.T) {
+	devices := []types.BaseVirtualDevice{
+		&types.VirtualCdrom{
+			VirtualDevice: types.VirtualDevice{
+				Key:     3000,
+				Backing: &types.VirtualCdromIsoBackingInfo{},
+				Connectable: &types.VirtualDeviceConnectInfo{
+					StartConnected: true,
+				},
+			},
+		},
+		&types.VirtualE1000{VirtualEthernetCard: types.VirtualEthernetCard{
+			VirtualDevice: types.VirtualDevice{
+				Key: 4000,
+				DeviceInfo: &types.Description{
+					Label:   "Network Adapter 1",
+					Summary: "VM Network",
+				},
+				Backing: &types.VirtualEthernetCardNetworkBackingInfo{
+					VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+						DeviceName: "VM Network",
+					},
+				},
+			},
+			AddressType: string(types.VirtualEthernetCardMacTypeGenerated),
+		}},
+	}
+
+	var out []types.BaseVirtualDevice
+	roundTrip(t, devices, &out)
+
+	b, err := Marshal(devices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(b, []byte(`[{"_typeName":"VirtualCdrom","Key":3000,`)) {
+		t.Errorf("unexpected encoding: %s", b)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	now := time.Date(2017, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []types.BaseEvent{
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+			Key:         1,
+			CreatedTime: now,
+			Vm: &types.VmEventArgument{
+				Vm: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+			},
+		}}},
+		&types.TaskEvent{Event: types.Event{Key: 2, CreatedTime: now},
+			Info: types.TaskInfo{
+				State: types.TaskInfoStateError,
+				Error: &types.LocalizedMethodFault{
+					Fault:            &types.InvalidArgument{InvalidProperty: "name"},
+					LocalizedMessage: "A specified parameter was not correct: name",
+				},
+				Result: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+			},
+		},
+	}
+
+	var out []types.BaseEvent
+	roundTrip(t, events, &out)
+}
+
+func TestManagedObject(t *testing.T) {
+	vm := mo.VirtualMachine{
+		Config: &types.VirtualMachineConfigInfo{
+			Name: "vm-001",
+			Hardware: types.VirtualHardware{
+				Device: []types.BaseVirtualDevice{
+					&types.VirtualIDEController{VirtualController: types.VirtualController{
+						VirtualDevice: types.VirtualDevice{Key: 200},
+					}},
+				},
+			},
+		},
+	}
+	vm.Self = types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	vm.CustomValue = []types.BaseCustomFieldValue{
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 1}, Value: "foo"},
+	}
+
+	var out mo.VirtualMachine
+	roundTrip(t, vm, &out)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{`{"_typeName":"NoSuchType"}`, "unknown _typeName"},
+		{`{"_typeName":"VirtualDisk"}`, "does not implement"},
+		{`{"Key":1}`, "without _typeName"},
+	}
+
+	for _, test := range tests {
+		var f types.BaseMethodFault
+		err := Unmarshal([]byte(test.in), &f)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected %q error, got %v", test.in, test.err, err)
+		}
+	}
+
+	var v interface{}
+	if err := Unmarshal([]byte(`{"Key":1}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := v.(map[string]interface{}); !ok {
+		t.Errorf("expected map, got %T", v)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+	for _, v := range []interface{}{&types.OptionValue{Key: "a", Value: "b"}, types.ManagedEntityStatusGreen} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect := `{"_typeName":"OptionValue","Key":"a","Value":{"_typeName":"string","_value":"b"}}
+{"_typeName":"ManagedEntityStatus","_value":"green"}
+`
+	if buf.String() != expect {
+		t.Errorf("expected %s, got %s", expect, buf.String())
+	}
+
+	dec := NewDecoder(&buf)
+
+	var option types.BaseOptionValue
+	if err := dec.Decode(&option); err != nil {
+		t.Fatal(err)
+	}
+
+	if option.GetOptionValue().Value != "b" {
+		t.Errorf("option=%#v", option)
+	}
+
+	var status types.AnyType
+	if err := dec.Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != types.ManagedEntityStatusGreen {
+		t.Errorf("status=%#v", status)
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vim25
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Limit caps the requests sent through a Limiter.
+// A zero value for a field means no limit.
+type Limit struct {
+	// Concurrency is the maximum number of requests in flight.
+	Concurrency int
+
+	// Rate is the maximum number of requests per second.
+	Rate float64
+
+	// Burst is the number of requests that may exceed Rate at once, defaults to 1.
+	Burst int
+
+	// SkipGlobal excludes a method from the global Limit.
+	// For example, long polling calls such as WaitForUpdatesEx should not
+	// hold one of the global Concurrency slots for minutes at a time.
+	SkipGlobal bool
+}
+
+// Limits configures a Limiter.
+type Limits struct {
+	// Global applies to all requests, unless the Method Limit has SkipGlobal set.
+	Global Limit
+
+	// Method applies per method name, for example "RetrievePropertiesEx".
+	// A method limit is applied before the Global limit.
+	Method map[string]Limit
+
+	// Wait, if set, is called with the time each request spent queued.
+	Wait func(method string, wait time.Duration)
+}
+
+// LimitStats are the queue statistics for a method.
+type LimitStats struct {
+	Requests  int64         // Number of requests sent
+	InFlight  int           // Number of requests in flight
+	Waiting   int           // Number of requests queued
+	TotalWait time.Duration // Total time requests spent queued
+	MaxWait   time.Duration // Longest time a request spent queued
+}
+
+// limiter implements a concurrency semaphore and token bucket for a Limit.
+type limiter struct {
+	sync.Mutex
+
+	sem    chan struct{}
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(l Limit) *limiter {
+	if l.Concurrency <= 0 && l.Rate <= 0 {
+		return nil
+	}
+
+	r := &limiter{
+		rate:  l.Rate,
+		burst: float64(l.Burst),
+	}
+
+	if r.burst < 1 {
+		r.burst = 1
+	}
+	r.tokens = r.burst
+
+	if l.Concurrency > 0 {
+		r.sem = make(chan struct{}, l.Concurrency)
+	}
+
+	return r
+}
+
+// reserve takes a token from the bucket, returning how long to wait before it can be used.
+func (l *limiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// unreserve returns a token taken by reserve that was not used.
+func (l *limiter) unreserve() {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.Lock()
+	l.tokens++
+	l.Unlock()
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	if d := l.reserve(); d > 0 {
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			l.unreserve()
+			return ctx.Err()
+		}
+	}
+
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (l *limiter) release() {
+	if l != nil && l.sem != nil {
+		<-l.sem
+	}
+}
+
+// Limiter is a soap.RoundTripper that throttles requests according to Limits.
+type Limiter struct {
+	roundTripper soap.RoundTripper
+
+	limits Limits
+	global *limiter
+	method map[string]*limiter
+
+	mu    sync.Mutex
+	stats map[string]*LimitStats
+}
+
+// NewLimiter wraps the specified soap.RoundTripper, capping the number of concurrent
+// requests and requests per second, both globally and per method. Requests that
+// exceed a limit are queued until they can be sent or their context is done.
+func NewLimiter(roundTripper soap.RoundTripper, limits Limits) *Limiter {
+	l := &Limiter{
+		roundTripper: roundTripper,
+		limits:       limits,
+		global:       newLimiter(limits.Global),
+		method:       make(map[string]*limiter),
+		stats:        make(map[string]*LimitStats),
+	}
+
+	for name, limit := range limits.Method {
+		l.method[name] = newLimiter(limit)
+	}
+
+	return l
+}
+
+// Stats returns a copy of the queue statistics, keyed by method name.
+func (l *Limiter) Stats() map[string]LimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]LimitStats, len(l.stats))
+	for name, s := range l.stats {
+		stats[name] = *s
+	}
+
+	return stats
+}
+
+func (l *Limiter) update(method string, fn func(*LimitStats)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.stats[method]
+	if !ok {
+		s = new(LimitStats)
+		l.stats[method] = s
+	}
+
+	fn(s)
+}
+
+func (l *Limiter) acquire(ctx context.Context, method string) error {
+	m := l.method[method]
+
+	if err := m.acquire(ctx); err != nil {
+		return err
+	}
+
+	if l.limits.Method[method].SkipGlobal {
+		return nil
+	}
+
+	if err := l.global.acquire(ctx); err != nil {
+		m.release()
+		return err
+	}
+
+	return nil
+}
+
+func (l *Limiter) release(method string) {
+	if !l.limits.Method[method].SkipGlobal {
+		l.global.release()
+	}
+
+	l.method[method].release()
+}
+
+func (l *Limiter) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	method := soap.MethodName(req)
+	start := time.Now()
+
+	l.update(method, func(s *LimitStats) { s.Waiting++ })
+
+	err := l.acquire(ctx, method)
+	wait := time.Since(start)
+
+	l.update(method, func(s *LimitStats) {
+		s.Waiting--
+		if err != nil {
+			return
+		}
+		s.Requests++
+		s.InFlight++
+		s.TotalWait += wait
+		if wait > s.MaxWait {
+			s.MaxWait = wait
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if l.limits.Wait != nil {
+		l.limits.Wait(method, wait)
+	}
+
+	defer func() {
+		l.release(method)
+		l.update(method, func(s *LimitStats) { s.InFlight-- })
+	}()
+
+	return l.roundTripper.RoundTrip(ctx, req, res)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vim25
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// blockingRoundTripper blocks each request until release is closed,
+// tracking the max number of concurrent requests per method.
+type blockingRoundTripper struct {
+	sync.Mutex
+	release  chan struct{}
+	inflight map[string]int
+	max      map[string]int
+}
+
+func (b *blockingRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := soap.MethodName(req)
+
+	b.Lock()
+	b.inflight[method]++
+	if b.inflight[method] > b.max[method] {
+		b.max[method] = b.inflight[method]
+	}
+	b.Unlock()
+
+	<-b.release
+
+	b.Lock()
+	b.inflight[method]--
+	b.Unlock()
+
+	return nil
+}
+
+func retrieveBody() soap.HasFault {
+	return &methods.RetrievePropertiesExBody{Req: new(types.RetrievePropertiesEx)}
+}
+
+func waitBody() soap.HasFault {
+	return &methods.WaitForUpdatesExBody{Req: new(types.WaitForUpdatesEx)}
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	b := &blockingRoundTripper{
+		release:  make(chan struct{}),
+		inflight: make(map[string]int),
+		max:      make(map[string]int),
+	}
+
+	l := NewLimiter(b, Limits{
+		Global: Limit{Concurrency: 2},
+		Method: map[string]Limit{
+			"RetrievePropertiesEx": {Concurrency: 1},
+			"WaitForUpdatesEx":     {SkipGlobal: true},
+		},
+	})
+
+	var wg sync.WaitGroup
+	call := func(req soap.HasFault) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.RoundTrip(context.Background(), req, req); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		call(retrieveBody())
+		call(waitBody())
+		call(waitBody())
+	}
+
+	// Wait for all requests to either be in flight or queued
+	for {
+		s := l.Stats()
+		if s["WaitForUpdatesEx"].InFlight == 6 && s["RetrievePropertiesEx"].InFlight+s["RetrievePropertiesEx"].Waiting == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(b.release)
+	wg.Wait()
+
+	if b.max["RetrievePropertiesEx"] != 1 {
+		t.Errorf("max RetrievePropertiesEx=%d", b.max["RetrievePropertiesEx"])
+	}
+
+	if b.max["WaitForUpdatesEx"] != 6 {
+		t.Errorf("max WaitForUpdatesEx=%d", b.max["WaitForUpdatesEx"])
+	}
+
+	s := l.Stats()["RetrievePropertiesEx"]
+	if s.Requests != 3 || s.InFlight != 0 || s.Waiting != 0 {
+		t.Errorf("stats=%#v", s)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	var waits int
+
+	l := NewLimiter(&fakeRoundTripper{errs: make([]error, 5)}, Limits{
+		Global: Limit{Rate: 100},
+		Wait: func(method string, wait time.Duration) {
+			if wait > 0 {
+				waits++
+			}
+		},
+	})
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.RoundTrip(context.Background(), retrieveBody(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("elapsed=%s", elapsed)
+	}
+
+	if waits == 0 {
+		t.Error("expected queue wait")
+	}
+
+	if s := l.Stats()["RetrievePropertiesEx"]; s.MaxWait == 0 || s.TotalWait < s.MaxWait {
+		t.Errorf("stats=%#v", s)
+	}
+}
+
+func TestLimiterContext(t *testing.T) {
+	l := NewLimiter(&fakeRoundTripper{errs: make([]error, 1)}, Limits{
+		Global: Limit{Rate: 0.001},
+	})
+
+	// Burst of 1
+	if err := l.RoundTrip(context.Background(), retrieveBody(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.RoundTrip(ctx, retrieveBody(), nil); err != context.DeadlineExceeded {
+		t.Errorf("err=%v", err)
+	}
+}
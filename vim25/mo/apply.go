@@ -0,0 +1,389 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// pathElement is a property name with an optional key, such as device[4000] or extraConfig["foo"].
+type pathElement struct {
+	name  string
+	key   string // key formatted as by types.ElementKey, such as [4000] or ["foo"]
+	index bool
+}
+
+// parsePath splits a property path into its elements.
+func parsePath(path string) ([]pathElement, error) {
+	var elems []pathElement
+
+	for len(path) > 0 {
+		var e pathElement
+
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			end = len(path)
+		}
+
+		e.name = path[:end]
+		path = path[end:]
+
+		if strings.HasPrefix(path, "[") {
+			var n int
+
+			if strings.HasPrefix(path, `["`) {
+				// Find the closing quote, skipping escaped characters
+				n = 2
+				for n < len(path) && path[n] != '"' {
+					if path[n] == '\\' {
+						n++
+					}
+					n++
+				}
+				n++
+			} else {
+				n = strings.Index(path, "]")
+			}
+
+			if n <= 0 || n >= len(path) || path[n] != ']' {
+				return nil, errors.New("unterminated key")
+			}
+
+			e.key = path[:n+1]
+			e.index = true
+
+			if strings.HasPrefix(e.key, `["`) {
+				// Normalize the quoting of string keys
+				key, err := strconv.Unquote(e.key[1:n])
+				if err != nil {
+					return nil, fmt.Errorf("invalid key %s", e.key)
+				}
+				e.key = "[" + strconv.Quote(key) + "]"
+			}
+			path = path[n+1:]
+		}
+
+		if e.name == "" {
+			return nil, errors.New("empty property name")
+		}
+
+		elems = append(elems, e)
+
+		if strings.HasPrefix(path, ".") {
+			path = path[1:]
+			if path == "" {
+				return nil, errors.New("empty property name")
+			}
+		} else if path != "" {
+			return nil, fmt.Errorf("unexpected %q", path)
+		}
+	}
+
+	if len(elems) == 0 {
+		return nil, errors.New("empty property path")
+	}
+
+	return elems, nil
+}
+
+var propertyFieldsLock sync.RWMutex
+var propertyFieldsMap = make(map[reflect.Type]map[string][]int)
+
+// propertyFields maps the property names of struct type typ, including those of embedded structs, to field indices.
+func propertyFields(typ reflect.Type) map[string][]int {
+	propertyFieldsLock.RLock()
+	fields, ok := propertyFieldsMap[typ]
+	propertyFieldsLock.RUnlock()
+
+	if ok {
+		return fields
+	}
+
+	fields = make(map[string][]int)
+
+	var build func(reflect.Type, []int)
+	build = func(t reflect.Type, fi []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+
+			fic := make([]int, len(fi)+1)
+			copy(fic, fi)
+			fic[len(fi)] = i
+
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				build(f.Type, fic)
+				continue
+			}
+
+			if name := buildName("", f); name != "" {
+				fields[name] = fic
+			}
+		}
+	}
+
+	build(typ, nil)
+
+	propertyFieldsLock.Lock()
+	propertyFieldsMap[typ] = fields
+	propertyFieldsLock.Unlock()
+
+	return fields
+}
+
+// findElement returns the index of the element of slice v with the given key, or -1 if not found.
+func findElement(v reflect.Value, key string) int {
+	for i := 0; i < v.Len(); i++ {
+		if k, ok := types.ElementKey(v.Index(i).Interface()); ok && k == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// convertValue converts the property value pv to type rt,
+// dereferencing ArrayOf types and pointers, or taking the address of pv as needed.
+func convertValue(pv reflect.Value, rt reflect.Type) (reflect.Value, error) {
+	if pv.Kind() == reflect.Struct && arrayOfRegexp.MatchString(pv.Type().Name()) {
+		pv = anyTypeToValue(pv.Interface())
+	}
+
+	for pv.Kind() == reflect.Ptr && !pv.Type().AssignableTo(rt) {
+		if pv.IsNil() {
+			return reflect.Zero(rt), nil
+		}
+		pv = pv.Elem()
+	}
+
+	pt := pv.Type()
+
+	switch {
+	case pt.AssignableTo(rt):
+		return pv, nil
+	case rt.Kind() == reflect.Ptr:
+		v, err := convertValue(pv, rt.Elem())
+		if err != nil {
+			return v, err
+		}
+		p := reflect.New(rt.Elem())
+		p.Elem().Set(v)
+		return p, nil
+	case rt.Kind() == reflect.Interface && reflect.PtrTo(pt).Implements(rt):
+		p := reflect.New(pt)
+		p.Elem().Set(pv)
+		return p, nil
+	case rt.Kind() == reflect.Slice && pt.Kind() == reflect.Slice:
+		s := reflect.MakeSlice(rt, pv.Len(), pv.Len())
+		for i := 0; i < pv.Len(); i++ {
+			e := pv.Index(i)
+			if e.Kind() == reflect.Interface && !e.IsNil() {
+				e = e.Elem()
+			}
+			v, err := convertValue(e, rt.Elem())
+			if err != nil {
+				return v, err
+			}
+			s.Index(i).Set(v)
+		}
+		return s, nil
+	case pt.ConvertibleTo(rt) && pt.Kind() == rt.Kind():
+		return pv.Convert(rt), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot assign %s to %s", pt, rt)
+}
+
+// setValue sets v to the given property value, or to its zero value if val is nil.
+func setValue(v reflect.Value, val types.AnyType) error {
+	if val == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	pv, err := convertValue(reflect.ValueOf(val), v.Type())
+	if err != nil {
+		return err
+	}
+
+	v.Set(pv)
+	return nil
+}
+
+// applyChange applies change c to v, where path is the remainder of the change property path.
+func applyChange(v reflect.Value, path []pathElement, c *types.PropertyChange) error {
+	remove := c.Op == types.PropertyChangeOpRemove || c.Op == types.PropertyChangeOpIndirectRemove
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if remove {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return applyChange(v.Elem(), path, c)
+	case reflect.Interface:
+		if v.IsNil() {
+			if remove {
+				return nil
+			}
+			return fmt.Errorf("%s is unset", v.Type())
+		}
+
+		e := v.Elem()
+		if e.Kind() == reflect.Ptr {
+			if !e.IsNil() {
+				return applyChange(e, path, c)
+			}
+			if remove {
+				return nil
+			}
+
+			// A typed nil pointer is not settable, so allocate a new value in its place
+			p := reflect.New(e.Type().Elem())
+			if err := applyChange(p, path, c); err != nil {
+				return err
+			}
+			v.Set(p)
+			return nil
+		}
+
+		// Values held by an interface are not addressable, so apply the change to a copy
+		cp := reflect.New(e.Type()).Elem()
+		cp.Set(e)
+		if err := applyChange(cp, path, c); err != nil {
+			return err
+		}
+		v.Set(cp)
+		return nil
+	case reflect.Struct:
+	default:
+		return fmt.Errorf("cannot set property %q of %s", path[0].name, v.Type())
+	}
+
+	fi, ok := propertyFields(v.Type())[path[0].name]
+	if !ok {
+		return nil // as with LoadFromObjectContent, properties without a field are ignored
+	}
+
+	f := v.FieldByIndex(fi)
+	elem := path[0]
+	path = path[1:]
+
+	if !elem.index {
+		if len(path) != 0 {
+			return applyChange(f, path, c)
+		}
+
+		switch {
+		case remove:
+			f.Set(reflect.Zero(f.Type()))
+			return nil
+		case c.Op == types.PropertyChangeOpAdd && f.Kind() == reflect.Slice:
+			// Add to an array without a key
+			items := reflect.New(f.Type()).Elem()
+			if err := setValue(items, c.Val); err != nil {
+				// A single element
+				e := reflect.New(f.Type().Elem()).Elem()
+				if err = setValue(e, c.Val); err != nil {
+					return err
+				}
+				items = reflect.Append(items, e)
+			}
+			f.Set(reflect.AppendSlice(f, items))
+			return nil
+		default:
+			return setValue(f, c.Val)
+		}
+	}
+
+	if f.Kind() != reflect.Slice {
+		return fmt.Errorf("property %q is not an array", elem.name)
+	}
+
+	i := findElement(f, elem.key)
+
+	if len(path) != 0 {
+		if i < 0 {
+			if remove {
+				return nil
+			}
+			return fmt.Errorf("%s%s not found", elem.name, elem.key)
+		}
+		return applyChange(f.Index(i), path, c)
+	}
+
+	if remove {
+		if i >= 0 {
+			s := reflect.MakeSlice(f.Type(), 0, f.Len()-1)
+			s = reflect.AppendSlice(s, f.Slice(0, i))
+			s = reflect.AppendSlice(s, f.Slice(i+1, f.Len()))
+			f.Set(s)
+		}
+		return nil
+	}
+
+	e := reflect.New(f.Type().Elem()).Elem()
+	if err := setValue(e, c.Val); err != nil {
+		return err
+	}
+
+	if i < 0 {
+		f.Set(reflect.Append(f, e))
+	} else {
+		f.Index(i).Set(e)
+	}
+
+	return nil
+}
+
+// ApplyPropertyChange applies the given changes, such as the ChangeSet of an ObjectUpdate returned
+// by WaitForUpdates, to the managed object struct pointed to by dst, for example *VirtualMachine.
+// Property paths can be nested and include keys for arrays of data objects with a Key field,
+// for example "config.hardware.device[2000].backing.fileName" or `config.extraConfig["foo"]`.
+// Op "add" and "assign" set the property or array element to the change value, appending
+// the element if the key is not found, while "remove" and "indirectRemove" unset the property
+// or remove the array element. As with ObjectContentToType, properties the struct does not
+// have a field for are ignored.
+func ApplyPropertyChange(dst interface{}, changes []types.PropertyChange) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("mo: ApplyPropertyChange(non-pointer %T)", dst)
+	}
+
+	for i := range changes {
+		c := &changes[i]
+
+		path, err := parsePath(c.Name)
+		if err == nil {
+			err = applyChange(v.Elem(), path, c)
+		}
+
+		if err != nil {
+			return fmt.Errorf("mo: %s %s: %s", c.Op, c.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func applyVM() VirtualMachine {
+	var vm VirtualMachine
+
+	vm.Self = types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	vm.Name = "vm-001"
+	vm.Config = &types.VirtualMachineConfigInfo{
+		Name: "vm-001",
+		Hardware: types.VirtualHardware{
+			NumCPU: 1,
+			Device: []types.BaseVirtualDevice{
+				&types.VirtualIDEController{VirtualController: types.VirtualController{
+					VirtualDevice: types.VirtualDevice{Key: 200},
+				}},
+				&types.VirtualDisk{
+					VirtualDevice: types.VirtualDevice{
+						Key:           2000,
+						ControllerKey: 200,
+						Backing: &types.VirtualDiskFlatVer2BackingInfo{
+							VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+								FileName: "[datastore1] vm-001/disk.vmdk",
+							},
+						},
+					},
+				},
+			},
+		},
+		ExtraConfig: []types.BaseOptionValue{
+			&types.OptionValue{Key: "guestinfo.a", Value: "1"},
+		},
+	}
+
+	return vm
+}
+
+func TestApplyPropertyChange(t *testing.T) {
+	vm := applyVM()
+
+	cdrom := types.VirtualCdrom{VirtualDevice: types.VirtualDevice{Key: 3000, ControllerKey: 200}}
+
+	// Changes as found in a WaitForUpdates response
+	changes := []types.PropertyChange{
+		{Name: "name", Op: types.PropertyChangeOpAssign, Val: "vm-002"},
+		{Name: "runtime.powerState", Op: types.PropertyChangeOpAssign, Val: "poweredOn"},
+		{Name: "guest.ipAddress", Op: types.PropertyChangeOpAssign, Val: "10.0.0.1"},
+		{Name: "config.hardware.device[2000].backing.fileName", Op: types.PropertyChangeOpAssign, Val: "[datastore2] vm-001/disk.vmdk"},
+		{Name: "config.hardware.device[200]", Op: types.PropertyChangeOpRemove},
+		{Name: "config.hardware.device[3000]", Op: types.PropertyChangeOpAdd, Val: cdrom},
+		{Name: `config.extraConfig["guestinfo.a"].value`, Op: types.PropertyChangeOpAssign, Val: "2"},
+		{Name: `config.extraConfig["guestinfo.b"]`, Op: types.PropertyChangeOpAdd, Val: types.OptionValue{Key: "guestinfo.b"}},
+		{Name: "layoutEx.file", Op: types.PropertyChangeOpAssign, Val: types.ArrayOfVirtualMachineFileLayoutExFileInfo{
+			VirtualMachineFileLayoutExFileInfo: []types.VirtualMachineFileLayoutExFileInfo{{Key: 1, Name: "vm-001.vmx"}},
+		}},
+		{Name: "effectiveRole", Op: types.PropertyChangeOpAssign, Val: types.ArrayOfInt{Int: []int32{-1}}},
+		{Name: "noSuchProperty", Op: types.PropertyChangeOpAssign, Val: "ignored"},
+	}
+
+	if err := ApplyPropertyChange(&vm, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	if vm.Name != "vm-002" {
+		t.Errorf("name=%s", vm.Name)
+	}
+
+	if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		t.Errorf("powerState=%s", vm.Runtime.PowerState)
+	}
+
+	if vm.Guest == nil || vm.Guest.IpAddress != "10.0.0.1" {
+		t.Errorf("guest=%#v", vm.Guest)
+	}
+
+	devices := vm.Config.Hardware.Device
+	if len(devices) != 2 {
+		t.Fatalf("devices=%#v", devices)
+	}
+
+	disk := devices[0].(*types.VirtualDisk)
+	if disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo).FileName != "[datastore2] vm-001/disk.vmdk" {
+		t.Errorf("disk=%#v", disk.Backing)
+	}
+
+	if !reflect.DeepEqual(devices[1], &cdrom) {
+		t.Errorf("cdrom=%#v", devices[1])
+	}
+
+	extra := vm.Config.ExtraConfig
+	if len(extra) != 2 || extra[0].GetOptionValue().Value != "2" || extra[1].GetOptionValue().Key != "guestinfo.b" {
+		t.Errorf("extraConfig=%#v", extra)
+	}
+
+	if vm.LayoutEx == nil || len(vm.LayoutEx.File) != 1 || vm.LayoutEx.File[0].Name != "vm-001.vmx" {
+		t.Errorf("layoutEx=%#v", vm.LayoutEx)
+	}
+
+	if !reflect.DeepEqual(vm.EffectiveRole, []int32{-1}) {
+		t.Errorf("effectiveRole=%#v", vm.EffectiveRole)
+	}
+
+	changes = []types.PropertyChange{
+		{Name: "config", Op: types.PropertyChangeOpIndirectRemove},
+		{Name: "guest.net[0]", Op: types.PropertyChangeOpRemove},
+		{Name: "summary.config", Op: types.PropertyChangeOpRemove},
+	}
+
+	if err := ApplyPropertyChange(&vm, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	if vm.Config != nil {
+		t.Errorf("config=%#v", vm.Config)
+	}
+}
+
+func TestApplyPropertyChangeTypedNil(t *testing.T) {
+	vm := applyVM()
+
+	disk := vm.Config.Hardware.Device[1].(*types.VirtualDisk)
+	disk.Backing = (*types.VirtualDiskFlatVer2BackingInfo)(nil)
+
+	remove := []types.PropertyChange{
+		{Name: "config.hardware.device[2000].backing.fileName", Op: types.PropertyChangeOpRemove},
+	}
+
+	if err := ApplyPropertyChange(&vm, remove); err != nil {
+		t.Fatal(err)
+	}
+
+	if b := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); b != nil {
+		t.Errorf("backing=%#v", b)
+	}
+
+	assign := []types.PropertyChange{
+		{Name: "config.hardware.device[2000].backing.fileName", Op: types.PropertyChangeOpAssign, Val: "[datastore2] vm-001/disk.vmdk"},
+	}
+
+	if err := ApplyPropertyChange(&vm, assign); err != nil {
+		t.Fatal(err)
+	}
+
+	b := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if b == nil || b.FileName != "[datastore2] vm-001/disk.vmdk" {
+		t.Errorf("backing=%#v", b)
+	}
+}
+
+func TestApplyDiff(t *testing.T) {
+	a := applyVM()
+	b := applyVM()
+
+	b.Config.Hardware.NumCPU = 2
+	b.Config.Hardware.Device[1].GetVirtualDevice().UnitNumber = new(int32)
+	b.Config.Hardware.Device = append(b.Config.Hardware.Device[1:], &types.VirtualCdrom{VirtualDevice: types.VirtualDevice{Key: 3000}})
+	b.Config.ExtraConfig[0].GetOptionValue().Value = int32(1)
+	b.Config.Tools = &types.ToolsConfigInfo{ToolsVersion: 1}
+
+	changes, err := types.Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ApplyPropertyChange(&a, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("%#v", changes)
+	}
+}
+
+func TestApplyPropertyChangeErrors(t *testing.T) {
+	tests := []string{
+		"config.",
+		"config..name",
+		"config.hardware.device[2000",
+		`config.extraConfig["guestinfo.a].value`,
+		"config.hardware.device[9999].key",
+		"config.name[0]",
+		"name.foo",
+	}
+
+	for _, name := range tests {
+		vm := applyVM()
+		err := ApplyPropertyChange(&vm, []types.PropertyChange{{Name: name, Op: types.PropertyChangeOpAssign, Val: "x"}})
+		if err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+
+	if err := ApplyPropertyChange(applyVM(), nil); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mo
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// ObjectContentStream decodes the returnval elements of a RetrievePropertiesResponse
+// one at a time, calling the func with each ObjectContent as soon as it has been parsed.
+// Unlike decoding into types.RetrievePropertiesResponse, the response is never held in
+// memory as a whole. If the func returns an error, decoding stops and the error is returned.
+type ObjectContentStream func(types.ObjectContent) error
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (fn ObjectContentStream) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "returnval" {
+				if err = d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var content types.ObjectContent
+			if err = d.DecodeElement(&content, &t); err != nil {
+				return err
+			}
+
+			if err = fn(content); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type retrievePropertiesStreamBody struct {
+	Req    *types.RetrieveProperties `xml:"urn:vim25 RetrieveProperties,omitempty"`
+	Res    *ObjectContentStream      `xml:"urn:vim25 RetrievePropertiesResponse,omitempty"`
+	Fault_ *soap.Fault               `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *retrievePropertiesStreamBody) Fault() *soap.Fault { return b.Fault_ }
+
+// Reset clears the fault of a failed attempt for soap.ResetResponse, keeping the stream
+// such that the response to a request sent again is passed to the same func.
+func (b *retrievePropertiesStreamBody) Reset() { b.Fault_ = nil }
+
+// RetrievePropertiesStream calls the RetrieveProperties method with the specified
+// request, calling fn with each ObjectContent of the response as it is decoded.
+// Use ObjectContentToType within fn to convert each ObjectContent to a managed object type.
+// This allows large responses to be processed without decoding them into memory as a whole.
+// See property.Collector.RetrieveStream for use with a vim25.Client.
+//
+// As fn is called during decoding, a RoundTripper that sends the request again, such as
+// vim25.Retry or session.Reauth, calls fn with the objects of each attempt. When an attempt
+// fails part way through the response, for example on a connection reset, fn sees the
+// objects decoded before the failure again.
+func RetrievePropertiesStream(ctx context.Context, r soap.RoundTripper, req types.RetrieveProperties, fn func(types.ObjectContent) error) error {
+	var reqBody, resBody retrievePropertiesStreamBody
+
+	stream := ObjectContentStream(fn)
+	reqBody.Req = &req
+	resBody.Res = &stream
+
+	return r.RoundTrip(ctx, &reqBody, &resBody)
+}
@@ -0,0 +1,223 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+var fixtures = []string{
+	"cluster_host_property",
+	"hostsystem_list_name_property",
+	"nested_property",
+	"not_authenticated_fault",
+	"pointer_property",
+}
+
+// fixture returns the named fixture with its returnval elements repeated n times,
+// simulating a response for a large inventory.
+func fixture(name string, n int) []byte {
+	b, err := ioutil.ReadFile("fixtures/" + name + ".xml")
+	if err != nil {
+		panic(err)
+	}
+
+	start := bytes.Index(b, []byte("<returnval>"))
+	end := bytes.LastIndex(b, []byte("</returnval>")) + len("</returnval>")
+
+	var buf bytes.Buffer
+	buf.Write(b[:start])
+	for i := 0; i < n; i++ {
+		buf.Write(b[start:end])
+	}
+	buf.Write(b[end:])
+
+	return buf.Bytes()
+}
+
+func decodeStream(b []byte, fn func(types.ObjectContent) error) error {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	dec.TypeFunc = types.TypeFunc()
+	stream := ObjectContentStream(fn)
+	return dec.Decode(&stream)
+}
+
+func TestObjectContentStream(t *testing.T) {
+	for _, name := range fixtures {
+		res := load("fixtures/" + name + ".xml")
+
+		var content []types.ObjectContent
+		err := decodeStream(fixture(name, 1), func(c types.ObjectContent) error {
+			content = append(content, c)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(content, res.Returnval) {
+			t.Errorf("%s: %#v", name, content)
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := decodeStream(fixture("nested_property", 10), func(types.ObjectContent) error {
+		n++
+		if n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Errorf("err=%v, n=%d", err, n)
+	}
+}
+
+// fixtureRoundTripper responds to any request with the given RetrievePropertiesResponse.
+type fixtureRoundTripper []byte
+
+func (b fixtureRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	if _, err := xml.Marshal(req); err != nil {
+		return err
+	}
+
+	// Strip the xml header and wrap the response in a soap envelope
+	body := b[bytes.Index(b, []byte("<RetrievePropertiesResponse")):]
+	env := `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body>` + string(body) + `</Body></Envelope>`
+
+	dec := xml.NewDecoder(bytes.NewReader([]byte(env)))
+	dec.TypeFunc = types.TypeFunc()
+	return dec.Decode(&soap.Envelope{Body: res})
+}
+
+func TestRetrievePropertiesStream(t *testing.T) {
+	rt := fixtureRoundTripper(fixture("hostsystem_list_name_property", 3))
+
+	var hosts []HostSystem
+	err := RetrievePropertiesStream(context.Background(), rt, types.RetrieveProperties{}, func(c types.ObjectContent) error {
+		v, err := ObjectContentToType(c)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, v.(HostSystem))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expect []HostSystem
+	if err = LoadRetrievePropertiesResponse(load("fixtures/hostsystem_list_name_property.xml"), &expect); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hosts) != 3*len(expect) || !reflect.DeepEqual(hosts[:len(expect)], expect) {
+		t.Errorf("hosts=%#v", hosts)
+	}
+}
+
+// resendRoundTripper sends each request twice, resetting the response in between.
+type resendRoundTripper struct {
+	soap.RoundTripper
+}
+
+func (r resendRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	if err := r.RoundTripper.RoundTrip(ctx, req, res); err != nil {
+		return err
+	}
+
+	soap.ResetResponse(res)
+
+	return r.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+func TestRetrievePropertiesStreamResend(t *testing.T) {
+	rt := resendRoundTripper{fixtureRoundTripper(fixture("hostsystem_list_name_property", 1))}
+
+	n := 0
+	err := RetrievePropertiesStream(context.Background(), rt, types.RetrieveProperties{}, func(types.ObjectContent) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expect []HostSystem
+	if err = LoadRetrievePropertiesResponse(load("fixtures/hostsystem_list_name_property.xml"), &expect); err != nil {
+		t.Fatal(err)
+	}
+
+	// fn sees the objects of both attempts
+	if n != 2*len(expect) {
+		t.Errorf("n=%d", n)
+	}
+}
+
+const benchmarkObjects = 1000
+
+// BenchmarkDecodeRetrievePropertiesResponse decodes each fixture response in full,
+// as soap.Client does for methods.RetrieveProperties.
+func BenchmarkDecodeRetrievePropertiesResponse(b *testing.B) {
+	for _, name := range fixtures {
+		data := fixture(name, benchmarkObjects)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+
+			for i := 0; i < b.N; i++ {
+				var res types.RetrievePropertiesResponse
+				dec := xml.NewDecoder(bytes.NewReader(data))
+				dec.TypeFunc = types.TypeFunc()
+				if err := dec.Decode(&res); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkObjectContentStream decodes the same responses one ObjectContent at a time,
+// as RetrievePropertiesStream does.
+func BenchmarkObjectContentStream(b *testing.B) {
+	for _, name := range fixtures {
+		data := fixture(name, benchmarkObjects)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+
+			for i := 0; i < b.N; i++ {
+				err := decodeStream(data, func(types.ObjectContent) error { return nil })
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
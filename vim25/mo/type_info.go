@@ -62,6 +62,27 @@ func typeInfoForType(tname string) *typeInfo {
 	return ti
 }
 
+// PropertyType returns the type of the property path of the managed object type kind,
+// for example "runtime.powerState" of "VirtualMachine".  If path is empty, the type of
+// kind itself is returned.  The ok result is false if either kind or path is unknown.
+func PropertyType(kind string, path string) (reflect.Type, bool) {
+	typ, ok := t[kind]
+	if !ok {
+		return nil, false
+	}
+
+	if path == "" {
+		return typ, true
+	}
+
+	fi, ok := typeInfoForType(kind).props[path]
+	if !ok {
+		return nil, false
+	}
+
+	return typ.FieldByIndex(fi).Type, true
+}
+
 func newTypeInfo(typ reflect.Type) *typeInfo {
 	t := typeInfo{
 		typ:   typ,
@@ -19,6 +19,8 @@ package mo
 import (
 	"reflect"
 	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func TestLoadAll(*testing.T) {
@@ -27,6 +29,39 @@ func TestLoadAll(*testing.T) {
 	}
 }
 
+func TestPropertyType(t *testing.T) {
+	tests := []struct {
+		kind string
+		path string
+		typ  interface{}
+	}{
+		{"VirtualMachine", "", VirtualMachine{}},
+		{"VirtualMachine", "name", ""},
+		{"VirtualMachine", "runtime.powerState", types.VirtualMachinePowerStatePoweredOn},
+		{"VirtualMachine", "resourcePool", &types.ManagedObjectReference{}},
+		{"ResourcePool", "vm", []types.ManagedObjectReference{}},
+		{"VirtualApp", "vm", []types.ManagedObjectReference{}},
+		{"VirtualMachine", "runtime.nope", nil},
+		{"VirtualMachine", "self", nil},
+		{"Nope", "", nil},
+		{"Nope", "name", nil},
+	}
+
+	for _, test := range tests {
+		typ, ok := PropertyType(test.kind, test.path)
+		if test.typ == nil {
+			if ok {
+				t.Errorf("%s.%s: %s", test.kind, test.path, typ)
+			}
+			continue
+		}
+
+		if !ok || typ != reflect.TypeOf(test.typ) {
+			t.Errorf("%s.%s: %v", test.kind, test.path, typ)
+		}
+	}
+}
+
 // The virtual machine managed object has about 500 nested properties.
 // It's likely to be indicative of the function's performance in general.
 func BenchmarkLoadVirtualMachine(b *testing.B) {
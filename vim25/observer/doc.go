@@ -0,0 +1,35 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package observer contains soap.Observer implementations that adapt the
+per call details of SOAP round trips to logging, tracing and metrics systems.
+
+None of the adapters depend on a specific library. Instead, each defines a
+minimal interface or function type which can be implemented with a few lines
+of code for a given system. For example, with Prometheus:
+
+	requests := prometheus.NewCounterVec(opts, []string{"method", "status"})
+	duration := prometheus.NewHistogramVec(opts, []string{"method"})
+
+	c.AddObserver(&observer.Metrics{
+		Requests: func(labels ...string) { requests.WithLabelValues(labels...).Inc() },
+		Duration: func(v float64, labels ...string) { duration.WithLabelValues(labels...).Observe(v) },
+	})
+
+Where c is the *soap.Client used by a vim25.Client.
+*/
+package observer
@@ -0,0 +1,201 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package observer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Logger is implemented by structured loggers that accept alternating keys and values.
+type Logger interface {
+	Log(keyvals ...interface{}) error
+}
+
+type logger struct {
+	l Logger
+}
+
+// Log returns a soap.Observer that logs each round trip to the given Logger.
+func Log(l Logger) soap.Observer {
+	return &logger{l}
+}
+
+func (l *logger) Start(ctx context.Context, call *soap.Call) context.Context {
+	return ctx
+}
+
+func (l *logger) End(ctx context.Context, call *soap.Call) {
+	keyvals := []interface{}{
+		"method", call.Method,
+		"duration", call.Duration,
+		"status", call.StatusCode,
+		"request_size", call.RequestSize,
+		"response_size", call.ResponseSize,
+	}
+
+	if call.This.Value != "" {
+		keyvals = append(keyvals, "this", call.This.String())
+	}
+
+	if call.OperationID != "" {
+		keyvals = append(keyvals, "operation_id", call.OperationID)
+	}
+
+	if call.Fault != "" {
+		keyvals = append(keyvals, "fault", call.Fault)
+	}
+
+	if call.Err != nil {
+		keyvals = append(keyvals, "error", call.Err.Error())
+	}
+
+	_ = l.l.Log(keyvals...)
+}
+
+// Span is implemented by tracing spans, such as those of OpenTelemetry or OpenTracing.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(err error)
+	End()
+}
+
+// Tracer is implemented by tracers, starting a Span as a child of any span in ctx.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type spanKey struct{}
+
+type tracer struct {
+	t Tracer
+}
+
+// Trace returns a soap.Observer that starts a Span named "vim25.<method>" for
+// each round trip, with attributes describing the call.
+func Trace(t Tracer) soap.Observer {
+	return &tracer{t}
+}
+
+func (t *tracer) Start(ctx context.Context, call *soap.Call) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, span := t.t.Start(ctx, "vim25."+call.Method)
+
+	span.SetAttribute("vim.method", call.Method)
+	if call.This.Value != "" {
+		span.SetAttribute("vim.this", call.This.String())
+	}
+
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func (t *tracer) End(ctx context.Context, call *soap.Call) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+
+	if call.OperationID != "" {
+		span.SetAttribute("vim.operation_id", call.OperationID)
+	}
+	span.SetAttribute("http.status_code", call.StatusCode)
+	span.SetAttribute("http.response_size", call.ResponseSize)
+
+	if call.Fault != "" {
+		span.SetAttribute("vim.fault", call.Fault)
+	}
+
+	if call.Err != nil {
+		span.SetError(call.Err)
+	}
+
+	span.End()
+}
+
+// CounterFunc increments a counter with the given label values.
+type CounterFunc func(labels ...string)
+
+// HistogramFunc records a value in a histogram with the given label values.
+type HistogramFunc func(value float64, labels ...string)
+
+// Metrics is a soap.Observer that records counters and histograms for each
+// round trip. Any of the fields may be nil.
+type Metrics struct {
+	// Requests is incremented with labels: method, status
+	Requests CounterFunc
+
+	// Faults is incremented with labels: method, fault
+	Faults CounterFunc
+
+	// Duration observes the round trip time in seconds, with labels: method
+	Duration HistogramFunc
+
+	// ResponseSize observes the response size in bytes, with labels: method
+	ResponseSize HistogramFunc
+}
+
+func (m *Metrics) Start(ctx context.Context, call *soap.Call) context.Context {
+	return ctx
+}
+
+func (m *Metrics) End(ctx context.Context, call *soap.Call) {
+	if m.Requests != nil {
+		m.Requests(call.Method, strconv.Itoa(call.StatusCode))
+	}
+
+	if m.Faults != nil && call.Fault != "" {
+		m.Faults(call.Method, call.Fault)
+	}
+
+	if m.Duration != nil {
+		m.Duration(call.Duration.Seconds(), call.Method)
+	}
+
+	if m.ResponseSize != nil && call.StatusCode != 0 {
+		m.ResponseSize(float64(call.ResponseSize), call.Method)
+	}
+}
+
+type operationID struct {
+	prefix string
+	n      uint64
+}
+
+// OperationID returns a soap.Observer that sets an operation ID on each round
+// trip that does not already have one, using the given prefix and a sequence number.
+// The ID is sent to vCenter, which includes it in the vpxd logs.
+// This observer should be added before others, such that they see the ID.
+func OperationID(prefix string) soap.Observer {
+	return &operationID{prefix: prefix}
+}
+
+func (o *operationID) Start(ctx context.Context, call *soap.Call) context.Context {
+	if call.OperationID == "" {
+		call.OperationID = fmt.Sprintf("%s-%d", o.prefix, atomic.AddUint64(&o.n, 1))
+	}
+
+	return ctx
+}
+
+func (o *operationID) End(ctx context.Context, call *soap.Call) {}
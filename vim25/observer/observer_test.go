@@ -0,0 +1,181 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package observer_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/observer"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+type testLogger struct {
+	lines []map[string]interface{}
+}
+
+func (l *testLogger) Log(keyvals ...interface{}) error {
+	m := make(map[string]interface{})
+	for i := 0; i < len(keyvals); i += 2 {
+		m[keyvals[i].(string)] = keyvals[i+1]
+	}
+	l.lines = append(l.lines, m)
+	return nil
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) SetError(err error)                         { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, observer.Span) {
+	s := &testSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+type testMetrics struct {
+	sync.Mutex
+	counts map[string]int
+	values map[string][]float64
+}
+
+func (m *testMetrics) counter(name string) observer.CounterFunc {
+	return func(labels ...string) {
+		m.Lock()
+		m.counts[name+"{"+strings.Join(labels, ",")+"}"]++
+		m.Unlock()
+	}
+}
+
+func (m *testMetrics) histogram(name string) observer.HistogramFunc {
+	return func(v float64, labels ...string) {
+		m.Lock()
+		key := name + "{" + strings.Join(labels, ",") + "}"
+		m.values[key] = append(m.values[key], v)
+		m.Unlock()
+	}
+}
+
+func TestObservers(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.ESX()
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	sc := soap.NewClient(s.URL, true)
+
+	c, err := vim25.NewClient(ctx, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := new(testLogger)
+	tracer := new(testTracer)
+	metrics := &testMetrics{counts: make(map[string]int), values: make(map[string][]float64)}
+
+	sc.AddObserver(observer.OperationID("test"))
+	sc.AddObserver(observer.Log(logger))
+	sc.AddObserver(observer.Trace(tracer))
+	sc.AddObserver(&observer.Metrics{
+		Requests:     metrics.counter("requests"),
+		Faults:       metrics.counter("faults"),
+		Duration:     metrics.histogram("duration"),
+		ResponseSize: metrics.histogram("size"),
+	})
+
+	// Not logged in yet
+	_, err = methods.GetCurrentTime(ctx, c)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	err = session.NewManager(c).Login(ctx, s.URL.User)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = methods.GetCurrentTime(soap.WithOperationID(ctx, "mine"), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.lines) != 3 {
+		t.Fatalf("logged %d lines", len(logger.lines))
+	}
+
+	expect := []map[string]interface{}{
+		{"method": "CurrentTime", "status": 500, "fault": "NotAuthenticated", "operation_id": "test-1", "this": "ServiceInstance:ServiceInstance"},
+		{"method": "Login", "status": 200, "operation_id": "test-2"},
+		{"method": "CurrentTime", "status": 200, "operation_id": "mine"},
+	}
+
+	for i, line := range logger.lines {
+		for key, val := range expect[i] {
+			if line[key] != val {
+				t.Errorf("%d: %s=%v, expected %v", i, key, line[key], val)
+			}
+		}
+
+		if line["response_size"].(int64) == 0 {
+			t.Errorf("%d: response_size=0", i)
+		}
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("traced %d spans", len(tracer.spans))
+	}
+
+	span := tracer.spans[0]
+	if span.name != "vim25.CurrentTime" || !span.ended || span.err == nil || span.attrs["vim.fault"] != "NotAuthenticated" {
+		t.Errorf("span=%#v", span)
+	}
+
+	if metrics.counts["requests{CurrentTime,500}"] != 1 || metrics.counts["requests{CurrentTime,200}"] != 1 {
+		t.Errorf("counts=%v", metrics.counts)
+	}
+
+	if metrics.counts["faults{CurrentTime,NotAuthenticated}"] != 1 {
+		t.Errorf("counts=%v", metrics.counts)
+	}
+
+	if len(metrics.values["duration{CurrentTime}"]) != 2 || len(metrics.values["size{Login}"]) != 1 {
+		t.Errorf("values=%v", metrics.values)
+	}
+}
@@ -14,10 +14,8 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package progress
-
 /*
-The progress package contains functionality to deal with progress reporting.
+Package progress contains functionality to deal with progress reporting.
 The functionality is built to serve progress reporting for infrastructure
 operations when talking the vSphere API, but is generic enough to be used
 elsewhere.
@@ -30,3 +28,4 @@ This semantic makes it easy to keep track of multiple progress report channels;
 they are only created when Sink() is called and assumed closed when any
 function that receives a Sinker parameter returns.
 */
+package progress
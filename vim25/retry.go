@@ -18,8 +18,10 @@ package vim25
 
 import (
 	"context"
+	"math/rand"
 	"net"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/vmware/govmomi/vim25/soap"
@@ -27,39 +29,245 @@ import (
 
 type RetryFunc func(err error) (retry bool, delay time.Duration)
 
+// RetryPolicy returns a new RetryFunc for each call made through a
+// RoundTripper created with RetryWithPolicy, such that state like the
+// number of attempts or elapsed time is tracked per call.
+type RetryPolicy func() RetryFunc
+
 // TemporaryNetworkError returns a RetryFunc that retries up to a maximum of n
 // times, only if the error returned by the RoundTrip function is a temporary
-// network error (for example: a connect timeout).
+// network error (for example: a connect timeout). The count is kept by the
+// returned RetryFunc, such that it is shared by all calls made through a
+// RoundTripper created with Retry. Use RetryWithPolicy and a Backoff to limit
+// the number of attempts per call.
 func TemporaryNetworkError(n int) RetryFunc {
+	left := int64(n)
+
 	return func(err error) (retry bool, delay time.Duration) {
-		var nerr net.Error
-		var ok bool
+		// Never retry if this is not a temporary network error.
+		if !isTemporaryNetworkError(err) {
+			return false, 0
+		}
 
-		// Never retry if this is not a network error.
-		switch rerr := err.(type) {
-		case *url.Error:
-			if nerr, ok = rerr.Err.(net.Error); !ok {
-				return false, 0
-			}
-		case net.Error:
-			nerr = rerr
-		default:
+		// Don't retry if we're out of tries.
+		if atomic.AddInt64(&left, -1) <= 0 {
 			return false, 0
 		}
 
-		if !nerr.Temporary() {
+		return true, 0
+	}
+}
+
+// retryTemporaryNetworkError is a RetryFunc that retries temporary network
+// errors without limit, leaving the number of attempts to a Backoff.
+func retryTemporaryNetworkError(err error) (bool, time.Duration) {
+	return isTemporaryNetworkError(err), 0
+}
+
+// isTemporaryNetworkError returns true if err is a temporary net.Error,
+// or a *url.Error wrapping one.
+func isTemporaryNetworkError(err error) bool {
+	var nerr net.Error
+	var ok bool
+
+	switch rerr := err.(type) {
+	case *url.Error:
+		if nerr, ok = rerr.Err.(net.Error); !ok {
+			return false
+		}
+	case net.Error:
+		nerr = rerr
+	default:
+		return false
+	}
+
+	return nerr.Temporary()
+}
+
+// RetryOnFault returns a RetryFunc that retries if the error returned by the
+// RoundTrip function is a SOAP or vim fault of one of the given type names,
+// or of a type that embeds one of them (for example: "HostCommunication"
+// also matches HostNotConnected).
+func RetryOnFault(names ...string) RetryFunc {
+	category := &soap.FaultCategory{Name: "retry", Faults: names}
+
+	return func(err error) (retry bool, delay time.Duration) {
+		var fault interface{}
+
+		switch {
+		case soap.IsSoapFault(err):
+			fault = soap.ToSoapFault(err).VimFault()
+		case soap.IsVimFault(err):
+			fault = soap.ToVimFault(err)
+		default:
 			return false, 0
 		}
 
-		// Don't retry if we're out of tries.
-		if n--; n <= 0 {
+		return category.Match(fault), 0
+	}
+}
+
+// RetryOnStatus returns a RetryFunc that retries if the error returned by the
+// RoundTrip function is a *soap.StatusError with one of the given codes.
+func RetryOnStatus(codes ...int) RetryFunc {
+	return func(err error) (retry bool, delay time.Duration) {
+		serr, ok := err.(*soap.StatusError)
+		if !ok {
 			return false, 0
 		}
 
-		return true, 0
+		for _, code := range codes {
+			if serr.StatusCode == code {
+				return true, 0
+			}
+		}
+
+		return false, 0
 	}
 }
 
+// RetryAny returns a RetryFunc that retries if any of the given functions
+// returns true. The delay of the first such function is used.
+func RetryAny(fns ...RetryFunc) RetryFunc {
+	return func(err error) (retry bool, delay time.Duration) {
+		for _, fn := range fns {
+			if retry, delay = fn(err); retry {
+				return
+			}
+		}
+
+		return false, 0
+	}
+}
+
+// DefaultRetryFunc returns a RetryFunc that retries temporary network errors,
+// the faults of soap.ErrTransient and HTTP 503 (Service Unavailable) responses.
+// The number of attempts and delay between them is left to a Backoff.
+func DefaultRetryFunc() RetryFunc {
+	return RetryAny(
+		retryTemporaryNetworkError,
+		RetryOnFault(soap.ErrTransient.Faults...),
+		RetryOnStatus(503),
+	)
+}
+
+// Backoff implements exponential backoff with jitter for a RetryFunc.
+type Backoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+
+	// Max caps the delay between attempts, if non-zero.
+	Max time.Duration
+
+	// Multiplier is applied to the delay after each attempt, defaults to 2.
+	Multiplier float64
+
+	// Jitter is the fraction (0..1) by which each delay is randomized.
+	Jitter float64
+
+	// MaxElapsed stops retrying once the time since the first attempt
+	// (plus the next delay) exceeds this value, if non-zero.
+	MaxElapsed time.Duration
+
+	// MaxAttempts stops retrying after this many attempts, if non-zero.
+	MaxAttempts int
+}
+
+// DefaultBackoff is used by RetryWithPolicy when no policy is specified.
+var DefaultBackoff = Backoff{
+	Initial:     250 * time.Millisecond,
+	Max:         10 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.2,
+	MaxElapsed:  2 * time.Minute,
+	MaxAttempts: 10,
+}
+
+// Policy returns a RetryPolicy that retries when fn returns true, within the
+// limits of this Backoff. The delay returned by fn, if any, is used in
+// place of the computed delay. The attempts and elapsed time are tracked per
+// call, while fn is shared by all calls and must be safe for concurrent use.
+func (b Backoff) Policy(fn RetryFunc) RetryPolicy {
+	return func() RetryFunc {
+		start := time.Now()
+		delay := b.Initial
+		attempts := 0
+
+		multiplier := b.Multiplier
+		if multiplier <= 0 {
+			multiplier = 2
+		}
+
+		return func(err error) (bool, time.Duration) {
+			retry, d := fn(err)
+			if !retry {
+				return false, 0
+			}
+
+			// Don't retry if we're out of tries.
+			if attempts++; b.MaxAttempts > 0 && attempts >= b.MaxAttempts {
+				return false, 0
+			}
+
+			if d == 0 {
+				d = delay
+				if b.Jitter > 0 {
+					d += time.Duration(b.Jitter * float64(d) * (2*rand.Float64() - 1))
+				}
+
+				delay = time.Duration(float64(delay) * multiplier)
+				if b.Max > 0 && delay > b.Max {
+					delay = b.Max
+				}
+			}
+
+			if b.MaxElapsed > 0 && time.Since(start)+d > b.MaxElapsed {
+				return false, 0
+			}
+
+			return true, d
+		}
+	}
+}
+
+// IdempotentMethods is the set of vim25 methods that RetryWithPolicy will
+// retry. These methods do not modify server side state, such that invoking
+// them more than once has the same effect as invoking them once.
+var IdempotentMethods = map[string]bool{
+	"RetrieveServiceContent":         true,
+	"RetrieveProperties":             true,
+	"RetrievePropertiesEx":           true,
+	"ContinueRetrievePropertiesEx":   true,
+	"CurrentTime":                    true,
+	"SessionIsActive":                true,
+	"FindByDatastorePath":            true,
+	"FindByDnsName":                  true,
+	"FindByInventoryPath":            true,
+	"FindByIp":                       true,
+	"FindByUuid":                     true,
+	"FindAllByDnsName":               true,
+	"FindAllByIp":                    true,
+	"FindAllByUuid":                  true,
+	"FindChild":                      true,
+	"QueryAvailablePerfMetric":       true,
+	"QueryPerf":                      true,
+	"QueryPerfCounter":               true,
+	"QueryPerfProviderSummary":       true,
+	"QueryOptions":                   true,
+	"QueryConfigOption":              true,
+	"QueryConfigOptionDescriptor":    true,
+	"QueryConfigTarget":              true,
+	"QueryTargetCapabilities":        true,
+	"QueryVirtualDiskUuid":           true,
+	"QueryVirtualDiskGeometry":       true,
+	"BrowseDiagnosticLog":            true,
+	"QueryDescriptions":              true,
+	"ValidateCredentialsInGuest":     true,
+	"ListFilesInGuest":               true,
+	"ListProcessesInGuest":           true,
+	"ReadEnvironmentVariableInGuest": true,
+}
+
 type retry struct {
 	roundTripper soap.RoundTripper
 
@@ -67,6 +275,12 @@ type retry struct {
 	// It returns whether or not to retry, and if so, how long to
 	// delay before retrying.
 	fn RetryFunc
+
+	// policy, if set, is used instead of fn to create a RetryFunc per call.
+	policy RetryPolicy
+
+	// idempotent, if set, restricts retries to these methods.
+	idempotent map[string]bool
 }
 
 // Retry wraps the specified soap.RoundTripper and invokes the
@@ -83,7 +297,34 @@ func Retry(roundTripper soap.RoundTripper, fn RetryFunc) soap.RoundTripper {
 	return r
 }
 
+// RetryWithPolicy wraps the specified soap.RoundTripper, retrying calls to
+// IdempotentMethods as directed by a RetryFunc created by policy for each call.
+// If policy is nil, DefaultBackoff.Policy(DefaultRetryFunc()) is used.
+// Calls to other methods are never retried.
+func RetryWithPolicy(roundTripper soap.RoundTripper, policy RetryPolicy) soap.RoundTripper {
+	if policy == nil {
+		policy = DefaultBackoff.Policy(DefaultRetryFunc())
+	}
+
+	r := &retry{
+		roundTripper: roundTripper,
+		policy:       policy,
+		idempotent:   IdempotentMethods,
+	}
+
+	return r
+}
+
 func (r *retry) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	if r.idempotent != nil && !r.idempotent[soap.MethodName(req)] {
+		return r.roundTripper.RoundTrip(ctx, req, res)
+	}
+
+	fn := r.fn
+	if r.policy != nil {
+		fn = r.policy()
+	}
+
 	var err error
 
 	for {
@@ -93,8 +334,13 @@ func (r *retry) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
 		}
 
 		// Invoke retry function to see if another attempt should be made.
-		if retry, delay := r.fn(err); retry {
-			time.Sleep(delay)
+		if retry, delay := fn(err); retry {
+			if !sleep(ctx, delay) {
+				break
+			}
+
+			// Clear any fault set by the previous attempt.
+			soap.ResetResponse(res)
 			continue
 		}
 
@@ -103,3 +349,31 @@ func (r *retry) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
 
 	return err
 }
+
+// sleep waits for the given delay, returning false if ctx is done first.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if ctx == nil {
+		time.Sleep(delay)
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	default:
+	}
+
+	if delay <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
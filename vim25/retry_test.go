@@ -18,9 +18,13 @@ package vim25
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 type tempError struct{}
@@ -36,15 +40,23 @@ func (nonTempError) Timeout() bool   { return false }
 func (nonTempError) Temporary() bool { return false }
 
 type fakeRoundTripper struct {
-	errs []error
+	errs  []error
+	calls int
 }
 
 func (f *fakeRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	f.calls++
 	err := f.errs[0]
 	f.errs = f.errs[1:]
 	return err
 }
 
+func faultError(fault types.AnyType) error {
+	f := &soap.Fault{}
+	f.Detail.Fault = fault
+	return soap.WrapSoapFault(f)
+}
+
 func TestRetry(t *testing.T) {
 	var tcs = []struct {
 		errs     []error
@@ -84,3 +96,139 @@ func TestRetry(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryOnFault(t *testing.T) {
+	fn := RetryAny(RetryOnFault(soap.ErrTransient.Faults...), RetryOnStatus(503))
+
+	var tcs = []struct {
+		err   error
+		retry bool
+	}{
+		{faultError(types.TaskInProgress{}), true},
+		{faultError(types.HostNotConnected{}), true},
+		{faultError(types.ConcurrentAccess{}), true},
+		{faultError(types.NotAuthenticated{}), false},
+		{faultError(nil), false},
+		{soap.WrapVimFault(&types.TaskInProgress{}), true},
+		{soap.WrapVimFault(&types.InvalidState{}), false},
+		{&soap.StatusError{StatusCode: 503, Status: "503 Service Unavailable"}, true},
+		{&soap.StatusError{StatusCode: 404, Status: "404 Not Found"}, false},
+		{nonTempError{}, false},
+	}
+
+	for i, tc := range tcs {
+		retry, _ := fn(tc.err)
+		if retry != tc.retry {
+			t.Errorf("%d: expected retry=%t for %s", i, tc.retry, tc.err)
+		}
+	}
+}
+
+func TestRetryWithPolicy(t *testing.T) {
+	policy := Backoff{Initial: time.Millisecond, MaxAttempts: 3}.Policy(DefaultRetryFunc())
+	busy := faultError(types.TaskInProgress{})
+
+	idempotent := &methods.RetrievePropertiesBody{Req: new(types.RetrieveProperties)}
+	other := &methods.PowerOnVM_TaskBody{Req: new(types.PowerOnVM_Task)}
+
+	var tcs = []struct {
+		req   soap.HasFault
+		errs  []error
+		calls int
+		err   error
+	}{
+		{idempotent, []error{busy, nil}, 2, nil},
+		{idempotent, []error{busy, busy, busy, nil}, 3, busy},
+		{idempotent, []error{nonTempError{}}, 1, nonTempError{}},
+		{other, []error{busy, nil}, 1, busy},
+	}
+
+	for i, tc := range tcs {
+		f := &fakeRoundTripper{errs: tc.errs}
+		rt := RetryWithPolicy(f, policy)
+
+		err := rt.RoundTrip(context.Background(), tc.req, tc.req)
+		if err != tc.err {
+			t.Errorf("%d: expected %v, got %v", i, tc.err, err)
+		}
+
+		if f.calls != tc.calls {
+			t.Errorf("%d: expected %d calls, got %d", i, tc.calls, f.calls)
+		}
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	busy := faultError(types.TaskInProgress{})
+	f := &fakeRoundTripper{errs: []error{busy, nil}}
+	rt := RetryWithPolicy(f, Backoff{Initial: time.Hour}.Policy(DefaultRetryFunc()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req := &methods.RetrievePropertiesBody{Req: new(types.RetrieveProperties)}
+
+	start := time.Now()
+	err := rt.RoundTrip(ctx, req, req)
+	if err != busy {
+		t.Errorf("expected %v, got %v", busy, err)
+	}
+
+	if time.Since(start) > time.Minute {
+		t.Error("sleep was not interrupted by context")
+	}
+
+	if f.calls != 1 {
+		t.Errorf("expected 1 call, got %d", f.calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 3 * time.Second, MaxElapsed: 6 * time.Second}
+	fn := b.Policy(RetryOnStatus(503))()
+	err := &soap.StatusError{StatusCode: 503}
+
+	expect := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, e := range expect {
+		retry, delay := fn(err)
+		if !retry || delay != e {
+			t.Errorf("%d: expected retry with %s, got %t, %s", i, e, retry, delay)
+		}
+	}
+
+	// Next delay would exceed MaxElapsed
+	b.MaxElapsed = 2 * time.Second
+	fn = b.Policy(RetryOnStatus(503))()
+	fn(err)
+	if retry, _ := fn(err); retry {
+		t.Error("expected MaxElapsed to stop retries")
+	}
+}
+
+func TestRetryWithPolicyConcurrent(t *testing.T) {
+	policy := Backoff{MaxAttempts: 3}.Policy(DefaultRetryFunc())
+	req := &methods.RetrievePropertiesBody{Req: new(types.RetrieveProperties)}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each call is allowed MaxAttempts, regardless of the attempts made by other calls
+			f := &fakeRoundTripper{errs: []error{tempError{}, tempError{}, nil}}
+			errs <- RetryWithPolicy(f, policy).RoundTrip(context.Background(), req, new(methods.RetrievePropertiesBody))
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
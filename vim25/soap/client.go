@@ -26,6 +26,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net"
@@ -34,6 +35,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -52,6 +54,43 @@ type RoundTripper interface {
 	RoundTrip(ctx context.Context, req, res HasFault) error
 }
 
+// MethodName returns the vim25 method name of the given request body,
+// for example "RetrieveProperties" for a *methods.RetrievePropertiesBody.
+func MethodName(req HasFault) string {
+	v := reflect.ValueOf(req)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if f := v.FieldByName("Req"); f.IsValid() {
+		return f.Type().Elem().Name()
+	}
+
+	return strings.TrimSuffix(v.Type().Name(), "Body")
+}
+
+// ResetResponse clears any fault or result set in res, such that res can be reused
+// when a RoundTripper sends the request again after a failed attempt.
+// If res has a Reset method, it is called instead.
+func ResetResponse(res HasFault) {
+	if res == nil {
+		return
+	}
+
+	if r, ok := res.(interface {
+		Reset()
+	}); ok {
+		r.Reset()
+		return
+	}
+
+	v := reflect.ValueOf(res)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
 var DefaultVimNamespace = "urn:vim25"
 var DefaultVimVersion = "6.0"
 
@@ -64,8 +103,13 @@ type Client struct {
 	t *http.Transport
 	p *url.URL
 
-	hostsMu sync.Mutex
-	hosts   map[string]string
+	dialer Dialer
+
+	hostsMu    sync.Mutex
+	hosts      map[string]string
+	knownHosts string
+
+	o []Observer
 
 	Namespace string // Vim namespace
 	Version   string // Vim version
@@ -259,41 +303,67 @@ func (c *Client) dialTLS(network string, addr string) (net.Conn, error) {
 	// Would be nice if there was a tls.Config.Verify func,
 	// see tls.clientHandshakeState.doFullHandshake
 
-	conn, err := tls.Dial(network, addr, c.t.TLSClientConfig)
+	conn, err := c.tlsDial(network, addr, c.t.TLSClientConfig)
 
 	if err == nil {
 		return conn, nil
 	}
 
-	switch err.(type) {
-	case x509.UnknownAuthorityError:
-	case x509.HostnameError:
-	default:
+	if !isCertificateError(err) {
 		return nil, err
 	}
 
 	thumbprint := c.Thumbprint(addr)
-	if thumbprint == "" {
+	if thumbprint == "" && c.KnownHosts() == "" {
 		return nil, err
 	}
 
 	config := &tls.Config{InsecureSkipVerify: true}
-	conn, err = tls.Dial(network, addr, config)
+	conn, err = c.tlsDial(network, addr, config)
 	if err != nil {
 		return nil, err
 	}
 
 	cert := conn.ConnectionState().PeerCertificates[0]
 	peer := ThumbprintSHA1(cert)
+	if thumbprint == "" {
+		if err = c.trustOnFirstUse(addr, peer); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+
 	if thumbprint != peer {
 		_ = conn.Close()
 
-		return nil, fmt.Errorf("Host %q thumbprint does not match %q", addr, thumbprint)
+		return nil, &ThumbprintError{Host: addr, Known: thumbprint, Actual: peer}
 	}
 
 	return conn, nil
 }
 
+// isCertificateError returns true if err is, or wraps, an x509.UnknownAuthorityError or x509.HostnameError.
+// Newer versions of crypto/tls wrap verification errors in a tls.CertificateVerificationError.
+func isCertificateError(err error) bool {
+	for err != nil {
+		switch err.(type) {
+		case x509.UnknownAuthorityError, x509.HostnameError:
+			return true
+		}
+
+		u, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			return false
+		}
+		err = u.Unwrap()
+	}
+
+	return false
+}
+
 // splitHostPort is similar to net.SplitHostPort,
 // but rather than return error if there isn't a ':port',
 // return an empty string for the port.
@@ -319,34 +389,53 @@ func (c *Client) SetCertificate(cert tls.Certificate) {
 	t.TLSClientConfig.Certificates = []tls.Certificate{cert}
 
 	// Proxy to vCenter host on port 80
-	host, _ := splitHostPort(c.u.Host)
+	c.p = c.tunnelProxy()
+	t.Proxy = c.proxy
 
-	// Should be no reason to change the default port other than testing
-	key := "GOVMOMI_TUNNEL_PROXY_PORT"
+	// Rewrite url Host to use the sdk tunnel, required for a certificate request.
+	c.u.Host = sdkTunnel
+}
 
-	port := c.URL().Query().Get(key)
-	if port == "" {
-		port = os.Getenv(key)
-	}
+// Clone returns a new Client with the same URL, TLS, thumbprint and proxy configuration,
+// but with its own cookie jar and connections, such that it can be used for an independent session.
+func (c *Client) Clone() *Client {
+	n := NewClient(c.URL(), c.k)
+
+	n.t.TLSClientConfig = cloneTLSConfig(c.t.TLSClientConfig)
 
-	if port != "" {
-		host += ":" + port
+	c.hostsMu.Lock()
+	for host, thumbprint := range c.hosts {
+		n.hosts[host] = thumbprint
 	}
+	n.knownHosts = c.knownHosts
+	c.hostsMu.Unlock()
 
-	c.p = &url.URL{
-		Scheme: "http",
-		Host:   host,
+	if c.dialer != nil {
+		n.SetDialer(c.dialer)
 	}
-	t.Proxy = func(r *http.Request) (*url.URL, error) {
-		// Only sdk requests should be proxied
-		if r.URL.Path == "/sdk" {
-			return c.p, nil
-		}
-		return http.ProxyFromEnvironment(r)
+
+	if c.p != nil {
+		n.p = c.p
+		n.t.Proxy = n.proxy
 	}
 
-	// Rewrite url Host to use the sdk tunnel, required for a certificate request.
-	c.u.Host = sdkTunnel
+	n.Namespace = c.Namespace
+	n.Version = c.Version
+	n.UserAgent = c.UserAgent
+	n.o = append([]Observer(nil), c.o...)
+
+	return n
+}
+
+// NewServiceClient returns a Client for a service other than vim25, such as the STS,
+// on the same host and with the same configuration as Clone, but with the given URL path and namespace.
+func (c *Client) NewServiceClient(path string, namespace string) *Client {
+	n := c.Clone()
+
+	n.u.Path = path
+	n.Namespace = namespace
+
+	return n
 }
 
 func (c *Client) URL() *url.URL {
@@ -355,16 +444,20 @@ func (c *Client) URL() *url.URL {
 }
 
 type marshaledClient struct {
-	Cookies  []*http.Cookie
-	URL      *url.URL
-	Insecure bool
+	Cookies   []*http.Cookie
+	URL       *url.URL
+	Insecure  bool
+	Namespace string `json:",omitempty"`
+	Version   string `json:",omitempty"`
 }
 
 func (c *Client) MarshalJSON() ([]byte, error) {
 	m := marshaledClient{
-		Cookies:  c.Jar.Cookies(c.u),
-		URL:      c.u,
-		Insecure: c.k,
+		Cookies:   c.Jar.Cookies(c.u),
+		URL:       c.u,
+		Insecure:  c.k,
+		Namespace: c.Namespace,
+		Version:   c.Version,
 	}
 
 	return json.Marshal(m)
@@ -378,9 +471,20 @@ func (c *Client) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	if m.URL == nil {
+		return errors.New("soap: URL missing from JSON")
+	}
+
 	*c = *NewClient(m.URL, m.Insecure)
 	c.Jar.SetCookies(m.URL, m.Cookies)
 
+	if m.Namespace != "" {
+		c.Namespace = m.Namespace
+	}
+	if m.Version != "" {
+		c.Version = m.Version
+	}
+
 	return nil
 }
 
@@ -415,20 +519,58 @@ func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, err
 }
 
 func (c *Client) RoundTrip(ctx context.Context, reqBody, resBody HasFault) error {
+	if len(c.o) != 0 {
+		return c.observe(ctx, reqBody, resBody)
+	}
+
+	return c.roundTrip(ctx, reqBody, resBody, nil)
+}
+
+// roundTrip sends the request, recording response details in call if non-nil.
+func (c *Client) roundTrip(ctx context.Context, reqBody, resBody HasFault, call *Call) error {
 	var err error
 
-	reqEnv := Envelope{Body: reqBody}
+	reqEnv := Envelope{Header: contextHeader(ctx), Body: reqBody}
 	resEnv := Envelope{Body: resBody}
 
+	if id := OperationID(ctx); id != "" {
+		if reqEnv.Header == nil {
+			reqEnv.Header = new(Header)
+		}
+		reqEnv.Header.ID = id
+	}
+
 	// Create debugging context for this round trip
 	d := c.d.newRoundTrip()
 	if d.enabled() {
 		defer d.done()
 	}
 
-	b, err := xml.Marshal(reqEnv)
-	if err != nil {
-		panic(err)
+	var b []byte
+	soapAction := fmt.Sprintf("%s/%s", c.Namespace, c.Version)
+
+	if reqEnv.Header != nil {
+		if reqEnv.Header.Action != "" {
+			soapAction = reqEnv.Header.Action
+		}
+
+		if s, ok := reqEnv.Header.Security.(Signer); ok {
+			b, err = s.Sign(reqEnv)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if b == nil {
+		b, err = xml.Marshal(reqEnv)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if call != nil {
+		call.RequestSize = int64(len(xml.Header) + len(b))
 	}
 
 	rawReqBody := io.MultiReader(strings.NewReader(xml.Header), bytes.NewReader(b))
@@ -438,7 +580,6 @@ func (c *Client) RoundTrip(ctx context.Context, reqBody, resBody HasFault) error
 	}
 
 	req.Header.Set(`Content-Type`, `text/xml; charset="utf-8"`)
-	soapAction := fmt.Sprintf("%s/%s", c.Namespace, c.Version)
 	req.Header.Set(`SOAPAction`, soapAction)
 	if c.UserAgent != "" {
 		req.Header.Set(`User-Agent`, c.UserAgent)
@@ -467,13 +608,18 @@ func (c *Client) RoundTrip(ctx context.Context, reqBody, resBody HasFault) error
 	// Close response regardless of what happens next
 	defer res.Body.Close()
 
+	if call != nil {
+		call.StatusCode = res.StatusCode
+		res.Body = countReader{res.Body, &call.ResponseSize}
+	}
+
 	switch res.StatusCode {
 	case http.StatusOK:
 		// OK
 	case http.StatusInternalServerError:
 		// Error, but typically includes a body explaining the error
 	default:
-		return errors.New(res.Status)
+		return &StatusError{StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	dec := xml.NewDecoder(res.Body)
@@ -519,6 +665,9 @@ type Upload struct {
 	Headers       map[string]string
 	Ticket        *http.Cookie
 	Progress      progress.Sinker
+
+	// Hash, if set (for example: sha256.New()), is written with the content as it is uploaded.
+	Hash hash.Hash
 }
 
 var DefaultUpload = Upload{
@@ -540,6 +689,10 @@ func (c *Client) Upload(f io.Reader, u *url.URL, param *Upload) error {
 		}()
 	}
 
+	if param.Hash != nil {
+		f = io.TeeReader(f, param.Hash)
+	}
+
 	req, err := http.NewRequest(param.Method, u.String(), f)
 	if err != nil {
 		return err
@@ -599,6 +752,31 @@ type Download struct {
 	Headers  map[string]string
 	Ticket   *http.Cookie
 	Progress progress.Sinker
+
+	// Context, if set, is used to cancel the requests along with the delay between DownloadFile retries.
+	Context context.Context
+
+	// The following fields are used by DownloadFile only.
+
+	// Parallel is the number of concurrent range requests.
+	Parallel int
+
+	// ChunkSize is the size of each range request when Parallel > 1, defaults to DefaultChunkSize.
+	ChunkSize int64
+
+	// Resume continues the download from the end of an existing local file.
+	Resume bool
+
+	// Retries is the number of times a dropped connection is resumed, with an increasing delay between attempts.
+	Retries int
+
+	// Hash, if set, is written with the downloaded content.
+	// When Parallel > 1, the hash is computed from the local file once all ranges are downloaded.
+	Hash hash.Hash
+
+	// Checksum, if set, is the expected hex encoded Hash sum, defaulting Hash to SHA-256.
+	// A *ChecksumError is returned if the downloaded content does not match.
+	Checksum string
 }
 
 var DefaultDownload = Download{
@@ -612,6 +790,10 @@ func (c *Client) DownloadRequest(u *url.URL, param *Download) (*http.Response, e
 		return nil, err
 	}
 
+	if param.Context != nil {
+		req = req.WithContext(param.Context)
+	}
+
 	for k, v := range param.Headers {
 		req.Header.Add(k, v)
 	}
@@ -642,49 +824,3 @@ func (c *Client) Download(u *url.URL, param *Download) (io.ReadCloser, int64, er
 
 	return res.Body, res.ContentLength, nil
 }
-
-// DownloadFile GETs the given URL to a local file
-func (c *Client) DownloadFile(file string, u *url.URL, param *Download) error {
-	var err error
-	if param == nil {
-		param = &DefaultDownload
-	}
-
-	rc, contentLength, err := c.Download(u, param)
-	if err != nil {
-		return err
-	}
-	defer rc.Close()
-
-	var r io.Reader = rc
-
-	fh, err := os.Create(file)
-	if err != nil {
-		return err
-	}
-	defer fh.Close()
-
-	if param.Progress != nil {
-		pr := progress.NewReader(param.Progress, r, contentLength)
-		r = pr
-
-		// Mark progress reader as done when returning from this function.
-		defer func() {
-			pr.Done(err)
-		}()
-	}
-
-	_, err = io.Copy(fh, r)
-	if err != nil {
-		return err
-	}
-
-	// Assign error before returning so that it gets picked up by the deferred
-	// function marking the progress reader as done.
-	err = fh.Close()
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package soap
 
-import "testing"
+import (
+	"crypto/tls"
+	"net/url"
+	"testing"
+)
 
 func TestSplitHostPort(t *testing.T) {
 	tests := []struct {
@@ -41,3 +45,25 @@ func TestSplitHostPort(t *testing.T) {
 		}
 	}
 }
+
+func TestCloneTLSConfig(t *testing.T) {
+	u := &url.URL{Scheme: "https", Host: "127.0.0.1", Path: "/sdk"}
+
+	c := NewClient(u, false)
+	c.t.TLSClientConfig.MinVersion = tls.VersionTLS12
+
+	n := c.Clone()
+	if n.t.TLSClientConfig == c.t.TLSClientConfig {
+		t.Fatal("TLS config is shared")
+	}
+
+	if n.t.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion=%d", n.t.TLSClientConfig.MinVersion)
+	}
+
+	// Changes to the clone do not apply to the parent
+	n.SetCertificate(tls.Certificate{})
+	if len(c.t.TLSClientConfig.Certificates) != 0 {
+		t.Error("parent certificates changed")
+	}
+}
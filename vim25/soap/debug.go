@@ -60,8 +60,10 @@ func (d *debugRoundTrip) enabled() bool {
 }
 
 func (d *debugRoundTrip) done() {
-	for _, c := range d.cs {
-		c.Close()
+	// Close in reverse order, such that the request body is closed last.
+	// Providers such as debug.JSONProvider use this to detect the round trip is complete.
+	for i := len(d.cs) - 1; i >= 0; i-- {
+		d.cs[i].Close()
 	}
 }
 
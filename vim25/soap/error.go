@@ -18,11 +18,170 @@ package soap
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// FaultCategory is a sentinel error that matches, using errors.Is, any SOAP fault, vim fault
+// or task error whose fault is one of the Faults type names, or of a type that embeds one of them.
+// For example, errors.Is(err, soap.ErrNotFound) is true for a ManagedObjectNotFound fault.
+type FaultCategory struct {
+	Name   string
+	Faults []string
+}
+
+func (c *FaultCategory) Error() string {
+	return c.Name
+}
+
+// Match returns true if the given fault is of one of the category's fault types.
+func (c *FaultCategory) Match(fault interface{}) bool {
+	if fault == nil {
+		return false
+	}
+
+	typ := reflect.TypeOf(fault)
+
+	for _, name := range c.Faults {
+		if isFaultType(typ, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fault categories, matched using errors.Is.
+var (
+	ErrNotFound         = &FaultCategory{"not found", []string{"ManagedObjectNotFound", "FileNotFound", "NotFound"}}
+	ErrAlreadyExists    = &FaultCategory{"already exists", []string{"DuplicateName", "FileAlreadyExists", "AlreadyExists"}}
+	ErrNotAuthenticated = &FaultCategory{"not authenticated", []string{"NotAuthenticated", "InvalidLogin"}}
+	ErrNoPermission     = &FaultCategory{"no permission", []string{"NoPermission"}}
+	ErrInvalidState     = &FaultCategory{"invalid state", []string{"InvalidState"}}
+	ErrTransient        = &FaultCategory{"transient", []string{"TaskInProgress", "HostCommunication", "ConcurrentAccess"}}
+)
+
+// isFaultType returns true if typ is named name, or embeds a type named name.
+func isFaultType(typ reflect.Type, name string) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Name() == name {
+		return true
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous && isFaultType(f.Type, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFault implements errors.Is for fault errors
+func isFault(fault interface{}, target error) bool {
+	if c, ok := target.(*FaultCategory); ok {
+		return c.Match(fault)
+	}
+
+	return false
+}
+
+// toFault returns the given vim fault as a types.BaseMethodFault, or nil if it is not a fault.
+// Faults held by a types.AnyType, such as a SOAP fault detail, are values rather than pointers.
+func toFault(fault interface{}) types.BaseMethodFault {
+	if f, ok := fault.(types.BaseMethodFault); ok {
+		return f
+	}
+
+	v := reflect.ValueOf(fault)
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+
+	f, _ := p.Interface().(types.BaseMethodFault)
+	return f
+}
+
+// asFault implements errors.As for fault errors, where target is a pointer to
+// an interface implemented by the fault, such as types.BaseMethodFault or types.BaseInvalidState.
+func asFault(fault types.BaseMethodFault, target interface{}) bool {
+	if fault == nil {
+		return false
+	}
+
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Ptr || tv.IsNil() || tv.Elem().Kind() != reflect.Interface {
+		return false
+	}
+
+	fv := reflect.ValueOf(fault)
+	if !fv.Type().AssignableTo(tv.Elem().Type()) {
+		return false
+	}
+
+	tv.Elem().Set(fv)
+	return true
+}
+
+// FaultError is implemented by the errors returned for SOAP faults, vim faults and task errors,
+// for use with errors.As. Fault returns nil for a SOAP fault without a vim fault detail.
+type FaultError interface {
+	error
+	Fault() types.BaseMethodFault
+}
+
+// localizableMessage returns the message m, formatted with its arguments if the message text is not set.
+func localizableMessage(m types.LocalizableMessage) string {
+	if m.Message != "" {
+		return m.Message
+	}
+
+	if len(m.Arg) == 0 {
+		return m.Key
+	}
+
+	args := make([]string, len(m.Arg))
+	for i, arg := range m.Arg {
+		args[i] = fmt.Sprintf("%s=%v", arg.Key, arg.Value)
+	}
+
+	return fmt.Sprintf("%s (%s)", m.Key, strings.Join(args, ", "))
+}
+
+// FaultMessage appends the fault messages of the given fault to msg, formatted with their arguments,
+// skipping any already included, such as in the localized message of a SOAP fault or task error.
+func FaultMessage(msg string, fault types.AnyType) string {
+	f := toFault(fault)
+	if f == nil {
+		return msg
+	}
+
+	for _, m := range f.GetMethodFault().FaultMessage {
+		if s := localizableMessage(m); s != "" && !strings.Contains(msg, s) {
+			if msg != "" {
+				msg += "; "
+			}
+			msg += s
+		}
+	}
+
+	return msg
+}
+
 type regularError struct {
 	err error
 }
@@ -31,12 +190,32 @@ func (r regularError) Error() string {
 	return r.err.Error()
 }
 
+// Unwrap returns the underlying error.
+func (r regularError) Unwrap() error {
+	return r.err
+}
+
 type soapFaultError struct {
 	fault *Fault
 }
 
 func (s soapFaultError) Error() string {
-	return fmt.Sprintf("%s: %s", s.fault.Code, s.fault.String)
+	return FaultMessage(fmt.Sprintf("%s: %s", s.fault.Code, s.fault.String), s.fault.VimFault())
+}
+
+// Is implements errors.Is, matching a FaultCategory against the fault detail.
+func (s soapFaultError) Is(target error) bool {
+	return isFault(s.fault.VimFault(), target)
+}
+
+// As implements errors.As, assigning the fault detail to target if it implements the target interface.
+func (s soapFaultError) As(target interface{}) bool {
+	return asFault(s.Fault(), target)
+}
+
+// Fault returns the vim fault detail, if any.
+func (s soapFaultError) Fault() types.BaseMethodFault {
+	return toFault(s.fault.VimFault())
 }
 
 type vimFaultError struct {
@@ -49,13 +228,56 @@ func (v vimFaultError) Error() string {
 		typ = typ.Elem()
 	}
 
-	return typ.Name()
+	msg := FaultMessage("", v.fault)
+	if msg == "" {
+		return typ.Name()
+	}
+
+	return typ.Name() + ": " + msg
 }
 
 func (v vimFaultError) Fault() types.BaseMethodFault {
 	return v.fault
 }
 
+// Is implements errors.Is, matching a FaultCategory against the fault.
+func (v vimFaultError) Is(target error) bool {
+	return isFault(v.fault, target)
+}
+
+// As implements errors.As, assigning the fault to target if it implements the target interface.
+func (v vimFaultError) As(target interface{}) bool {
+	return asFault(v.fault, target)
+}
+
+// StatusError is returned by Client.RoundTrip when the server responds with
+// an HTTP status other than 200 (OK) or 500 (SOAP fault).
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (s *StatusError) Error() string {
+	return s.Status
+}
+
+// Is implements errors.Is, matching ErrNotAuthenticated, ErrNoPermission, ErrNotFound and ErrTransient
+// against HTTP status 401, 403, 404 and 503 respectively.
+func (s *StatusError) Is(target error) bool {
+	switch s.StatusCode {
+	case http.StatusUnauthorized:
+		return target == ErrNotAuthenticated
+	case http.StatusForbidden:
+		return target == ErrNoPermission
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusServiceUnavailable:
+		return target == ErrTransient
+	}
+
+	return false
+}
+
 func Wrap(err error) error {
 	switch err.(type) {
 	case regularError:
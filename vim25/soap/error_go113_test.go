@@ -0,0 +1,116 @@
+//go:build go1.13
+// +build go1.13
+
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestFaultCategory(t *testing.T) {
+	categories := []*FaultCategory{
+		ErrNotFound, ErrAlreadyExists, ErrNotAuthenticated, ErrNoPermission, ErrInvalidState, ErrTransient,
+	}
+
+	tests := []struct {
+		err    error
+		expect []*FaultCategory
+	}{
+		// SOAP fault details are decoded as values
+		{soapFault(types.ManagedObjectNotFound{}), []*FaultCategory{ErrNotFound}},
+		{soapFault(types.FileNotFound{}), []*FaultCategory{ErrNotFound}},
+		{soapFault(types.DuplicateName{}), []*FaultCategory{ErrAlreadyExists}},
+		{soapFault(types.NotAuthenticated{}), []*FaultCategory{ErrNotAuthenticated, ErrNoPermission}},
+		{soapFault(types.InvalidLogin{}), []*FaultCategory{ErrNotAuthenticated}},
+		{soapFault(types.InvalidPowerState{}), []*FaultCategory{ErrInvalidState}},
+		{soapFault(types.HostNotConnected{}), []*FaultCategory{ErrTransient}},
+		{soapFault(nil), nil},
+		{WrapVimFault(&types.FileAlreadyExists{}), []*FaultCategory{ErrAlreadyExists}},
+		{WrapVimFault(&types.NoPermission{}), []*FaultCategory{ErrNoPermission}},
+		{WrapVimFault(&types.TaskInProgress{}), []*FaultCategory{ErrTransient}},
+		{WrapVimFault(&types.InvalidArgument{}), nil},
+		{&StatusError{StatusCode: 404}, []*FaultCategory{ErrNotFound}},
+		{&StatusError{StatusCode: 503}, []*FaultCategory{ErrTransient}},
+		{&StatusError{StatusCode: 500}, nil},
+		{WrapRegularError(errors.New("fault")), nil},
+	}
+
+	for _, test := range tests {
+		// Categories match through wrapped errors
+		err := fmt.Errorf("wrapped: %w", test.err)
+
+		for _, c := range categories {
+			expect := false
+			for _, e := range test.expect {
+				if e == c {
+					expect = true
+				}
+			}
+
+			if errors.Is(err, c) != expect {
+				t.Errorf("errors.Is(%s, %s) != %t", err, c, expect)
+			}
+		}
+	}
+
+	err := fmt.Errorf("wrapped: %w", WrapRegularError(context.Canceled))
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected context.Canceled")
+	}
+}
+
+func TestFaultAs(t *testing.T) {
+	obj := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+
+	for _, err := range []error{
+		soapFault(types.ManagedObjectNotFound{Obj: obj}),
+		WrapVimFault(&types.ManagedObjectNotFound{Obj: obj}),
+	} {
+		err = fmt.Errorf("wrapped: %w", err)
+
+		var fe FaultError
+		if !errors.As(err, &fe) {
+			t.Fatalf("errors.As(%s, FaultError)=false", err)
+		}
+
+		if nf, ok := fe.Fault().(*types.ManagedObjectNotFound); !ok || nf.Obj != obj {
+			t.Errorf("Fault()=%#v", fe.Fault())
+		}
+
+		var base types.BaseMethodFault
+		if !errors.As(err, &base) || base.GetMethodFault() == nil {
+			t.Errorf("errors.As(%s, BaseMethodFault)=%#v", err, base)
+		}
+
+		var state types.BaseInvalidState
+		if errors.As(err, &state) {
+			t.Errorf("errors.As(%s, BaseInvalidState)=%#v", err, state)
+		}
+	}
+
+	var fe FaultError
+	if !errors.As(soapFault(nil), &fe) || fe.Fault() != nil {
+		t.Error("expected FaultError without a fault")
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func soapFault(detail types.AnyType) error {
+	f := &Fault{Code: "ServerFaultCode", String: "fault"}
+	f.Detail.Fault = detail
+	return WrapSoapFault(f)
+}
+
+func TestFaultMessage(t *testing.T) {
+	fault := &types.InvalidArgument{}
+	fault.FaultMessage = []types.LocalizableMessage{
+		{
+			Key: "com.vmware.vim.vpxd.vpx.vmprov.InvalidDisk",
+			Arg: []types.KeyAnyValue{{Key: "name", Value: "disk-1"}, {Key: "size", Value: int32(0)}},
+		},
+		{Key: "msg.disk", Message: "The disk is invalid."},
+	}
+
+	tests := []struct {
+		err    error
+		expect string
+	}{
+		{WrapVimFault(&types.InvalidArgument{}), "InvalidArgument"},
+		{WrapVimFault(fault), "InvalidArgument: com.vmware.vim.vpxd.vpx.vmprov.InvalidDisk (name=disk-1, size=0); The disk is invalid."},
+		{soapFault(*fault), "ServerFaultCode: fault; com.vmware.vim.vpxd.vpx.vmprov.InvalidDisk (name=disk-1, size=0); The disk is invalid."},
+	}
+
+	for _, test := range tests {
+		if msg := test.err.Error(); msg != test.expect {
+			t.Errorf("expected %q, got %q", test.expect, msg)
+		}
+	}
+
+	// Messages already included in the fault string are not repeated
+	f := &Fault{Code: "ServerFaultCode", String: "The disk is invalid."}
+	f.Detail.Fault = types.InvalidArgument{RuntimeFault: types.RuntimeFault{MethodFault: types.MethodFault{FaultMessage: fault.FaultMessage[1:]}}}
+	if msg := WrapSoapFault(f).Error(); msg != "ServerFaultCode: The disk is invalid." {
+		t.Errorf("msg=%q", msg)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ThumbprintError is returned when a host's certificate thumbprint does not match the known thumbprint.
+type ThumbprintError struct {
+	Host   string // Host address, including port
+	Known  string // Thumbprint set via SetThumbprint or loaded from a known hosts file
+	Actual string // Thumbprint of the certificate presented by the host
+}
+
+func (e *ThumbprintError) Error() string {
+	return fmt.Sprintf("Host %q thumbprint %s does not match known thumbprint %s", e.Host, e.Actual, e.Known)
+}
+
+// knownHostsMu serializes updates to known hosts files within this process.
+var knownHostsMu sync.Mutex
+
+// SetKnownHosts enables trust-on-first-use verification using the given known hosts file.
+// Thumbprints already in the file are loaded as with LoadThumbprints.
+// When a host certificate cannot be verified and there is no known thumbprint for the host,
+// the certificate is trusted and its SHA-1 thumbprint is recorded via AddKnownHost.
+// Later connections fail with a *ThumbprintError if the host presents a different certificate.
+// If file is empty, trust-on-first-use is disabled.
+func (c *Client) SetKnownHosts(file string) error {
+	if file != "" {
+		// Only the first file in a list is written to
+		file = filepath.SplitList(file)[0]
+
+		if err := c.loadThumbprints(file); err != nil {
+			return err
+		}
+	}
+
+	c.hostsMu.Lock()
+	c.knownHosts = file
+	c.hostsMu.Unlock()
+
+	return nil
+}
+
+// KnownHosts returns the known hosts file set via SetKnownHosts, if any.
+func (c *Client) KnownHosts() string {
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+	return c.knownHosts
+}
+
+// trustOnFirstUse records the thumbprint for the given host, if a known hosts file is set.
+func (c *Client) trustOnFirstUse(host string, thumbprint string) error {
+	file := c.KnownHosts()
+	if file == "" {
+		return nil
+	}
+
+	c.SetThumbprint(host, thumbprint)
+
+	return AddKnownHost(file, host, thumbprint)
+}
+
+// AddKnownHost adds the thumbprint for the given host to the known hosts file, in the format read by LoadThumbprints.
+// Any existing entry for the host is replaced. If file is a list, as accepted by SetKnownHosts,
+// only the first file in the list is written to.
+// The file is written to a temporary file and renamed, such that readers never see a partial update.
+func AddKnownHost(file string, host string, thumbprint string) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if list := filepath.SplitList(file); len(list) != 0 {
+		file = list[0]
+	}
+	host = hostAddr(host)
+
+	var buf bytes.Buffer
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		e := strings.SplitN(line, " ", 2)
+		if len(e) == 2 && hostAddr(e[0]) == host {
+			continue
+		}
+		fmt.Fprintln(&buf, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(&buf, "%s %s\n", host, thumbprint)
+
+	dir := filepath.Dir(file)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, filepath.Base(file))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func TestKnownHostsTrustOnFirstUse(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	thumbprint := soap.ThumbprintSHA1(s.Certificate())
+
+	dir, err := ioutil.TempDir("", "govmomi-known-hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "known_hosts")
+
+	// Without TOFU, the unverified host is rejected
+	c := soap.NewClient(u, false)
+	if _, err = c.Get(s.URL); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// With TOFU, the host is trusted and recorded
+	c = soap.NewClient(u, false)
+	if err = c.SetKnownHosts(file); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line := strings.TrimSpace(string(data)); line != u.Host+" "+thumbprint {
+		t.Errorf("known_hosts=%q", line)
+	}
+
+	// A new client loads the recorded thumbprint
+	c = soap.NewClient(u, false)
+	if err = c.LoadThumbprints(file); err != nil {
+		t.Fatal(err)
+	}
+	if c.Thumbprint(u.Host) != thumbprint {
+		t.Errorf("thumbprint=%q", c.Thumbprint(u.Host))
+	}
+
+	// A changed certificate is rejected with the known and actual thumbprints
+	known := strings.Repeat("00:", 19) + "00"
+	if err = soap.AddKnownHost(file, u.Host, known); err != nil {
+		t.Fatal(err)
+	}
+
+	c = soap.NewClient(u, false)
+	if err = c.SetKnownHosts(file); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Get(s.URL)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	uerr, ok := err.(*url.Error)
+	if !ok {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	terr, ok := uerr.Err.(*soap.ThumbprintError)
+	if !ok {
+		t.Fatalf("unexpected error: %s", uerr.Err)
+	}
+
+	if terr.Known != known || terr.Actual != thumbprint {
+		t.Errorf("error=%s", terr)
+	}
+
+	data, _ = ioutil.ReadFile(file)
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Errorf("expected 1 entry, got %d", n)
+	}
+
+	// Only the first file in a list is written to
+	other := filepath.Join(dir, "other_known_hosts")
+	list := strings.Join([]string{file, other}, string(filepath.ListSeparator))
+
+	if err = soap.AddKnownHost(list, u.Host, thumbprint); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ = ioutil.ReadFile(file)
+	if line := strings.TrimSpace(string(data)); line != u.Host+" "+thumbprint {
+		t.Errorf("known_hosts=%q", line)
+	}
+
+	if _, err = os.Stat(other); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to exist: %v", other, err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Call describes a single SOAP round trip made by Client, as seen by an Observer.
+type Call struct {
+	Method       string                       // vim25 method name
+	This         types.ManagedObjectReference // Target of the method
+	OperationID  string                       // Sent in the SOAP header as operationID, if set
+	StatusCode   int                          // HTTP response status, 0 if no response was received
+	Start        time.Time                    // Time the request was started
+	Duration     time.Duration                // Time taken for the round trip
+	RequestSize  int64                        // Size in bytes of the request body
+	ResponseSize int64                        // Size in bytes of the response body
+	Fault        string                       // Type name of the vim fault, if any
+	Err          error                        // Error returned by RoundTrip, if any
+}
+
+// Observer is invoked by Client for each SOAP round trip.
+// Start is called before the request is sent, and may return a derived context,
+// for example to carry a tracing span. Start may also set Call.OperationID,
+// which is then sent in the request header.
+// End is called once the round trip has completed, with the context returned by Start.
+type Observer interface {
+	Start(ctx context.Context, call *Call) context.Context
+	End(ctx context.Context, call *Call)
+}
+
+// AddObserver registers the given Observer to be invoked for each round trip.
+// Observers are not safe to add while requests are in flight.
+func (c *Client) AddObserver(o Observer) {
+	c.o = append(c.o, o)
+}
+
+type operationIDKey struct{}
+
+// WithOperationID returns a context that causes requests made with it to
+// include the given ID in the operationID SOAP header. vCenter includes the
+// operation ID in its logs (vpxd.log), which can be used to correlate client and server logs.
+func WithOperationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, operationIDKey{}, id)
+}
+
+// OperationID returns the operation ID associated with ctx, if any.
+func OperationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(operationIDKey{}).(string)
+	return id
+}
+
+// methodThis returns the value of the This field of the given request body, if any.
+func methodThis(req HasFault) types.ManagedObjectReference {
+	v := reflect.ValueOf(req)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if f := v.FieldByName("Req"); f.IsValid() && !f.IsNil() {
+		if this := f.Elem().FieldByName("This"); this.IsValid() {
+			if ref, ok := this.Interface().(types.ManagedObjectReference); ok {
+				return ref
+			}
+		}
+	}
+
+	return types.ManagedObjectReference{}
+}
+
+// FaultName returns the type name of the vim fault wrapped by err, if any.
+func FaultName(err error) string {
+	var fault interface{}
+
+	switch {
+	case IsSoapFault(err):
+		fault = ToSoapFault(err).VimFault()
+	case IsVimFault(err):
+		fault = ToVimFault(err)
+	}
+
+	if fault == nil {
+		return ""
+	}
+
+	t := reflect.TypeOf(fault)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
+
+// countReader counts the bytes read from a response body.
+type countReader struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (r countReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.n += int64(n)
+	return n, err
+}
+
+func (c *Client) observe(ctx context.Context, reqBody, resBody HasFault) error {
+	call := &Call{
+		Method:      MethodName(reqBody),
+		This:        methodThis(reqBody),
+		OperationID: OperationID(ctx),
+		Start:       time.Now(),
+	}
+
+	for _, o := range c.o {
+		ctx = o.Start(ctx, call)
+	}
+
+	if call.OperationID != OperationID(ctx) {
+		ctx = WithOperationID(ctx, call.OperationID)
+	}
+
+	err := c.roundTrip(ctx, reqBody, resBody, call)
+
+	call.Duration = time.Since(call.Start)
+	call.Err = err
+	call.Fault = FaultName(err)
+
+	for i := len(c.o) - 1; i >= 0; i-- {
+		c.o[i].End(ctx, call)
+	}
+
+	return err
+}
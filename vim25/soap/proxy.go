@@ -0,0 +1,439 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// Dialer creates network connections, as implemented by net.Dialer and
+// golang.org/x/net/proxy.Dialer.
+type Dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+// DialerFunc adapts an ordinary function to the Dialer interface.
+type DialerFunc func(network, addr string) (net.Conn, error)
+
+// Dial calls f(network, addr)
+func (f DialerFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(network, addr)
+}
+
+var direct = &net.Dialer{}
+
+// ProxyDialer returns a Dialer that connects via the given proxy URL, using forward
+// to connect to the proxy itself. If forward is nil, a direct connection is made.
+// The http and https schemes use HTTP CONNECT, the socks5 scheme uses SOCKS version 5.
+// If the URL includes userinfo, it is used for Basic or username/password authentication respectively.
+func ProxyDialer(proxy *url.URL, forward Dialer) (Dialer, error) {
+	if forward == nil {
+		forward = direct
+	}
+
+	switch proxy.Scheme {
+	case "http", "https":
+		return &connectDialer{proxy: proxy, forward: forward}, nil
+	case "socks5":
+		return &socks5Dialer{proxy: proxy, forward: forward}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported proxy scheme: %q", proxy.Scheme)
+}
+
+// proxyAddr returns the proxy host:port, using the scheme's default port if needed.
+func proxyAddr(u *url.URL) string {
+	host, port := splitHostPort(u.Host)
+	if port != "" {
+		return u.Host
+	}
+
+	switch u.Scheme {
+	case "https":
+		port = "443"
+	case "socks5":
+		port = "1080"
+	default:
+		port = "80"
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// connectDialer tunnels connections through an HTTP proxy using the CONNECT method.
+type connectDialer struct {
+	proxy   *url.URL
+	forward Dialer
+}
+
+func (d *connectDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial(network, proxyAddr(d.proxy))
+	if err != nil {
+		return nil, err
+	}
+
+	if d.proxy.Scheme == "https" {
+		host, _ := splitHostPort(d.proxy.Host)
+		tconn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err = tconn.Handshake(); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tconn
+	}
+
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+
+	if u := d.proxy.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+
+	if err = req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// The proxy does not send anything after the response header
+	// until the client does, so the buffered reader can be discarded.
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %s CONNECT %s: %s", d.proxy.Host, addr, res.Status)
+	}
+
+	return conn, nil
+}
+
+// socks5Dialer implements the client side of RFC 1928 and RFC 1929.
+type socks5Dialer struct {
+	proxy   *url.URL
+	forward Dialer
+}
+
+const (
+	socks5Version      = 5
+	socks5NoAuth       = 0
+	socks5PasswordAuth = 2
+	socks5NoAcceptable = 0xff
+	socks5Connect      = 1
+	socks5IPv4         = 1
+	socks5Domain       = 3
+	socks5IPv6         = 4
+)
+
+var socks5Errors = []string{
+	"",
+	"general SOCKS server failure",
+	"connection not allowed by ruleset",
+	"network unreachable",
+	"host unreachable",
+	"connection refused",
+	"TTL expired",
+	"command not supported",
+	"address type not supported",
+}
+
+func (d *socks5Dialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial(network, proxyAddr(d.proxy))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.connect(conn, addr); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %s: %s", d.proxy.Host, err)
+	}
+
+	return conn, nil
+}
+
+func (d *socks5Dialer) connect(conn net.Conn, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 0xffff {
+		return fmt.Errorf("invalid port: %q", portStr)
+	}
+
+	method := byte(socks5NoAuth)
+	if d.proxy.User != nil {
+		method = socks5PasswordAuth
+	}
+
+	buf := []byte{socks5Version, 1, method}
+	if _, err = conn.Write(buf); err != nil {
+		return err
+	}
+
+	if _, err = io.ReadFull(conn, buf[:2]); err != nil {
+		return err
+	}
+
+	if buf[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version: %d", buf[0])
+	}
+
+	switch buf[1] {
+	case socks5NoAuth:
+	case socks5PasswordAuth:
+		user := d.proxy.User.Username()
+		password, _ := d.proxy.User.Password()
+		if len(user) > 255 || len(password) > 255 {
+			return errors.New("SOCKS username or password too long")
+		}
+
+		buf = []byte{1, byte(len(user))}
+		buf = append(buf, user...)
+		buf = append(buf, byte(len(password)))
+		buf = append(buf, password...)
+		if _, err = conn.Write(buf); err != nil {
+			return err
+		}
+
+		if _, err = io.ReadFull(conn, buf[:2]); err != nil {
+			return err
+		}
+
+		if buf[1] != 0 {
+			return errors.New("SOCKS authentication failed")
+		}
+	default:
+		return errors.New("no acceptable SOCKS authentication methods")
+	}
+
+	buf = []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			buf = append(buf, socks5IPv4)
+			buf = append(buf, ip4...)
+		} else {
+			buf = append(buf, socks5IPv6)
+			buf = append(buf, ip...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("host name too long: %q", host)
+		}
+		buf = append(buf, socks5Domain, byte(len(host)))
+		buf = append(buf, host...)
+	}
+	buf = append(buf, byte(port>>8), byte(port))
+
+	if _, err = conn.Write(buf); err != nil {
+		return err
+	}
+
+	// Reply: version, status, reserved, address type
+	if _, err = io.ReadFull(conn, buf[:4]); err != nil {
+		return err
+	}
+
+	if status := int(buf[1]); status != 0 {
+		msg := "unknown error"
+		if status < len(socks5Errors) {
+			msg = socks5Errors[status]
+		}
+		return fmt.Errorf("SOCKS CONNECT %s: %s", addr, msg)
+	}
+
+	// Discard the bound address and port
+	var n int
+	switch buf[3] {
+	case socks5IPv4:
+		n = net.IPv4len
+	case socks5IPv6:
+		n = net.IPv6len
+	case socks5Domain:
+		if _, err = io.ReadFull(conn, buf[:1]); err != nil {
+			return err
+		}
+		n = int(buf[0])
+	default:
+		return fmt.Errorf("unexpected SOCKS address type: %d", buf[3])
+	}
+
+	_, err = io.ReadFull(conn, make([]byte, n+2))
+	return err
+}
+
+// SetDialer configures the Client to create all connections with the given Dialer,
+// including connections to ESX hosts for datastore and guest file transfers.
+// Proxy settings from the environment (HTTPS_PROXY, etc) are no longer used.
+func (c *Client) SetDialer(d Dialer) {
+	c.dialer = d
+	c.t.Proxy = c.proxy
+	c.t.DialContext = func(_ context.Context, network, addr string) (net.Conn, error) {
+		return d.Dial(network, addr)
+	}
+}
+
+// SetProxy configures the Client to connect via the given proxy URL.
+// See ProxyDialer for the supported schemes.
+func (c *Client) SetProxy(proxy *url.URL) error {
+	d, err := ProxyDialer(proxy, c.dialer)
+	if err != nil {
+		return err
+	}
+
+	c.SetDialer(d)
+
+	return nil
+}
+
+// tunnelProxy returns the URL of the vCenter reverse HTTP proxy, port 80 by default.
+func (c *Client) tunnelProxy() *url.URL {
+	host, _ := splitHostPort(c.u.Host)
+
+	// Should be no reason to change the default port other than testing
+	key := "GOVMOMI_TUNNEL_PROXY_PORT"
+
+	port := c.URL().Query().Get(key)
+	if port == "" {
+		port = os.Getenv(key)
+	}
+
+	if port != "" {
+		host += ":" + port
+	}
+
+	return &url.URL{
+		Scheme: "http",
+		Host:   host,
+	}
+}
+
+// TunnelHosts configures the Client to connect to ESX hosts via the vCenter
+// reverse HTTP proxy, using HTTP CONNECT, as is done for the sdkTunnel used
+// by SetCertificate. This is useful when ESX hosts are only routable through
+// vCenter, such as for datastore and guest file transfers.
+// Connections to vCenter itself are not tunneled.
+func (c *Client) TunnelHosts() {
+	forward := c.dialer
+	if forward == nil {
+		forward = direct
+	}
+
+	tunnel := &connectDialer{proxy: c.tunnelProxy(), forward: forward}
+	vc := hostAddr(c.u.Host)
+
+	c.SetDialer(DialerFunc(func(network, addr string) (net.Conn, error) {
+		if addr == vc {
+			return forward.Dial(network, addr)
+		}
+		return tunnel.Dial(network, addr)
+	}))
+}
+
+// proxy is used as the http.Transport.Proxy function, once SetCertificate or SetDialer is called.
+func (c *Client) proxy(r *http.Request) (*url.URL, error) {
+	// Only sdk requests should be proxied
+	if c.p != nil && r.URL.Path == "/sdk" {
+		return c.p, nil
+	}
+
+	// The Dialer is responsible for any other proxying
+	if c.dialer != nil {
+		return nil, nil
+	}
+
+	return http.ProxyFromEnvironment(r)
+}
+
+// dial creates a connection using the configured Dialer, if any.
+func (c *Client) dial(network, addr string) (net.Conn, error) {
+	if c.dialer == nil {
+		return net.Dial(network, addr)
+	}
+
+	return c.dialer.Dial(network, addr)
+}
+
+// tlsDial is similar to tls.Dial, but uses the configured Dialer, if any.
+func (c *Client) tlsDial(network, addr string, config *tls.Config) (*tls.Conn, error) {
+	conn, err := c.dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ServerName == "" {
+		host, _ := splitHostPort(addr)
+		config = cloneTLSConfig(config)
+		config.ServerName = host
+	}
+
+	tconn := tls.Client(conn, config)
+	if err = tconn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return tconn, nil
+}
+
+// cloneTLSConfig returns a copy of config, as tls.Config.Clone requires Go 1.8.
+// Fields added after Go 1.7 are not copied.
+func cloneTLSConfig(config *tls.Config) *tls.Config {
+	return &tls.Config{
+		Rand:                        config.Rand,
+		Time:                        config.Time,
+		Certificates:                config.Certificates,
+		NameToCertificate:           config.NameToCertificate,
+		GetCertificate:              config.GetCertificate,
+		RootCAs:                     config.RootCAs,
+		NextProtos:                  config.NextProtos,
+		ServerName:                  config.ServerName,
+		ClientAuth:                  config.ClientAuth,
+		ClientCAs:                   config.ClientCAs,
+		InsecureSkipVerify:          config.InsecureSkipVerify,
+		CipherSuites:                config.CipherSuites,
+		PreferServerCipherSuites:    config.PreferServerCipherSuites,
+		SessionTicketsDisabled:      config.SessionTicketsDisabled,
+		SessionTicketKey:            config.SessionTicketKey,
+		ClientSessionCache:          config.ClientSessionCache,
+		MinVersion:                  config.MinVersion,
+		MaxVersion:                  config.MaxVersion,
+		CurvePreferences:            config.CurvePreferences,
+		DynamicRecordSizingDisabled: config.DynamicRecordSizingDisabled,
+		Renegotiation:               config.Renegotiation,
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// proxyServer is a minimal HTTP CONNECT and SOCKS5 proxy for testing.
+type proxyServer struct {
+	sync.Mutex
+	user, password string
+	connects       []string
+}
+
+func (p *proxyServer) record(addr string) {
+	p.Lock()
+	p.connects = append(p.connects, addr)
+	p.Unlock()
+}
+
+func (p *proxyServer) count() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.connects)
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+func (p *proxyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "CONNECT" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(p.user+":"+p.password))
+	if p.user != "" && r.Header.Get("Proxy-Authorization") != auth {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+
+	target, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	p.record(r.Host)
+	w.WriteHeader(http.StatusOK)
+
+	conn, _, _ := w.(http.Hijacker).Hijack()
+	pipe(conn, target)
+}
+
+func (p *proxyServer) serveSOCKS(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			buf := make([]byte, 512)
+
+			// Greeting, expect username/password method
+			_, _ = io.ReadFull(conn, buf[:2])
+			_, _ = io.ReadFull(conn, buf[:buf[1]])
+			_, _ = conn.Write([]byte{5, 2})
+
+			// Credentials
+			_, _ = io.ReadFull(conn, buf[:2])
+			user := make([]byte, buf[1])
+			_, _ = io.ReadFull(conn, user)
+			_, _ = io.ReadFull(conn, buf[:1])
+			password := make([]byte, buf[0])
+			_, _ = io.ReadFull(conn, password)
+			if string(user) != p.user || string(password) != p.password {
+				_, _ = conn.Write([]byte{1, 1})
+				_ = conn.Close()
+				return
+			}
+			_, _ = conn.Write([]byte{1, 0})
+
+			// Request, expect an IPv4 address
+			_, _ = io.ReadFull(conn, buf[:10])
+			ip := net.IP(buf[4:8])
+			port := binary.BigEndian.Uint16(buf[8:10])
+			addr := net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+
+			target, err := net.Dial("tcp", addr)
+			if err != nil {
+				_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+				_ = conn.Close()
+				return
+			}
+
+			p.record(addr)
+			_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+			pipe(conn, target)
+		}()
+	}
+}
+
+func TestProxy(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	p := &proxyServer{user: "proxy", password: "secret"}
+
+	hp := httptest.NewServer(p)
+	defer hp.Close()
+
+	sl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+	go p.serveSOCKS(sl)
+
+	proxies := []string{
+		"http://proxy:secret@" + hp.Listener.Addr().String(),
+		"socks5://proxy:secret@" + sl.Addr().String(),
+	}
+
+	for _, proxy := range proxies {
+		u, _ := url.Parse(proxy)
+		n := p.count()
+
+		sc := soap.NewClient(s.URL, true)
+		if err = sc.SetProxy(u); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := vim25.NewClient(ctx, sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = methods.GetCurrentTime(ctx, c); err == nil {
+			t.Error("expected NotAuthenticated")
+		}
+
+		if p.count() == n {
+			t.Errorf("%s: no connections proxied", proxy)
+		}
+
+		// Invalid credentials
+		u.User = url.UserPassword("proxy", "invalid")
+		sc = soap.NewClient(s.URL, true)
+		_ = sc.SetProxy(u)
+
+		if _, err = vim25.NewClient(ctx, sc); err == nil {
+			t.Errorf("%s: expected error", proxy)
+		}
+	}
+
+	if _, err = soap.ProxyDialer(&url.URL{Scheme: "ftp"}, nil); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestTunnelHosts(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	p := &proxyServer{}
+	hp := httptest.NewServer(p)
+	defer hp.Close()
+
+	// An "ESX host" that is reached via the tunnel
+	host := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer host.Close()
+
+	_, port, _ := net.SplitHostPort(hp.Listener.Addr().String())
+	u := *s.URL
+	u.RawQuery = "GOVMOMI_TUNNEL_PROXY_PORT=" + port
+
+	sc := soap.NewClient(&u, true)
+	sc.TunnelHosts()
+
+	c, err := vim25.NewClient(ctx, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.count() != 0 {
+		t.Errorf("vCenter connection was tunneled: %v", p.connects)
+	}
+
+	res, err := c.Client.Get(host.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	if p.count() != 1 || p.connects[0] != host.Listener.Addr().String() {
+		t.Errorf("connects=%v", p.connects)
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// Interaction is a single request/response pair, as stored in a cassette.
+// A cassette is a stream of JSON encoded Interactions, one per line.
+type Interaction struct {
+	// Method is the name of the vim25 method, for example "RetrieveProperties"
+	Method string `json:"method"`
+
+	// Request is the SOAP request envelope
+	Request string `json:"request"`
+
+	// Response is the SOAP response envelope, including the fault if the call returned one
+	Response string `json:"response,omitempty"`
+
+	// Error is the error returned by the call, if it failed without a SOAP response
+	Error string `json:"error,omitempty"`
+}
+
+// passwordElement matches elements whose content must not be written to a cassette
+var passwordElement = regexp.MustCompile(`<(password|pwd)>[^<]*</(password|pwd)>`)
+
+// redact removes passwords from the given request envelope.
+// Requests are redacted before they are recorded and before they are matched.
+func redact(b []byte) []byte {
+	return passwordElement.ReplaceAll(b, []byte("<$1>(redacted)</$2>"))
+}
+
+func encodeEnvelope(body HasFault) ([]byte, error) {
+	b, err := xml.Marshal(Envelope{Body: body})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+type recorder struct {
+	roundTripper RoundTripper
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder wraps the specified RoundTripper, writing the request and response
+// of each call to w in cassette format. Passwords are redacted from the recorded
+// requests. A cassette can be replayed using NewReplayer.
+func NewRecorder(roundTripper RoundTripper, w io.Writer) RoundTripper {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	r := &recorder{
+		roundTripper: roundTripper,
+		enc:          enc,
+	}
+
+	return r
+}
+
+func (r *recorder) RoundTrip(ctx context.Context, req, res HasFault) error {
+	rerr := r.roundTripper.RoundTrip(ctx, req, res)
+
+	i := Interaction{
+		Method: MethodName(req),
+	}
+
+	b, err := encodeEnvelope(req)
+	if err != nil {
+		return err
+	}
+	i.Request = string(redact(b))
+
+	if rerr == nil || IsSoapFault(rerr) {
+		b, err = encodeEnvelope(res)
+		if err != nil {
+			return err
+		}
+		i.Response = string(b)
+	} else {
+		i.Error = rerr.Error()
+	}
+
+	r.mu.Lock()
+	err = r.enc.Encode(&i)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return rerr
+}
+
+// ReplayError is returned by the Replayer when a request does not match any recorded interaction.
+type ReplayError struct {
+	Method  string
+	Request string
+
+	// Expected is the next unused interaction in the cassette, if any
+	Expected *Interaction
+}
+
+func (e *ReplayError) Error() string {
+	msg := fmt.Sprintf("replay: no recorded interaction matches %s request", e.Method)
+
+	if e.Expected == nil {
+		return msg + " (cassette exhausted)"
+	}
+
+	if e.Expected.Method != e.Method {
+		return fmt.Sprintf("%s (next recorded method is %s)", msg, e.Expected.Method)
+	}
+
+	return fmt.Sprintf("%s:\n%s\nnext recorded request:\n%s", msg, e.Request, e.Expected.Request)
+}
+
+// Replayer is a RoundTripper that serves responses from a cassette, without a server.
+// Each request must match the method and request envelope of a recorded interaction,
+// otherwise the call fails with a ReplayError. Interactions are matched in the order
+// they were recorded and each is used only once. Passwords are redacted from both
+// the recorded and replayed requests, so they are not compared.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer for the cassette read from r.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	p := &Replayer{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var i Interaction
+		if err := json.Unmarshal(line, &i); err != nil {
+			return nil, fmt.Errorf("replay: invalid cassette interaction %d: %s", len(p.interactions)+1, err)
+		}
+
+		p.interactions = append(p.interactions, i)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	p.used = make([]bool, len(p.interactions))
+
+	return p, nil
+}
+
+// Remaining returns the number of recorded interactions that have not been replayed.
+func (p *Replayer) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for _, used := range p.used {
+		if !used {
+			n++
+		}
+	}
+
+	return n
+}
+
+// next returns the first unused interaction matching the given method and request
+func (p *Replayer) next(method string, request string) (*Interaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var expected *Interaction
+
+	for i := range p.interactions {
+		if p.used[i] {
+			continue
+		}
+
+		x := &p.interactions[i]
+
+		if expected == nil {
+			expected = x
+		}
+
+		if x.Method == method && x.Request == request {
+			p.used[i] = true
+			return x, nil
+		}
+	}
+
+	return nil, &ReplayError{
+		Method:   method,
+		Request:  request,
+		Expected: expected,
+	}
+}
+
+func (p *Replayer) RoundTrip(ctx context.Context, req, res HasFault) error {
+	b, err := encodeEnvelope(req)
+	if err != nil {
+		return err
+	}
+
+	i, err := p.next(MethodName(req), string(redact(b)))
+	if err != nil {
+		return err
+	}
+
+	if i.Error != "" {
+		return errors.New(i.Error)
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(i.Response))
+	dec.TypeFunc = types.TypeFunc()
+
+	err = dec.Decode(&Envelope{Body: res})
+	if err != nil {
+		return err
+	}
+
+	if f := res.Fault(); f != nil {
+		return WrapSoapFault(f)
+	}
+
+	return nil
+}
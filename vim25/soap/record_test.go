@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap_test
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// replaySession runs a sequence of calls against the given RoundTripper,
+// returning the name of each VM found.
+func replaySession(ctx context.Context, rt soap.RoundTripper, t *testing.T) []string {
+	c, err := vim25.NewClient(ctx, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := session.NewManager(c)
+
+	err = m.Login(ctx, url.UserPassword("user", "pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finder := find.NewFinder(c, false)
+
+	vms, err := finder.VirtualMachineList(ctx, "/ha-datacenter/vm/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, vm := range vms {
+		var props mo.VirtualMachine
+		err = vm.Properties(ctx, vm.Reference(), []string{"name"}, &props)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, props.Name)
+	}
+
+	return names
+}
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.ESX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+
+	var cassette bytes.Buffer
+
+	recorded := replaySession(ctx, soap.NewRecorder(soap.NewClient(s.URL, true), &cassette), t)
+
+	s.Close() // replay does not use the server
+
+	if strings.Contains(cassette.String(), "<password>pass</password>") {
+		t.Error("password was recorded")
+	}
+
+	p, err := soap.NewReplayer(bytes.NewReader(cassette.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := p.Remaining()
+	if n == 0 {
+		t.Fatal("nothing recorded")
+	}
+
+	replayed := replaySession(ctx, p, t)
+
+	if strings.Join(recorded, ",") != strings.Join(replayed, ",") {
+		t.Errorf("%s != %s", recorded, replayed)
+	}
+
+	if p.Remaining() != 0 {
+		t.Errorf("%d of %d interactions not replayed", p.Remaining(), n)
+	}
+
+	// Faults are replayed
+	p, _ = soap.NewReplayer(bytes.NewReader(cassette.Bytes()))
+	c, err := vim25.NewClient(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = session.NewManager(c).Login(ctx, url.UserPassword("enoent", "pass"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	rerr, ok := err.(*soap.ReplayError)
+	if !ok {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if rerr.Method != "Login" || rerr.Expected == nil || rerr.Expected.Method != "Login" {
+		t.Errorf("unexpected error: %#v", rerr)
+	}
+}
+
+func TestReplayFault(t *testing.T) {
+	ctx := context.Background()
+
+	m := simulator.VPX()
+
+	err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service.NewServer()
+	defer s.Close()
+
+	var cassette bytes.Buffer
+
+	rt := soap.NewRecorder(soap.NewClient(s.URL, true), &cassette)
+	c, err := vim25.NewClient(ctx, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(err error) {
+		if !soap.IsSoapFault(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, ok := soap.ToSoapFault(err).VimFault().(types.NotAuthenticated); !ok {
+			t.Errorf("unexpected fault: %#v", soap.ToSoapFault(err).VimFault())
+		}
+	}
+
+	var folder mo.Folder
+	pc := c.ServiceContent.PropertyCollector
+	root := c.ServiceContent.RootFolder
+
+	check(mo.RetrieveProperties(ctx, c, pc, root, &folder))
+
+	p, err := soap.NewReplayer(&cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = vim25.NewClient(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check(mo.RetrieveProperties(ctx, c, pc, root, &folder))
+
+	// Cassette is exhausted
+	err = mo.RetrieveProperties(ctx, c, pc, root, &folder)
+	if _, ok := err.(*soap.ReplayError); !ok {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
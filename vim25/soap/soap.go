@@ -17,6 +17,8 @@ limitations under the License.
 package soap
 
 import (
+	"context"
+
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/govmomi/vim25/xml"
 )
@@ -28,7 +30,39 @@ type Envelope struct {
 }
 
 type Header struct {
-	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Header"`
+	XMLName  xml.Name    `xml:"http://schemas.xmlsoap.org/soap/envelope/ Header"`
+	Action   string      `xml:"-"` // SOAPAction HTTP header, defaults to Namespace/Version
+	Security interface{} `xml:",omitempty"`
+	ID       string      `xml:"operationID,omitempty"`
+}
+
+// Signer is implemented by a Header.Security value that must sign the request.
+// If the header's Security field implements Signer, Client.RoundTrip uses Sign
+// to marshal the request envelope, rather than xml.Marshal.
+type Signer interface {
+	Sign(Envelope) ([]byte, error)
+}
+
+type headerKey struct{}
+
+// WithHeader returns a context that causes requests made with it to include the given Header.
+// For example, session.Manager.LoginByToken requires a Header with a Security token.
+func WithHeader(ctx context.Context, header Header) context.Context {
+	return context.WithValue(ctx, headerKey{}, header)
+}
+
+// contextHeader returns a copy of the Header associated with ctx, or nil if there is none.
+func contextHeader(ctx context.Context) *Header {
+	if ctx == nil {
+		return nil
+	}
+
+	header, ok := ctx.Value(headerKey{}).(Header)
+	if !ok {
+		return nil
+	}
+
+	return &header
 }
 
 type Fault struct {
@@ -53,3 +53,18 @@ func TestEmptyHeader(t *testing.T) {
 		t.Fatalf("expected: %s, actual: %s", expected, actual)
 	}
 }
+
+func TestOperationIDHeader(t *testing.T) {
+	env := Envelope{Header: &Header{ID: "op-123"}}
+
+	b, err := xml.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Header xmlns="http://schemas.xmlsoap.org/soap/envelope/"><operationID>op-123</operationID></Header></Envelope>`
+	actual := string(b)
+	if expected != actual {
+		t.Fatalf("expected: %s, actual: %s", expected, actual)
+	}
+}
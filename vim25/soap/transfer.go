@@ -0,0 +1,554 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/progress"
+)
+
+// DefaultChunkSize is the size of each range request made by DownloadFile when Download.Parallel > 1
+const DefaultChunkSize = 64 * 1024 * 1024
+
+// retryDelay is the delay before the first DownloadFile retry, doubled for each further attempt up to maxRetryDelay.
+var retryDelay = 250 * time.Millisecond
+
+const maxRetryDelay = 10 * time.Second
+
+// ChecksumError is returned by DownloadFile when the downloaded content does not match Download.Checksum
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %s, actual %s", e.Expected, e.Actual)
+}
+
+// contentRange parses the Content-Range header of a 206 response,
+// returning the start offset and total size, which is -1 if unknown.
+func contentRange(res *http.Response) (int64, int64, error) {
+	var start, end, total int64
+
+	cr := res.Header.Get("Content-Range")
+
+	_, err := fmt.Sscanf(cr, "bytes %d-%d/%d", &start, &end, &total)
+	if err != nil {
+		if _, err = fmt.Sscanf(cr, "bytes %d-%d/*", &start, &end); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range: %q", cr)
+		}
+		total = -1
+	}
+
+	return start, total, nil
+}
+
+// progressCounter adapts byte counts reported by concurrent writers to a progress.Sinker,
+// by way of a reader that is drained in the background.
+type progressCounter struct {
+	mu   sync.Mutex
+	skip int64 // bytes to be downloaded again after a restart, already reported
+
+	ch      chan int64
+	done    chan struct{}
+	pending int64
+	pr      interface {
+		io.Reader
+		Done(error)
+	}
+}
+
+func newProgressCounter(s progress.Sinker, size int64) *progressCounter {
+	if s == nil {
+		return nil
+	}
+
+	p := &progressCounter{
+		ch:   make(chan int64),
+		done: make(chan struct{}),
+	}
+
+	p.pr = progress.NewReader(s, p, size)
+
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, p.pr)
+		close(p.done)
+	}()
+
+	return p
+}
+
+// Read implements io.Reader for the progress reader, returning the number of bytes written.
+func (p *progressCounter) Read(b []byte) (int, error) {
+	if p.pending == 0 {
+		n, ok := <-p.ch
+		if !ok {
+			return 0, io.EOF
+		}
+		p.pending = n
+	}
+
+	n := p.pending
+	if n > int64(len(b)) {
+		n = int64(len(b))
+	}
+	p.pending -= n
+
+	return int(n), nil
+}
+
+func (p *progressCounter) add(n int64) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	if p.skip >= n {
+		p.skip -= n
+		n = 0
+	} else {
+		n -= p.skip
+		p.skip = 0
+	}
+	p.mu.Unlock()
+
+	if n > 0 {
+		p.ch <- n
+	}
+}
+
+// rewind is called when n bytes already reported will be downloaded again,
+// as the progress reader's position cannot move backwards.
+func (p *progressCounter) rewind(n int64) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.skip += n
+	p.mu.Unlock()
+}
+
+func (p *progressCounter) finish(err error) {
+	if p == nil {
+		return
+	}
+
+	close(p.ch)
+	<-p.done
+	p.pr.Done(err)
+}
+
+// progressWriter counts bytes written to w
+type progressWriter struct {
+	w io.Writer
+	p *progressCounter
+}
+
+func (pw progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	pw.p.add(int64(n))
+	return n, err
+}
+
+// offsetWriter writes to f starting at the given offset
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(b []byte) (int, error) {
+	n, err := w.f.WriteAt(b, w.off)
+	w.off += int64(n)
+	return n, err
+}
+
+// fileDownload is the state of a single DownloadFile call
+type fileDownload struct {
+	c     *Client
+	u     *url.URL
+	param *Download
+	f     *os.File
+	h     hash.Hash
+	p     *progressCounter
+}
+
+// request GETs the given range of the URL, end is inclusive, or open ended if < 0.
+func (d *fileDownload) request(start, end int64) (*http.Response, error) {
+	param := *d.param
+	param.Headers = make(map[string]string, len(d.param.Headers)+1)
+	for k, v := range d.param.Headers {
+		param.Headers[k] = v
+	}
+
+	if end >= 0 {
+		param.Headers["Range"] = fmt.Sprintf("bytes=%d-%d", start, end)
+	} else if start > 0 {
+		param.Headers["Range"] = fmt.Sprintf("bytes=%d-", start)
+	}
+
+	return d.c.DownloadRequest(d.u, &param)
+}
+
+// progress creates the progress counter, once the total size is known.
+func (d *fileDownload) progress(offset, total int64) {
+	if d.p == nil {
+		d.p = newProgressCounter(d.param.Progress, total)
+		d.p.add(offset)
+	}
+}
+
+// wait sleeps before the given retry attempt, returning an error if param.Context is done first.
+func (d *fileDownload) wait(attempt int) error {
+	delay := maxRetryDelay
+	if attempt < 16 && retryDelay<<uint(attempt) < maxRetryDelay {
+		delay = retryDelay << uint(attempt)
+	}
+
+	// Randomize the delay by up to 20%, such that parallel chunks don't retry in lockstep
+	delay += time.Duration(0.2 * float64(delay) * (2*rand.Float64() - 1))
+
+	ctx := d.param.Context
+	if ctx == nil {
+		time.Sleep(delay)
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serial downloads the URL to the file starting at offset, resuming up to param.Retries times.
+// If res is not nil, it is the response to the first request.
+func (d *fileDownload) serial(offset int64, res *http.Response) error {
+	for attempt := 0; ; attempt++ {
+		var err error
+
+		if res == nil {
+			res, err = d.request(offset, -1)
+			if err != nil {
+				if attempt < d.param.Retries {
+					if err = d.wait(attempt); err != nil {
+						return err
+					}
+					continue
+				}
+				return err
+			}
+		}
+
+		total := int64(-1)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			if offset != 0 {
+				// Server does not support Range requests, start over.
+				d.p.rewind(offset)
+				offset = 0
+				if err = d.f.Truncate(0); err != nil {
+					_ = res.Body.Close()
+					return err
+				}
+				if d.h != nil {
+					d.h.Reset()
+				}
+			}
+			total = res.ContentLength
+		case http.StatusPartialContent:
+			var start int64
+			start, total, err = contentRange(res)
+			if err == nil && start != offset {
+				err = fmt.Errorf("unexpected Content-Range start %d, expected %d", start, offset)
+			}
+		case http.StatusRequestedRangeNotSatisfiable:
+			if offset != 0 {
+				// Local file is already complete
+				_ = res.Body.Close()
+				d.progress(offset, offset)
+				return nil
+			}
+			err = errors.New(res.Status)
+		default:
+			err = errors.New(res.Status)
+		}
+
+		if err != nil {
+			_ = res.Body.Close()
+			return err
+		}
+
+		d.progress(offset, total)
+
+		var w io.Writer = &offsetWriter{d.f, offset}
+		if d.h != nil {
+			w = io.MultiWriter(w, d.h)
+		}
+		if d.p != nil {
+			w = progressWriter{w, d.p}
+		}
+
+		n, err := io.Copy(w, res.Body)
+		_ = res.Body.Close()
+		res = nil
+		offset += n
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= d.param.Retries {
+			return err
+		}
+
+		if err = d.wait(attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// chunk downloads the given range, end is inclusive, resuming up to param.Retries times.
+// If res is not nil, it is the response to the first request.
+func (d *fileDownload) chunk(start, end int64, res *http.Response) error {
+	for attempt := 0; ; attempt++ {
+		var err error
+
+		if res == nil {
+			res, err = d.request(start, end)
+			if err != nil {
+				if attempt < d.param.Retries {
+					if err = d.wait(attempt); err != nil {
+						return err
+					}
+					continue
+				}
+				return err
+			}
+		}
+
+		if res.StatusCode != http.StatusPartialContent {
+			_ = res.Body.Close()
+			return errors.New(res.Status)
+		}
+
+		w := progressWriter{&offsetWriter{d.f, start}, d.p}
+		n, err := io.Copy(w, io.LimitReader(res.Body, end-start+1))
+		_ = res.Body.Close()
+		res = nil
+		start += n
+
+		if err == nil && start <= end {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= d.param.Retries {
+			return err
+		}
+
+		if err = d.wait(attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// parallel downloads the URL to the file starting at offset, using concurrent range requests.
+// If the server does not support range requests, the download falls back to serial.
+func (d *fileDownload) parallel(offset int64) error {
+	size := d.param.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	res, err := d.request(offset, offset+size-1)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusPartialContent {
+		return d.serial(offset, res)
+	}
+
+	start, total, err := contentRange(res)
+	if err == nil && start != offset {
+		err = fmt.Errorf("unexpected Content-Range start %d, expected %d", start, offset)
+	}
+	if err != nil {
+		_ = res.Body.Close()
+		return err
+	}
+
+	if total < 0 {
+		_ = res.Body.Close()
+		return d.serial(offset, nil)
+	}
+
+	d.progress(offset, total)
+
+	type chunk struct {
+		start, end int64
+		res        *http.Response
+	}
+
+	var chunks []chunk
+	for s := offset; s < total; s += size {
+		e := s + size - 1
+		if e >= total {
+			e = total - 1
+		}
+		chunks = append(chunks, chunk{start: s, end: e})
+	}
+	chunks[0].res = res
+
+	errs := make([]error, len(chunks))
+	work := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.param.Parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				c := chunks[i]
+				errs[i] = d.chunk(c.start, c.end, c.res)
+			}
+		}()
+	}
+
+	for i := range chunks {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			// Truncate to the contiguous prefix that completed, such that Resume can continue from there.
+			_ = d.f.Truncate(chunks[i].start)
+			return err
+		}
+	}
+
+	// Extend file to its total size, for example if total is 0
+	if err = d.f.Truncate(total); err != nil {
+		return err
+	}
+
+	if d.h != nil {
+		if _, err = d.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = io.Copy(d.h, d.f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DownloadFile GETs the given URL to a local file.
+// If param.Resume is true and the file exists, the download continues from the end of the file using a Range request.
+// If the connection is dropped, the download is resumed up to param.Retries times.
+// If param.Parallel is greater than 1, ranges of param.ChunkSize are downloaded concurrently.
+// Servers that do not support Range requests fall back to a single request.
+func (c *Client) DownloadFile(file string, u *url.URL, param *Download) error {
+	var err error
+	if param == nil {
+		param = &DefaultDownload
+	}
+
+	flag := os.O_RDWR | os.O_CREATE
+	if !param.Resume {
+		flag |= os.O_TRUNC
+	}
+
+	fh, err := os.OpenFile(file, flag, 0666)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	d := &fileDownload{
+		c:     c,
+		u:     u,
+		param: param,
+		f:     fh,
+		h:     param.Hash,
+	}
+
+	if d.h == nil && param.Checksum != "" {
+		d.h = sha256.New()
+	}
+
+	var offset int64
+	if param.Resume {
+		if offset, err = fh.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+
+		// Hash the existing content
+		if d.h != nil && param.Parallel <= 1 && offset != 0 {
+			if _, err = io.Copy(d.h, io.NewSectionReader(fh, 0, offset)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if param.Parallel > 1 {
+		err = d.parallel(offset)
+	} else {
+		err = d.serial(offset, nil)
+	}
+
+	// Mark progress reader as done, including any error.
+	d.p.finish(err)
+
+	if err != nil {
+		return err
+	}
+
+	if param.Checksum != "" {
+		sum := hex.EncodeToString(d.h.Sum(nil))
+		if sum != param.Checksum {
+			return &ChecksumError{Expected: param.Checksum, Actual: sum}
+		}
+	}
+
+	return fh.Close()
+}
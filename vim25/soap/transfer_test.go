@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soap_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// fileServer serves content with Range support, dropping the first drops connections half way.
+type fileServer struct {
+	sync.Mutex
+	content []byte
+	drops   int
+	ranges  bool
+	headers []string
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.headers = append(s.headers, r.Header.Get("Range"))
+	drop := s.drops > 0
+	s.drops--
+	s.Unlock()
+
+	if drop {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n", len(s.content))
+		_, _ = conn.Write(s.content[:len(s.content)/2])
+		_ = conn.Close()
+		return
+	}
+
+	if !s.ranges {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(s.content)))
+		_, _ = w.Write(s.content)
+		return
+	}
+
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.content))
+}
+
+type testSink struct {
+	last float32
+	done chan struct{}
+}
+
+func (s *testSink) Sink() chan<- progress.Report {
+	ch := make(chan progress.Report)
+	go func() {
+		for r := range ch {
+			s.last = r.Percentage()
+		}
+		close(s.done)
+	}()
+	return ch
+}
+
+func TestDownloadFile(t *testing.T) {
+	content := make([]byte, 10000)
+	_, _ = rand.Read(content)
+
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	dir, err := ioutil.TempDir("", "govmomi-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		name    string
+		server  *fileServer
+		partial int
+		param   soap.Download
+		err     bool
+	}{
+		{"default", &fileServer{ranges: true}, 0, soap.Download{Checksum: checksum}, false},
+		{"checksum mismatch", &fileServer{ranges: true}, 0, soap.Download{Checksum: "00"}, true},
+		{"resume", &fileServer{ranges: true}, 4000, soap.Download{Resume: true, Checksum: checksum}, false},
+		{"resume complete", &fileServer{ranges: true}, 10000, soap.Download{Resume: true, Checksum: checksum}, false},
+		{"resume no ranges", &fileServer{}, 4000, soap.Download{Resume: true, Checksum: checksum}, false},
+		{"retries", &fileServer{ranges: true, drops: 2}, 0, soap.Download{Retries: 2, Checksum: checksum}, false},
+		{"no retries", &fileServer{ranges: true, drops: 1}, 0, soap.Download{}, true},
+		{"parallel", &fileServer{ranges: true}, 0, soap.Download{Parallel: 4, ChunkSize: 999, Checksum: checksum}, false},
+		{"parallel resume", &fileServer{ranges: true}, 2500, soap.Download{Parallel: 3, ChunkSize: 1000, Resume: true, Checksum: checksum}, false},
+		{"parallel no ranges", &fileServer{}, 0, soap.Download{Parallel: 4, ChunkSize: 1000, Checksum: checksum}, false},
+	}
+
+	for _, test := range tests {
+		test.server.content = content
+		s := httptest.NewServer(test.server)
+
+		u, _ := url.Parse(s.URL)
+		c := soap.NewClient(u, true)
+
+		name := filepath.Join(dir, "file")
+		if err = ioutil.WriteFile(name, content[:test.partial], 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		param := test.param
+		param.Method = "GET"
+
+		sink := &testSink{done: make(chan struct{})}
+		param.Progress = sink
+
+		err = c.DownloadFile(name, u, &param)
+		s.Close()
+
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		<-sink.done
+		if sink.last != 100 {
+			t.Errorf("%s: progress=%f", test.name, sink.last)
+		}
+
+		b, _ := ioutil.ReadFile(name)
+		if !bytes.Equal(b, content) {
+			t.Errorf("%s: content mismatch (%d bytes)", test.name, len(b))
+		}
+
+		if test.partial != 0 && test.server.ranges && test.server.headers[0] != fmt.Sprintf("bytes=%d-", test.partial) {
+			if test.param.Parallel <= 1 {
+				t.Errorf("%s: Range=%q", test.name, test.server.headers[0])
+			}
+		}
+
+		if test.param.Parallel > 1 && test.server.ranges {
+			chunks := (len(content) - test.partial + int(test.param.ChunkSize) - 1) / int(test.param.ChunkSize)
+			if len(test.server.headers) != chunks {
+				t.Errorf("%s: %d requests, expected %d", test.name, len(test.server.headers), chunks)
+			}
+		}
+	}
+}
+
+func TestDownloadFileCanceled(t *testing.T) {
+	server := &fileServer{content: make([]byte, 10000), ranges: true, drops: 1000}
+	s := httptest.NewServer(server)
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := soap.NewClient(u, true)
+
+	f, err := ioutil.TempFile("", "govmomi-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	defer os.Remove(f.Name())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	param := soap.DefaultDownload
+	param.Retries = 100
+	param.Context = ctx
+
+	start := time.Now()
+
+	err = c.DownloadFile(f.Name(), u, &param)
+	if err != context.DeadlineExceeded {
+		t.Errorf("err=%v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("elapsed=%s", elapsed)
+	}
+
+	server.Lock()
+	requests := len(server.headers)
+	server.Unlock()
+
+	// Without a delay between attempts, all retries would be made within the timeout
+	if requests > 10 {
+		t.Errorf("%d requests", requests)
+	}
+}
+
+func TestUploadHash(t *testing.T) {
+	content := []byte("upload content")
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := soap.NewClient(u, true)
+
+	param := soap.DefaultUpload
+	param.ContentLength = int64(len(content))
+	param.Hash = sha256.New()
+
+	if err := c.Upload(bytes.NewReader(content), u, &param); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(content)
+	if !bytes.Equal(param.Hash.Sum(nil), sum[:]) {
+		t.Error("hash mismatch")
+	}
+}
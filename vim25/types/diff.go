@@ -0,0 +1,247 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	diffTimeType = reflect.TypeOf((*time.Time)(nil)).Elem()
+	diffRefType  = reflect.TypeOf((*ManagedObjectReference)(nil)).Elem()
+)
+
+// Diff compares a and b, which must be of the same type, such as two VirtualMachineConfigSpec
+// or two mo.VirtualMachine values, and returns the changes needed to turn a into b.
+//
+// Change names use the vSphere property path syntax, where property names are taken from the
+// `mo` or `xml` struct field tags, for example "config.hardware.device[4000].backing.fileName".
+// Arrays of data objects with a Key field, such as devices and option values, are compared
+// by key: elements only in a are reported with op "remove", elements only in b with op "add",
+// and elements in both are compared property by property. Other changes are reported with
+// op "assign" and the value from b, which is nil if the property is unset in b.
+// As in a WaitForUpdates response, values are not pointers, for example the "add" value
+// of a VirtualDisk is a VirtualDisk rather than a *VirtualDisk.
+func Diff(a, b interface{}) ([]PropertyChange, error) {
+	va := reflect.ValueOf(a)
+	vb := reflect.ValueOf(b)
+
+	if !va.IsValid() || !vb.IsValid() {
+		return nil, fmt.Errorf("diff: values must not be nil")
+	}
+
+	if va.Type() != vb.Type() {
+		return nil, fmt.Errorf("diff: %s and %s are not of the same type", va.Type(), vb.Type())
+	}
+
+	var d differ
+
+	d.value("", deref(va), deref(vb))
+
+	return d.changes, nil
+}
+
+type differ struct {
+	changes []PropertyChange
+}
+
+// deref returns the value pointed to by v, or v itself if v is not a pointer or is nil
+func deref(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	}
+	return false
+}
+
+// add appends a change, where the value is dereferenced as it would be in a WaitForUpdates response
+func (d *differ) add(name string, op PropertyChangeOp, v reflect.Value) {
+	var val AnyType
+	if v = deref(v); v.IsValid() && !isNil(v) {
+		val = v.Interface()
+	}
+
+	d.changes = append(d.changes, PropertyChange{Name: name, Op: op, Val: val})
+}
+
+// propertyName returns the property name of a struct field, from its `mo` or `xml` tag.
+func propertyName(f reflect.StructField) string {
+	tag := f.Tag.Get("mo")
+	if tag == "" {
+		tag = f.Tag.Get("xml")
+	}
+
+	return strings.Split(tag, ",")[0]
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (d *differ) value(path string, a, b reflect.Value) {
+	switch a.Kind() {
+	case reflect.Slice:
+		if a.Len() == 0 && b.Len() == 0 {
+			return
+		}
+		if d.keyed(path, a, b) {
+			return
+		}
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, PropertyChangeOpAssign, b)
+			}
+			return
+		}
+
+		ea, eb := deref(a), deref(b)
+		if ea.Type() != eb.Type() {
+			d.add(path, PropertyChangeOpAssign, b)
+			return
+		}
+
+		d.value(path, ea, eb)
+		return
+	case reflect.Struct:
+		if a.Type() != diffTimeType && a.Type() != diffRefType {
+			d.fields(path, a, b)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		d.add(path, PropertyChangeOpAssign, b)
+	}
+}
+
+// fields compares the fields of structs a and b, descending into embedded structs.
+func (d *differ) fields(path string, a, b reflect.Value) {
+	t := a.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			d.fields(path, a.Field(i), b.Field(i))
+			continue
+		}
+
+		name := propertyName(f)
+		if name == "" {
+			continue
+		}
+
+		d.value(join(path, name), a.Field(i), b.Field(i))
+	}
+}
+
+// ElementKey returns the Key field of obj, a data object array element such as a VirtualDevice,
+// formatted as a property path index, for example [4000] or ["foo"].
+func ElementKey(obj interface{}) (string, bool) {
+	return elementKey(reflect.ValueOf(obj))
+}
+
+// elementKey returns the Key field of a data object array element, formatted as a property path index
+func elementKey(v reflect.Value) (string, bool) {
+	v = deref(v)
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	k := v.FieldByName("Key")
+	if !k.IsValid() {
+		return "", false
+	}
+
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("[%d]", k.Int()), true
+	case reflect.String:
+		return "[" + strconv.Quote(k.String()) + "]", true
+	}
+
+	return "", false
+}
+
+// elementKeys returns the keys of slice v, or false if any element does not have a unique key
+func elementKeys(v reflect.Value) ([]string, map[string]int, bool) {
+	keys := make([]string, v.Len())
+	index := make(map[string]int, v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		key, ok := elementKey(v.Index(i))
+		if !ok {
+			return nil, nil, false
+		}
+
+		if _, dup := index[key]; dup {
+			return nil, nil, false
+		}
+
+		keys[i] = key
+		index[key] = i
+	}
+
+	return keys, index, true
+}
+
+// keyed compares slices a and b by element key, returning false if either slice is not keyed.
+func (d *differ) keyed(path string, a, b reflect.Value) bool {
+	akeys, aindex, ok := elementKeys(a)
+	if !ok {
+		return false
+	}
+
+	bkeys, bindex, ok := elementKeys(b)
+	if !ok {
+		return false
+	}
+
+	for i, key := range akeys {
+		if j, ok := bindex[key]; ok {
+			d.value(path+key, a.Index(i), b.Index(j))
+		} else {
+			d.add(path+key, PropertyChangeOpRemove, reflect.Value{})
+		}
+	}
+
+	for j, key := range bkeys {
+		if _, ok := aindex[key]; !ok {
+			d.add(path+key, PropertyChangeOpAdd, b.Index(j))
+		}
+	}
+
+	return true
+}
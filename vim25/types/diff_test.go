@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func diffConfig() *VirtualMachineConfigInfo {
+	return &VirtualMachineConfigInfo{
+		Name:     "vm-001",
+		GuestId:  "otherGuest",
+		Template: false,
+		Files:    VirtualMachineFileInfo{VmPathName: "[datastore1] vm-001/vm-001.vmx"},
+		Hardware: VirtualHardware{
+			NumCPU:   1,
+			MemoryMB: 128,
+			Device: []BaseVirtualDevice{
+				&VirtualLsiLogicController{VirtualSCSIController{
+					VirtualController: VirtualController{
+						VirtualDevice: VirtualDevice{Key: 1000},
+					},
+				}},
+				&VirtualDisk{
+					VirtualDevice: VirtualDevice{
+						Key:           2000,
+						ControllerKey: 1000,
+						Backing: &VirtualDiskFlatVer2BackingInfo{
+							VirtualDeviceFileBackingInfo: VirtualDeviceFileBackingInfo{
+								FileName: "[datastore1] vm-001/disk.vmdk",
+							},
+						},
+					},
+					CapacityInKB: 1024,
+				},
+			},
+		},
+		ExtraConfig: []BaseOptionValue{
+			&OptionValue{Key: "guestinfo.a", Value: "1"},
+			&OptionValue{Key: "guestinfo.b", Value: "2"},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := diffConfig()
+	b := diffConfig()
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %#v", changes)
+	}
+
+	b.Name = "vm-002"
+	b.Hardware.MemoryMB = 256
+	b.Files.SuspendDirectory = "[datastore2]"
+	disk := b.Hardware.Device[1].(*VirtualDisk)
+	disk.Backing.(*VirtualDiskFlatVer2BackingInfo).FileName = "[datastore2] vm-001/disk.vmdk"
+	disk.UnitNumber = new(int32)
+	cdrom := &VirtualCdrom{VirtualDevice: VirtualDevice{Key: 3000}}
+	b.Hardware.Device = append(b.Hardware.Device[1:], cdrom)
+	b.ExtraConfig[1].GetOptionValue().Value = int32(2)
+	b.ExtraConfig = append(b.ExtraConfig, &OptionValue{Key: "guestinfo.c", Value: "3"})
+	b.Tools = &ToolsConfigInfo{ToolsVersion: 1}
+
+	changes, err = Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []PropertyChange{
+		{Name: "name", Op: PropertyChangeOpAssign, Val: "vm-002"},
+		{Name: "files.suspendDirectory", Op: PropertyChangeOpAssign, Val: "[datastore2]"},
+		{Name: "tools", Op: PropertyChangeOpAssign, Val: *b.Tools},
+		{Name: "hardware.memoryMB", Op: PropertyChangeOpAssign, Val: int32(256)},
+		{Name: "hardware.device[1000]", Op: PropertyChangeOpRemove},
+		{Name: "hardware.device[2000].backing.fileName", Op: PropertyChangeOpAssign, Val: "[datastore2] vm-001/disk.vmdk"},
+		{Name: "hardware.device[2000].unitNumber", Op: PropertyChangeOpAssign, Val: int32(0)},
+		{Name: "hardware.device[3000]", Op: PropertyChangeOpAdd, Val: *cdrom},
+		{Name: `extraConfig["guestinfo.b"].value`, Op: PropertyChangeOpAssign, Val: int32(2)},
+		{Name: `extraConfig["guestinfo.c"]`, Op: PropertyChangeOpAdd, Val: *b.ExtraConfig[2].GetOptionValue()},
+	}
+
+	if len(changes) != len(expect) {
+		t.Fatalf("expected %d changes, got %d: %#v", len(expect), len(changes), changes)
+	}
+
+	for i := range expect {
+		if !reflect.DeepEqual(changes[i], expect[i]) {
+			t.Errorf("%d: expected %#v, got %#v", i, expect[i], changes[i])
+		}
+	}
+
+	// Unset properties are assigned nil
+	changes, err = Diff(b, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range changes {
+		if c.Name == "tools" && c.Val != nil {
+			t.Errorf("tools=%#v", c.Val)
+		}
+	}
+
+	if _, err = Diff(a, *b); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestElementKey(t *testing.T) {
+	tests := []struct {
+		obj interface{}
+		key string
+		ok  bool
+	}{
+		{&VirtualDisk{VirtualDevice: VirtualDevice{Key: 2000}}, "[2000]", true},
+		{BaseVirtualDevice(&VirtualE1000{VirtualEthernetCard: VirtualEthernetCard{VirtualDevice: VirtualDevice{Key: 4000}}}), "[4000]", true},
+		{&OptionValue{Key: "foo"}, `["foo"]`, true},
+		{VirtualMachineFileInfo{}, "", false},
+		{nil, "", false},
+	}
+
+	for _, test := range tests {
+		key, ok := ElementKey(test.obj)
+		if key != test.key || ok != test.ok {
+			t.Errorf("%T: key=%q ok=%t", test.obj, key, ok)
+		}
+	}
+}
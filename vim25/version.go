@@ -0,0 +1,224 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vim25
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// Version is a dotted API version, such as "6.0" or "5.5.0".
+type Version []int
+
+// ParseVersion parses a dotted API version.
+// Non-numeric components, such as the "x" in development build versions like "6.x",
+// compare greater than any released version.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+
+	for _, p := range strings.Split(s, ".") {
+		if p == "x" {
+			v = append(v, math.MaxInt32)
+			continue
+		}
+
+		i, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+
+		v = append(v, i)
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 if v is less than, equal to or greater than u.
+// Missing trailing components are treated as 0, such that "6.0" and "6.0.0" are equal.
+func (v Version) Compare(u Version) int {
+	n := len(v)
+	if len(u) > n {
+		n = len(u)
+	}
+
+	for i := 0; i < n; i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(u) {
+			b = u[i]
+		}
+
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// AtLeast returns true if v is greater than or equal to the version string s.
+// False is returned if s is not a valid version.
+func (v Version) AtLeast(s string) bool {
+	u, err := ParseVersion(s)
+	if err != nil {
+		return false
+	}
+
+	return v.Compare(u) >= 0
+}
+
+func (v Version) String() string {
+	s := make([]string, len(v))
+	for i, n := range v {
+		if n == math.MaxInt32 {
+			s[i] = "x"
+		} else {
+			s[i] = strconv.Itoa(n)
+		}
+	}
+	return strings.Join(s, ".")
+}
+
+// APIVersion returns the API version of the server the client is connected to,
+// as reported by ServiceContent.About.ApiVersion.
+// A nil Version is returned if the server version cannot be parsed.
+// Features can be gated using, for example: c.APIVersion().AtLeast("6.0")
+func (c *Client) APIVersion() Version {
+	v, _ := ParseVersion(c.ServiceContent.About.ApiVersion)
+	return v
+}
+
+// ServiceVersions is the content of the server's /sdk/vimServiceVersions.xml file.
+type ServiceVersions struct {
+	Namespaces []ServiceNamespace `xml:"namespace"`
+}
+
+// ServiceNamespace lists the versions supported by the server for a vim namespace.
+type ServiceNamespace struct {
+	Name          string   `xml:"name"`
+	Version       string   `xml:"version"`
+	PriorVersions []string `xml:"priorVersions>version"`
+}
+
+// Versions returns Version followed by PriorVersions.
+func (n *ServiceNamespace) Versions() []string {
+	return append([]string{n.Version}, n.PriorVersions...)
+}
+
+// Namespace returns the ServiceNamespace with the given name, or nil if not found.
+func (s *ServiceVersions) Namespace(name string) *ServiceNamespace {
+	for i := range s.Namespaces {
+		if s.Namespaces[i].Name == name {
+			return &s.Namespaces[i]
+		}
+	}
+	return nil
+}
+
+// GetServiceVersions fetches vimServiceVersions.xml from the directory of the client's SDK URL.
+func GetServiceVersions(ctx context.Context, c *soap.Client) (*ServiceVersions, error) {
+	u := c.URL()
+	u.Path = path.Join(u.Path, "vimServiceVersions.xml")
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &soap.StatusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	var v ServiceVersions
+
+	if err = xml.NewDecoder(res.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// NegotiateVersion returns the highest of the server versions that is not greater than max.
+// If all server versions are greater than max, max is returned.
+// Invalid versions are ignored.
+func NegotiateVersion(max string, server []string) string {
+	limit, err := ParseVersion(max)
+	if err != nil {
+		return max
+	}
+
+	var best Version
+	var version string
+
+	for _, s := range server {
+		v, err := ParseVersion(s)
+		if err != nil || v.Compare(limit) > 0 {
+			continue
+		}
+
+		if best == nil || v.Compare(best) > 0 {
+			best = v
+			version = s
+		}
+	}
+
+	if version == "" {
+		return max
+	}
+
+	return version
+}
+
+// UseServiceVersion sets soap.Client.Version to the highest version supported by both
+// the server and this library (soap.DefaultVimVersion).
+// The server's versions are read from vimServiceVersions.xml for the client's Namespace,
+// falling back to ServiceContent.About.ApiVersion if the file is not available.
+func (c *Client) UseServiceVersion(ctx context.Context) error {
+	server := []string{c.ServiceContent.About.ApiVersion}
+
+	sv, err := GetServiceVersions(ctx, c.Client)
+	if err == nil {
+		if ns := sv.Namespace(c.Namespace); ns != nil {
+			server = ns.Versions()
+		}
+	} else if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	c.Client.Version = NegotiateVersion(soap.DefaultVimVersion, server)
+
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vim25
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		c    int
+	}{
+		{"5.5", "6.0", -1},
+		{"6.0", "6.0.0", 0},
+		{"6.5", "6.0", 1},
+		{"6.x", "6.5", 1},
+		{"5.5", "5.5.0.1", -1},
+	}
+
+	for _, test := range tests {
+		a, err := ParseVersion(test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c := a.Compare(b); c != test.c {
+			t.Errorf("%s.Compare(%s)=%d", test.a, test.b, c)
+		}
+	}
+
+	if _, err := ParseVersion("6.0-beta"); err == nil {
+		t.Error("expected error")
+	}
+
+	c := &Client{}
+	c.ServiceContent.About.ApiVersion = "5.5"
+	if !c.APIVersion().AtLeast("5.1") || c.APIVersion().AtLeast("6.0") {
+		t.Errorf("APIVersion=%s", c.APIVersion())
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		server []string
+		expect string
+	}{
+		{[]string{"6.5", "6.0", "5.5"}, "6.0"},
+		{[]string{"5.5", "5.1", "5.0"}, "5.5"},
+		{[]string{"7.0"}, "6.0"},
+		{[]string{"invalid", "5.1"}, "5.1"},
+		{nil, "6.0"},
+	}
+
+	for _, test := range tests {
+		if v := NegotiateVersion("6.0", test.server); v != test.expect {
+			t.Errorf("%v: %s", test.server, v)
+		}
+	}
+}
+
+const serviceVersions = `<?xml version="1.0" encoding="UTF-8" ?>
+<namespaces version="1.0">
+ <namespace>
+  <name>urn:vim25</name>
+  <version>5.5</version>
+  <priorVersions>
+   <version>5.1</version>
+   <version>5.0</version>
+  </priorVersions>
+ </namespace>
+ <namespace>
+  <name>urn:vim2</name>
+  <version>2.5</version>
+ </namespace>
+</namespaces>`
+
+func TestUseServiceVersion(t *testing.T) {
+	found := true
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !found || r.URL.Path != "/sdk/vimServiceVersions.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(serviceVersions))
+	}))
+	defer s.Close()
+
+	u, err := soap.ParseURL(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{Client: soap.NewClient(u, false)}
+	c.ServiceContent.About.ApiVersion = "5.1"
+
+	ctx := context.Background()
+
+	if err = c.UseServiceVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Version != "5.5" {
+		t.Errorf("Version=%s", c.Version)
+	}
+
+	// Fall back to About.ApiVersion
+	found = false
+
+	if err = c.UseServiceVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Version != "5.1" {
+		t.Errorf("Version=%s", c.Version)
+	}
+}
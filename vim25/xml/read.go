@@ -233,27 +233,29 @@ func (p *Decoder) unmarshalAttr(val reflect.Value, attr Attr) error {
 		val = val.Elem()
 	}
 
-	if val.CanInterface() && val.Type().Implements(unmarshalerAttrType) {
+	flags := getDecodeFlags(val.Type())
+
+	if flags&dUnmarshalerAttr != 0 && val.CanInterface() {
 		// This is an unmarshaler with a non-pointer receiver,
 		// so it's likely to be incorrect, but we do what we're told.
 		return val.Interface().(UnmarshalerAttr).UnmarshalXMLAttr(attr)
 	}
-	if val.CanAddr() {
+	if flags&dPtrUnmarshalerAttr != 0 && val.CanAddr() {
 		pv := val.Addr()
-		if pv.CanInterface() && pv.Type().Implements(unmarshalerAttrType) {
+		if pv.CanInterface() {
 			return pv.Interface().(UnmarshalerAttr).UnmarshalXMLAttr(attr)
 		}
 	}
 
 	// Not an UnmarshalerAttr; try encoding.TextUnmarshaler.
-	if val.CanInterface() && val.Type().Implements(textUnmarshalerType) {
+	if flags&dTextUnmarshaler != 0 && val.CanInterface() {
 		// This is an unmarshaler with a non-pointer receiver,
 		// so it's likely to be incorrect, but we do what we're told.
 		return val.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(attr.Value))
 	}
-	if val.CanAddr() {
+	if flags&dPtrTextUnmarshaler != 0 && val.CanAddr() {
 		pv := val.Addr()
-		if pv.CanInterface() && pv.Type().Implements(textUnmarshalerType) {
+		if pv.CanInterface() {
 			return pv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(attr.Value))
 		}
 	}
@@ -366,26 +368,28 @@ func (p *Decoder) unmarshal(val reflect.Value, start *StartElement) error {
 		val = val.Elem()
 	}
 
-	if val.CanInterface() && val.Type().Implements(unmarshalerType) {
+	flags := getDecodeFlags(val.Type())
+
+	if flags&dUnmarshaler != 0 && val.CanInterface() {
 		// This is an unmarshaler with a non-pointer receiver,
 		// so it's likely to be incorrect, but we do what we're told.
 		return p.unmarshalInterface(val.Interface().(Unmarshaler), start)
 	}
 
-	if val.CanAddr() {
+	if flags&dPtrUnmarshaler != 0 && val.CanAddr() {
 		pv := val.Addr()
-		if pv.CanInterface() && pv.Type().Implements(unmarshalerType) {
+		if pv.CanInterface() {
 			return p.unmarshalInterface(pv.Interface().(Unmarshaler), start)
 		}
 	}
 
-	if val.CanInterface() && val.Type().Implements(textUnmarshalerType) {
+	if flags&dTextUnmarshaler != 0 && val.CanInterface() {
 		return p.unmarshalTextInterface(val.Interface().(encoding.TextUnmarshaler), start)
 	}
 
-	if val.CanAddr() {
+	if flags&dPtrTextUnmarshaler != 0 && val.CanAddr() {
 		pv := val.Addr()
-		if pv.CanInterface() && pv.Type().Implements(textUnmarshalerType) {
+		if pv.CanInterface() {
 			return p.unmarshalTextInterface(pv.Interface().(encoding.TextUnmarshaler), start)
 		}
 	}
@@ -482,7 +486,7 @@ func (p *Decoder) unmarshal(val reflect.Value, start *StartElement) error {
 
 		// Assign attributes.
 		// Also, determine whether we need to save character data or comments.
-		for i := range tinfo.fields {
+		for _, i := range tinfo.others {
 			finfo := &tinfo.fields[i]
 			switch finfo.flags & fMode {
 			case fAttr:
@@ -698,10 +702,16 @@ func copyValue(dst reflect.Value, src []byte) (err error) {
 // still untouched because start is uninteresting for sv's fields.
 func (p *Decoder) unmarshalPath(tinfo *typeInfo, sv reflect.Value, parents []string, start *StartElement) (consumed bool, err error) {
 	recurse := false
+
+	// Only fields whose path starts with the outermost element can match.
+	name := start.Name.Local
+	if len(parents) != 0 {
+		name = parents[0]
+	}
 Loop:
-	for i := range tinfo.fields {
+	for _, i := range tinfo.elements[name] {
 		finfo := &tinfo.fields[i]
-		if finfo.flags&fElement == 0 || len(finfo.parents) < len(parents) || finfo.xmlns != "" && finfo.xmlns != start.Name.Space {
+		if len(finfo.parents) < len(parents) || finfo.xmlns != "" && finfo.xmlns != start.Name.Space {
 			continue
 		}
 		for j := range parents {
@@ -760,3 +760,30 @@ func TestNegativeValuesUnsignedFields(t *testing.T) {
 		}
 	}
 }
+
+func TestInternedNames(t *testing.T) {
+	// Attribute values are interned separately from names,
+	// so a value must not cause an invalid name to be accepted.
+	d := NewDecoder(strings.NewReader(`<a x="1b"><b y="1b"/><1b/></a>`))
+	var err error
+	for err == nil {
+		_, err = d.Token()
+	}
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+
+	type T struct {
+		A []struct {
+			X string `xml:"x,attr"`
+		} `xml:"a"`
+	}
+
+	var v T
+	if err = Unmarshal([]byte(`<T><a x="1"></a><a></a><a x="1"/></T>`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.A) != 3 || v.A[0].X != "1" || v.A[1].X != "" || v.A[2].X != "1" {
+		t.Errorf("%#v", v)
+	}
+}
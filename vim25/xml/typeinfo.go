@@ -15,6 +15,13 @@ import (
 type typeInfo struct {
 	xmlname *fieldInfo
 	fields  []fieldInfo
+
+	// Decode plan, so that unmarshal need not scan all fields for every element.
+	// elements maps the local name of an element field, or of the first parent
+	// in its path, to the indices of the matching fields in fields.
+	// others holds the indices of all fields that are not plain elements.
+	elements map[string][]int
+	others   []int
 }
 
 // fieldInfo holds details for the xml representation of a single field.
@@ -105,12 +112,82 @@ func getTypeInfo(typ reflect.Type) (*typeInfo, error) {
 			}
 		}
 	}
+	tinfo.plan()
 	tinfoLock.Lock()
 	tinfoMap[typ] = tinfo
 	tinfoLock.Unlock()
 	return tinfo, nil
 }
 
+// plan indexes tinfo.fields for unmarshal.
+func (tinfo *typeInfo) plan() {
+	for i := range tinfo.fields {
+		finfo := &tinfo.fields[i]
+		if finfo.flags&fMode != fElement {
+			tinfo.others = append(tinfo.others, i)
+		}
+		if finfo.flags&fElement == 0 {
+			continue
+		}
+		name := finfo.name
+		if len(finfo.parents) != 0 {
+			name = finfo.parents[0]
+		}
+		if tinfo.elements == nil {
+			tinfo.elements = make(map[string][]int)
+		}
+		tinfo.elements[name] = append(tinfo.elements[name], i)
+	}
+}
+
+// decodeFlags records which unmarshaling interfaces a type implements.
+type decodeFlags int
+
+const (
+	dUnmarshaler decodeFlags = 1 << iota
+	dPtrUnmarshaler
+	dTextUnmarshaler
+	dPtrTextUnmarshaler
+	dUnmarshalerAttr
+	dPtrUnmarshalerAttr
+)
+
+var dflagsMap = make(map[reflect.Type]decodeFlags)
+var dflagsLock sync.RWMutex
+
+// getDecodeFlags returns the decodeFlags for typ, caching the result
+// to avoid repeated calls to reflect.Type.Implements.
+func getDecodeFlags(typ reflect.Type) decodeFlags {
+	dflagsLock.RLock()
+	flags, ok := dflagsMap[typ]
+	dflagsLock.RUnlock()
+	if ok {
+		return flags
+	}
+
+	ptr := reflect.PtrTo(typ)
+	for _, x := range []struct {
+		t     reflect.Type
+		f, pf decodeFlags
+	}{
+		{unmarshalerType, dUnmarshaler, dPtrUnmarshaler},
+		{textUnmarshalerType, dTextUnmarshaler, dPtrTextUnmarshaler},
+		{unmarshalerAttrType, dUnmarshalerAttr, dPtrUnmarshalerAttr},
+	} {
+		if typ.Implements(x.t) {
+			flags |= x.f
+		}
+		if ptr.Implements(x.t) {
+			flags |= x.pf
+		}
+	}
+
+	dflagsLock.Lock()
+	dflagsMap[typ] = flags
+	dflagsLock.Unlock()
+	return flags
+}
+
 // structFieldInfo builds and returns a fieldInfo for f.
 func structFieldInfo(typ reflect.Type, f *reflect.StructField) (*fieldInfo, error) {
 	finfo := &fieldInfo{idx: f.Index}
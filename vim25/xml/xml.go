@@ -201,6 +201,8 @@ type Decoder struct {
 	err            error
 	line           int
 	unmarshalDepth int
+	names          map[string]string
+	values         map[string]string
 }
 
 // NewDecoder creates a new XML parser reading from r.
@@ -726,7 +728,6 @@ func (d *Decoder) rawToken() (Token, error) {
 		return nil, d.err
 	}
 
-	attr = make([]Attr, 0, 4)
 	for {
 		d.space()
 		if b, ok = d.mustgetc(); !ok {
@@ -749,7 +750,9 @@ func (d *Decoder) rawToken() (Token, error) {
 		d.ungetc(b)
 
 		n := len(attr)
-		if n >= cap(attr) {
+		if attr == nil {
+			attr = make([]Attr, 0, 4)
+		} else if n >= cap(attr) {
 			nattr := make([]Attr, n, 2*cap(attr))
 			copy(nattr, attr)
 			attr = nattr
@@ -780,9 +783,14 @@ func (d *Decoder) rawToken() (Token, error) {
 			if data == nil {
 				return nil, d.err
 			}
-			a.Value = string(data)
+			a.Value = d.internValue(data)
 		}
 	}
+	if attr == nil {
+		// Avoid allocating for elements without attributes;
+		// the slice has no capacity, so appending to it will not modify noAttr.
+		attr = noAttr
+	}
 	if empty {
 		d.needClose = true
 		d.toClose = name
@@ -790,6 +798,41 @@ func (d *Decoder) rawToken() (Token, error) {
 	return StartElement{name, attr}, nil
 }
 
+var noAttr = make([]Attr, 0)
+
+// maxInterned limits the number of strings a Decoder interns per table,
+// so that arbitrary input cannot grow the tables without bound.
+const maxInterned = 4096
+
+// maxInternLen is the maximum length of an interned attribute value.
+// Element and attribute names are interned regardless of their length.
+const maxInternLen = 64
+
+// intern returns b as a string, sharing the allocation with previous
+// occurrences of the same value in table m. Responses repeat the same element
+// names and attribute values, such as xsi:type, for every object they contain.
+func intern(m *map[string]string, b []byte) string {
+	if s, ok := (*m)[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	if len(*m) < maxInterned {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+		(*m)[s] = s
+	}
+	return s
+}
+
+// internValue returns the attribute value b as a string, interning short values.
+func (d *Decoder) internValue(b []byte) string {
+	if len(b) > maxInternLen {
+		return string(b)
+	}
+	return intern(&d.values, b)
+}
+
 func (d *Decoder) attrval() []byte {
 	b, ok := d.mustgetc()
 	if !ok {
@@ -1113,12 +1156,16 @@ func (d *Decoder) name() (s string, ok bool) {
 	}
 
 	// Now we check the characters.
-	s = d.buf.String()
-	if !isName([]byte(s)) {
-		d.err = d.syntaxError("invalid XML name: " + s)
+	// Interned names have already been checked.
+	b := d.buf.Bytes()
+	if s, ok = d.names[string(b)]; ok {
+		return s, true
+	}
+	if !isName(b) {
+		d.err = d.syntaxError("invalid XML name: " + string(b))
 		return "", false
 	}
-	return s, true
+	return intern(&d.names, b), true
 }
 
 // Read a name and append its bytes to d.buf.